@@ -0,0 +1,219 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"encoding/json"
+	"strconv"
+
+	v2 "github.com/cnvergence/facturnetes/api/v2"
+	"sigs.k8s.io/controller-runtime/pkg/conversion"
+)
+
+// conversionDataAnnotation keeps the v2 spec and status of an Invoice read
+// through v1, so that fields v1 cannot express survive a round trip.
+const conversionDataAnnotation = "facturnetes.cnvergence.io/conversion-data"
+
+// conversionData is the content of the conversionDataAnnotation.
+type conversionData struct {
+	Spec   v2.InvoiceSpec   `json:"spec"`
+	Status v2.InvoiceStatus `json:"status"`
+}
+
+// ConvertTo converts this Invoice to the Hub version (v2).
+func (src *Invoice) ConvertTo(dstRaw conversion.Hub) error {
+	dst := dstRaw.(*v2.Invoice)
+
+	dst.ObjectMeta = src.ObjectMeta
+	restored := conversionData{}
+	if data, ok := src.Annotations[conversionDataAnnotation]; ok {
+		if err := json.Unmarshal([]byte(data), &restored); err != nil {
+			return err
+		}
+		dst.Annotations = make(map[string]string, len(src.Annotations))
+		for k, v := range src.Annotations {
+			if k != conversionDataAnnotation {
+				dst.Annotations[k] = v
+			}
+		}
+	}
+
+	dst.Spec = restored.Spec
+	dst.Spec.Exposure = v2.Exposure{
+		PublicURL: src.Spec.Exposure.PublicURL,
+		Ingress:   v2.Ingress(src.Spec.Exposure.Ingress),
+	}
+	dst.Spec.Deployment = v2.Deployment{
+		Name:            src.Spec.Deployment.Name,
+		Image:           src.Spec.Deployment.Image,
+		ImagePullPolicy: src.Spec.Deployment.ImagePullPolicy,
+		PaidStamp:       restored.Spec.Deployment.PaidStamp,
+	}
+	dst.Spec.State = v2.State(src.Spec.State)
+
+	in := src.Spec.InvoiceData
+	out := &dst.Spec.InvoiceData
+	out.Number = in.Number
+	out.IssueDate = in.IssueDate
+	out.SaleDate = in.SaleDate
+	out.DueDate = in.DueDate
+	out.Notes = in.Notes
+	out.Company.Buyer.Name = in.Company.Buyer.Name
+	out.Company.Buyer.Address = in.Company.Buyer.Address
+	out.Company.Buyer.VAT = in.Company.Buyer.VAT
+	out.Company.Seller.Name = in.Company.Seller.Name
+	out.Company.Seller.Address = in.Company.Seller.Address
+	out.Company.Seller.VAT = in.Company.Seller.VAT
+	out.Bank = v2.Bank(in.Bank)
+	out.Currency = in.Currency
+	out.Signature = in.Signature
+	out.Options.FontFamily = in.Options.FontFamily
+
+	items := make([]*v2.Item, 0, len(in.Items))
+	for i, item := range in.Items {
+		if item == nil {
+			continue
+		}
+		converted := &v2.Item{
+			Description: item.Description,
+			Quantity:    decimalFromFloat(item.Quantity),
+			UnitPrice:   decimalFromFloat(item.UnitPrice),
+			VATRate:     decimalFromFloat(item.VATRate),
+		}
+		// Keep the exact decimals of the v2 object when v1 did not change them.
+		if i < len(restored.Spec.InvoiceData.Items) && restored.Spec.InvoiceData.Items[i] != nil {
+			prev := restored.Spec.InvoiceData.Items[i]
+			if sameDecimal(prev.Quantity, item.Quantity) {
+				converted.Quantity = prev.Quantity
+			}
+			if sameDecimal(prev.UnitPrice, item.UnitPrice) {
+				converted.UnitPrice = prev.UnitPrice
+			}
+			if sameDecimal(prev.VATRate, item.VATRate) {
+				converted.VATRate = prev.VATRate
+			}
+		}
+		items = append(items, converted)
+	}
+	out.Items = items
+
+	dst.Status = restored.Status
+	dst.Status.LastProcessedTime = src.Status.LastProcessedTime
+	dst.Status.ObservedGeneration = src.Status.ObservedGeneration
+	dst.Status.Message = src.Status.Message
+	dst.Status.Phase = v2.Phase(src.Status.Phase)
+	dst.Status.Endpoint = src.Status.Endpoint
+	dst.Status.State = v2.State(src.Status.State)
+	dst.Status.StateChangedTime = src.Status.StateChangedTime
+	dst.Status.History = nil
+	for _, t := range src.Status.History {
+		dst.Status.History = append(dst.Status.History, v2.StateTransition{
+			From: v2.State(t.From),
+			To:   v2.State(t.To),
+			Time: t.Time,
+		})
+	}
+	dst.Status.Conditions = src.Status.Conditions
+
+	return nil
+}
+
+// ConvertFrom converts from the Hub version (v2) to this version.
+func (dst *Invoice) ConvertFrom(srcRaw conversion.Hub) error {
+	src := srcRaw.(*v2.Invoice)
+
+	dst.ObjectMeta = src.ObjectMeta
+	data, err := json.Marshal(conversionData{Spec: src.Spec, Status: src.Status})
+	if err != nil {
+		return err
+	}
+	dst.Annotations = make(map[string]string, len(src.Annotations)+1)
+	for k, v := range src.Annotations {
+		dst.Annotations[k] = v
+	}
+	dst.Annotations[conversionDataAnnotation] = string(data)
+
+	dst.Spec.Exposure = Exposure{
+		PublicURL: src.Spec.Exposure.PublicURL,
+		Ingress:   Ingress(src.Spec.Exposure.Ingress),
+	}
+	dst.Spec.Deployment = Deployment{
+		Name:            src.Spec.Deployment.Name,
+		Image:           src.Spec.Deployment.Image,
+		ImagePullPolicy: src.Spec.Deployment.ImagePullPolicy,
+	}
+	dst.Spec.State = State(src.Spec.State)
+
+	in := src.Spec.InvoiceData
+	out := &dst.Spec.InvoiceData
+	out.Number = in.Number
+	out.IssueDate = in.IssueDate
+	out.SaleDate = in.SaleDate
+	out.DueDate = in.DueDate
+	out.Notes = in.Notes
+	out.Company.Buyer = Buyer{Name: in.Company.Buyer.Name, Address: in.Company.Buyer.Address, VAT: in.Company.Buyer.VAT}
+	out.Company.Seller = Seller{Name: in.Company.Seller.Name, Address: in.Company.Seller.Address, VAT: in.Company.Seller.VAT}
+	out.Bank = Bank(in.Bank)
+	out.Currency = in.Currency
+	out.Signature = in.Signature
+	out.Options.FontFamily = in.Options.FontFamily
+
+	out.Items = make([]*Item, 0, len(in.Items))
+	for _, item := range in.Items {
+		if item == nil {
+			continue
+		}
+		out.Items = append(out.Items, &Item{
+			Description: item.Description,
+			Quantity:    floatFromDecimal(item.Quantity),
+			UnitPrice:   floatFromDecimal(item.UnitPrice),
+			VATRate:     floatFromDecimal(item.VATRate),
+		})
+	}
+
+	dst.Status.LastProcessedTime = src.Status.LastProcessedTime
+	dst.Status.ObservedGeneration = src.Status.ObservedGeneration
+	dst.Status.Message = src.Status.Message
+	dst.Status.Phase = Phase(src.Status.Phase)
+	dst.Status.Endpoint = src.Status.Endpoint
+	dst.Status.State = State(src.Status.State)
+	dst.Status.StateChangedTime = src.Status.StateChangedTime
+	dst.Status.History = nil
+	for _, t := range src.Status.History {
+		dst.Status.History = append(dst.Status.History, StateTransition{
+			From: State(t.From),
+			To:   State(t.To),
+			Time: t.Time,
+		})
+	}
+	dst.Status.Conditions = src.Status.Conditions
+
+	return nil
+}
+
+func decimalFromFloat(f float64) v2.Decimal {
+	return v2.Decimal(strconv.FormatFloat(f, 'f', -1, 64))
+}
+
+func floatFromDecimal(d v2.Decimal) float64 {
+	f, _ := strconv.ParseFloat(string(d), 64)
+	return f
+}
+
+func sameDecimal(d v2.Decimal, f float64) bool {
+	return floatFromDecimal(d) == f
+}
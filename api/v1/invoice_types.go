@@ -28,9 +28,26 @@ var (
 	Success Phase = "Generated"
 )
 
+// State is the business lifecycle state of an Invoice.
+// +kubebuilder:validation:Enum=Draft;Issued;Sent;PartiallyPaid;Paid;Overdue;Cancelled
+type State string
+
+const (
+	Draft         State = "Draft"
+	Issued        State = "Issued"
+	Sent          State = "Sent"
+	PartiallyPaid State = "PartiallyPaid"
+	Paid          State = "Paid"
+	Overdue       State = "Overdue"
+	Cancelled     State = "Cancelled"
+)
+
 // +kubebuilder:object:root=true
 // +kubebuilder:subresource:status
+// +kubebuilder:deprecatedversion:warning="facturnetes.cnvergence.io/v1 Invoice is deprecated, use facturnetes.cnvergence.io/v2 with decimal amounts"
 // +kubebuilder:printcolumn:name="Status",type="string",JSONPath=".status.phase"
+// +kubebuilder:printcolumn:name="State",type="string",JSONPath=".status.state"
+// +kubebuilder:printcolumn:name="Due",type="string",JSONPath=".spec.invoiceData.dueDate"
 // +kubebuilder:printcolumn:name="Endpoint",type="string",JSONPath=".status.endpoint"
 // Invoice is the Schema for the invoices API
 type Invoice struct {
@@ -60,6 +77,13 @@ type InvoiceSpec struct {
 	Exposure   Exposure   `json:"exposure,omitempty"`
 	Deployment Deployment `json:"deployment,omitempty"`
 
+	// State requested for the invoice. Overdue is never requested, the
+	// controller derives it from the due date.
+	// +kubebuilder:validation:Enum=Draft;Issued;Sent;PartiallyPaid;Paid;Cancelled
+	// +kubebuilder:default:=Draft
+	// +optional
+	State State `json:"state,omitempty"`
+
 	InvoiceData InvoiceData `json:"invoiceData" yaml:"invoiceData"`
 }
 
@@ -73,6 +97,26 @@ type InvoiceStatus struct {
 	Message  string `json:"message,omitempty"`
 	Phase    Phase  `json:"phase,omitempty"`
 	Endpoint string `json:"endpoint,omitempty"`
+
+	// Current lifecycle state of the invoice.
+	State State `json:"state,omitempty"`
+	// Time of the last lifecycle state change.
+	StateChangedTime *metav1.Time `json:"stateChangedTime,omitempty"`
+	// History of lifecycle state changes, oldest first.
+	History []StateTransition `json:"history,omitempty"`
+
+	// Conditions describe the state of the rendered document and the viewer.
+	// +listType=map
+	// +listMapKey=type
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty" patchStrategy:"merge" patchMergeKey:"type"`
+}
+
+// StateTransition records a single lifecycle state change.
+type StateTransition struct {
+	From State       `json:"from,omitempty"`
+	To   State       `json:"to"`
+	Time metav1.Time `json:"time"`
 }
 
 type Deployment struct {
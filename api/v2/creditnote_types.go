@@ -0,0 +1,149 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v2
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// CorrectedItem is a line of a credit note.
+type CorrectedItem struct {
+	// Line is the number of the corrected line of the original invoice,
+	// starting from 1. An item without a line is added to the invoice.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	Line *int32 `json:"line,omitempty"`
+	// Negate cancels the original line.
+	// +optional
+	Negate bool `json:"negate,omitempty"`
+	// Corrected is the line as it should have been invoiced. It is required
+	// unless the line is negated.
+	// +optional
+	Corrected *Item `json:"corrected,omitempty"`
+}
+
+// CreditNoteSpec defines the correction of an issued invoice.
+type CreditNoteSpec struct {
+	// InvoiceRef names the corrected Invoice.
+	InvoiceRef corev1.LocalObjectReference `json:"invoiceRef"`
+
+	// Number is allocated from the InvoiceSequence when it is not set.
+	// +optional
+	Number string `json:"number,omitempty"`
+	// SequenceRef names the InvoiceSequence numbering the credit note. The
+	// default InvoiceSequence of the namespace is used when it is not set.
+	// +optional
+	SequenceRef *corev1.LocalObjectReference `json:"sequenceRef,omitempty"`
+
+	IssueDate string `json:"issueDate"`
+	// Reason of the correction, printed on the credit note.
+	Reason string `json:"reason"`
+
+	// +kubebuilder:validation:MinItems=1
+	Items []CorrectedItem `json:"items"`
+}
+
+// CreditNoteStatus defines the observed state of CreditNote
+type CreditNoteStatus struct {
+	ObservedGeneration int64  `json:"observedGeneration,omitempty"`
+	Message            string `json:"message,omitempty"`
+
+	// InvoiceNumber is the number of the corrected invoice.
+	// +optional
+	InvoiceNumber string `json:"invoiceNumber,omitempty"`
+	// Totals is the difference the credit note makes to the invoice totals
+	// as corrected by the credit notes issued before, negative when the
+	// invoice is credited. It is kept once the credit note is issued.
+	// +optional
+	Totals *InvoiceTotals `json:"totals,omitempty"`
+	// PDFSHA256 is the hex encoded SHA-256 digest of the rendered PDF. The
+	// controller never replaces the PDF once its digest is recorded, later
+	// changes to the spec are not applied.
+	// +optional
+	PDFSHA256 string `json:"pdfSHA256,omitempty"`
+	// IssuedTime is when the credit note was issued, ordering the
+	// corrections of the invoice.
+	// +optional
+	IssuedTime *metav1.Time `json:"issuedTime,omitempty"`
+
+	// +listType=map
+	// +listMapKey=type
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty" patchStrategy:"merge" patchMergeKey:"type"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Number",type="string",JSONPath=".spec.number"
+// +kubebuilder:printcolumn:name="Invoice",type="string",JSONPath=".spec.invoiceRef.name"
+// +kubebuilder:printcolumn:name="Total",type="string",JSONPath=".status.totals.total"
+// +kubebuilder:printcolumn:name="Ready",type="string",JSONPath=".status.conditions[?(@.type==\"Ready\")].status"
+// CreditNote is the Schema for the creditnotes API
+type CreditNote struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   CreditNoteSpec   `json:"spec,omitempty"`
+	Status CreditNoteStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// CreditNoteList contains a list of CreditNote
+type CreditNoteList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []CreditNote `json:"items"`
+}
+
+// Apply returns a copy of the invoice data with the corrections of the credit
+// note. Negated lines are kept with a zero quantity, added lines come last.
+func (s *CreditNoteSpec) Apply(data *InvoiceData) *InvoiceData {
+	corrected := data.DeepCopy()
+	corrected.Items = nil
+	for _, item := range data.Items {
+		if item != nil {
+			corrected.Items = append(corrected.Items, item.DeepCopy())
+		}
+	}
+
+	for _, c := range s.Items {
+		if c.Line == nil {
+			if c.Corrected != nil {
+				corrected.Items = append(corrected.Items, c.Corrected.DeepCopy())
+			}
+			continue
+		}
+		i := int(*c.Line) - 1
+		if i < 0 || i >= len(corrected.Items) {
+			continue
+		}
+		switch {
+		case c.Negate:
+			corrected.Items[i].Quantity = "0"
+		case c.Corrected != nil:
+			corrected.Items[i] = c.Corrected.DeepCopy()
+		}
+	}
+
+	return corrected
+}
+
+func init() {
+	SchemeBuilder.Register(&CreditNote{}, &CreditNoteList{})
+}
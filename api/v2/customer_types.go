@@ -0,0 +1,50 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v2
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// CustomerSpec defines the buyer data of a customer.
+type CustomerSpec struct {
+	Buyer `json:",inline"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:printcolumn:name="Buyer",type="string",JSONPath=".spec.name"
+// +kubebuilder:printcolumn:name="VAT",type="string",JSONPath=".spec.vat"
+// Customer is the Schema for the customers API
+type Customer struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec CustomerSpec `json:"spec,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// CustomerList contains a list of Customer
+type CustomerList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []Customer `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&Customer{}, &CustomerList{})
+}
@@ -0,0 +1,127 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v2
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// DunningLevel is a reminder sent when an invoice is overdue for a number of days.
+type DunningLevel struct {
+	// Name of the level, used in the name of the reminder Secret.
+	// +kubebuilder:validation:Pattern=`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`
+	// +kubebuilder:validation:MaxLength=30
+	Name string `json:"name"`
+	// Days after the due date the level is reached.
+	// +kubebuilder:validation:Minimum=1
+	Days int32 `json:"days"`
+
+	// Title printed on the reminder, e.g. Payment reminder.
+	Title string `json:"title"`
+	// Text printed on the reminder. It is a Go template with the fields
+	// Number, DueDate, DaysLate and Outstanding of the invoice, e.g.
+	// "Invoice {{ .Number }} is {{ .DaysLate }} days overdue."
+	// +optional
+	Text string `json:"text,omitempty"`
+
+	// LateFee is a fixed fee charged with the reminder, in the currency of
+	// the invoice.
+	// +optional
+	LateFee Decimal `json:"lateFee,omitempty"`
+	// InterestRate is the yearly percentage of interest charged on the
+	// outstanding amount for the days after the due date, counted as
+	// Actual/365.
+	// +optional
+	InterestRate Decimal `json:"interestRate,omitempty"`
+	// ChargeInterest claims the interest computed for the invoice from its
+	// InterestRateTable instead of a fixed interest rate.
+	// +optional
+	ChargeInterest bool `json:"chargeInterest,omitempty"`
+}
+
+// DunningPolicySpec defines the reminders sent for overdue invoices.
+type DunningPolicySpec struct {
+	// Default marks the policy of the invoices of the namespace without a
+	// dunningPolicyRef.
+	// +optional
+	Default bool `json:"default,omitempty"`
+
+	// Levels of the reminders. An invoice reaching a level gets its reminder
+	// once, levels it skipped are not sent.
+	// +kubebuilder:validation:MinItems=1
+	// +listType=map
+	// +listMapKey=name
+	Levels []DunningLevel `json:"levels"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:printcolumn:name="Default",type="boolean",JSONPath=".spec.default"
+// DunningPolicy is the Schema for the dunningpolicies API
+type DunningPolicy struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec DunningPolicySpec `json:"spec,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// DunningPolicyList contains a list of DunningPolicy
+type DunningPolicyList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []DunningPolicy `json:"items"`
+}
+
+// Reminder is a dunning reminder sent for an invoice.
+type Reminder struct {
+	// Level of the DunningPolicy the reminder was sent for.
+	Level string `json:"level"`
+	// IssueDate of the reminder.
+	IssueDate string `json:"issueDate"`
+	// Secret keeping the PDF of the reminder.
+	Secret string `json:"secret"`
+	// DaysLate is the number of days after the due date.
+	DaysLate int32 `json:"daysLate"`
+	// Outstanding amount of the invoice when the reminder was sent.
+	Outstanding Decimal `json:"outstanding"`
+	// +optional
+	LateFee Decimal `json:"lateFee,omitempty"`
+	// +optional
+	Interest Decimal `json:"interest,omitempty"`
+	// Total claimed by the reminder.
+	Total Decimal `json:"total"`
+}
+
+// DunningStatus is the progress of the dunning of an overdue invoice.
+type DunningStatus struct {
+	// Policy is the name of the DunningPolicy applied to the invoice.
+	Policy string `json:"policy"`
+	// Level is the last level reached.
+	// +optional
+	Level string `json:"level,omitempty"`
+	// NextLevelTime is when the next level is reached.
+	// +optional
+	NextLevelTime *metav1.Time `json:"nextLevelTime,omitempty"`
+	// Reminders sent for the invoice, oldest first.
+	// +optional
+	Reminders []Reminder `json:"reminders,omitempty"`
+}
+
+func init() {
+	SchemeBuilder.Register(&DunningPolicy{}, &DunningPolicyList{})
+}
@@ -0,0 +1,141 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v2
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// InterestNoteSpec defines the interest charged to a buyer for the invoices
+// paid late in a period.
+type InterestNoteSpec struct {
+	// Number is allocated from the InvoiceSequence when it is not set.
+	// +optional
+	Number string `json:"number,omitempty"`
+	// SequenceRef names the InvoiceSequence numbering the interest note. The
+	// default InvoiceSequence of the namespace is used when it is not set.
+	// +optional
+	SequenceRef *corev1.LocalObjectReference `json:"sequenceRef,omitempty"`
+
+	IssueDate string `json:"issueDate"`
+	// DueDate of the interest.
+	// +optional
+	DueDate string `json:"dueDate,omitempty"`
+
+	// CustomerRef selects the invoices billed to the Customer.
+	// +optional
+	CustomerRef *corev1.LocalObjectReference `json:"customerRef,omitempty"`
+	// BuyerVAT selects the invoices of the buyer with the VAT number.
+	// +optional
+	BuyerVAT string `json:"buyerVAT,omitempty"`
+
+	// From is the first day of the period the late payments were received in.
+	From string `json:"from"`
+	// To is the last day of the period the late payments were received in.
+	To string `json:"to"`
+}
+
+// InterestNoteLine is the interest on an amount of an invoice paid late.
+type InterestNoteLine struct {
+	// Invoice is the name of the source Invoice.
+	Invoice string `json:"invoice"`
+	// Number of the invoice.
+	Number string `json:"number"`
+	// Payment is the name of the Payment received late.
+	Payment string `json:"payment"`
+	// Amount paid late.
+	Amount Decimal `json:"amount"`
+	// DueDate of the invoice.
+	DueDate string `json:"dueDate"`
+	// PaymentDate of the amount.
+	PaymentDate string `json:"paymentDate"`
+	// DaysLate is the number of days after the due date.
+	DaysLate int32 `json:"daysLate"`
+	// Interest on the amount.
+	Interest Decimal `json:"interest"`
+}
+
+// InterestNoteStatus defines the observed state of InterestNote
+type InterestNoteStatus struct {
+	ObservedGeneration int64  `json:"observedGeneration,omitempty"`
+	Message            string `json:"message,omitempty"`
+
+	// Invoices lists the names of the source invoices.
+	// +optional
+	Invoices []string `json:"invoices,omitempty"`
+	// Lines of the interest note. They are not changed once the PDF is issued.
+	// +optional
+	Lines []InterestNoteLine `json:"lines,omitempty"`
+	// +optional
+	Currency string `json:"currency,omitempty"`
+	// Total interest charged by the note.
+	// +optional
+	Total Decimal `json:"total,omitempty"`
+	// PDFSHA256 is the hex encoded SHA-256 digest of the rendered PDF. The
+	// controller never replaces the PDF once its digest is recorded.
+	// +optional
+	PDFSHA256 string `json:"pdfSHA256,omitempty"`
+
+	// +listType=map
+	// +listMapKey=type
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty" patchStrategy:"merge" patchMergeKey:"type"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Number",type="string",JSONPath=".spec.number"
+// +kubebuilder:printcolumn:name="From",type="string",JSONPath=".spec.from"
+// +kubebuilder:printcolumn:name="To",type="string",JSONPath=".spec.to"
+// +kubebuilder:printcolumn:name="Total",type="string",JSONPath=".status.total"
+// +kubebuilder:printcolumn:name="Currency",type="string",JSONPath=".status.currency"
+// +kubebuilder:printcolumn:name="Ready",type="string",JSONPath=".status.conditions[?(@.type==\"Ready\")].status"
+// InterestNote is the Schema for the interestnotes API
+type InterestNote struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   InterestNoteSpec   `json:"spec,omitempty"`
+	Status InterestNoteStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// InterestNoteList contains a list of InterestNote
+type InterestNoteList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []InterestNote `json:"items"`
+}
+
+// Selects tells whether the interest note selects the invoices of the buyer
+// of the invoice.
+func (s *InterestNoteSpec) Selects(invoice *Invoice) bool {
+	if s.CustomerRef != nil {
+		return invoice.Spec.CustomerRef != nil && invoice.Spec.CustomerRef.Name == s.CustomerRef.Name
+	}
+	buyer := invoice.Spec.InvoiceData.Company.Buyer
+	if invoice.Status.Parties != nil {
+		buyer = invoice.Status.Parties.Buyer
+	}
+	return s.BuyerVAT != "" && buyer.VAT == s.BuyerVAT
+}
+
+func init() {
+	SchemeBuilder.Register(&InterestNote{}, &InterestNoteList{})
+}
@@ -0,0 +1,121 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v2
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// DayCount is the convention counting the days of interest against a year.
+// +kubebuilder:validation:Enum=Actual365;Actual360
+type DayCount string
+
+const (
+	// Actual365 counts the actual days against a year of 365 days.
+	Actual365 DayCount = "Actual365"
+	// Actual360 counts the actual days against a year of 360 days.
+	Actual360 DayCount = "Actual360"
+)
+
+// InterestRate is a yearly interest rate in force from a date.
+type InterestRate struct {
+	// From is the first day the rate applies, in DD-MM-YYYY format.
+	From string `json:"from"`
+	// Rate is the yearly percentage, e.g. 11.25.
+	Rate Decimal `json:"rate"`
+}
+
+// InterestRateTableSpec defines the late-payment interest rates over time.
+type InterestRateTableSpec struct {
+	// Default marks the table of the invoices of the namespace without an
+	// interestRateTableRef.
+	// +optional
+	Default bool `json:"default,omitempty"`
+
+	// DayCount convention of the simple interest.
+	// +kubebuilder:default:=Actual365
+	// +optional
+	DayCount DayCount `json:"dayCount,omitempty"`
+
+	// Rates in force over time. A rate applies from its date until the date
+	// of the next rate.
+	// +kubebuilder:validation:MinItems=1
+	// +listType=map
+	// +listMapKey=from
+	Rates []InterestRate `json:"rates"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:printcolumn:name="Default",type="boolean",JSONPath=".spec.default"
+// +kubebuilder:printcolumn:name="Day count",type="string",JSONPath=".spec.dayCount"
+// InterestRateTable is the Schema for the interestratetables API
+type InterestRateTable struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec InterestRateTableSpec `json:"spec,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// InterestRateTableList contains a list of InterestRateTable
+type InterestRateTableList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []InterestRateTable `json:"items"`
+}
+
+// InterestPeriod is the interest on an amount paid late, for the days one
+// rate was in force.
+type InterestPeriod struct {
+	// Payment names the Payment of a paid amount, it is empty for the
+	// outstanding amount.
+	// +optional
+	Payment string `json:"payment,omitempty"`
+	// Amount the interest is charged on.
+	Amount Decimal `json:"amount"`
+	// From is the first day of interest.
+	From string `json:"from"`
+	// To is the last day of interest, the payment date of a paid amount.
+	To string `json:"to"`
+	// Days of interest.
+	Days int32 `json:"days"`
+	// Rate is the yearly percentage in force.
+	Rate Decimal `json:"rate"`
+	// Interest for the period.
+	Interest Decimal `json:"interest"`
+}
+
+// InvoiceInterest is the late-payment interest of an invoice.
+type InvoiceInterest struct {
+	// Table is the name of the InterestRateTable the interest is computed with.
+	Table string `json:"table"`
+	// DayCount convention of the table.
+	DayCount DayCount `json:"dayCount"`
+	// Until is the last day interest was computed for. The interest on the
+	// outstanding amount keeps accruing until it is paid.
+	Until string `json:"until"`
+	// Amount is the sum of the interest of the periods.
+	Amount Decimal `json:"amount"`
+	// Periods of interest of the amounts paid late and of the outstanding amount.
+	// +optional
+	Periods []InterestPeriod `json:"periods,omitempty"`
+}
+
+func init() {
+	SchemeBuilder.Register(&InterestRateTable{}, &InterestRateTableList{})
+}
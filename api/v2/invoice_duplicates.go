@@ -0,0 +1,109 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v2
+
+import (
+	"context"
+	"fmt"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// InvoiceNumberField is the field index of the invoice numbers.
+const InvoiceNumberField = "spec.invoiceData.number"
+
+// IndexInvoiceNumber returns the number of an Invoice for the field index.
+func IndexInvoiceNumber(obj client.Object) []string {
+	invoice := obj.(*Invoice)
+	if invoice.Spec.InvoiceData.Number == "" {
+		return nil
+	}
+	return []string{invoice.Spec.InvoiceData.Number}
+}
+
+// ResolveSeller returns the seller of the invoice: the frozen seller of an
+// issued invoice, the seller of the referenced SellerProfile or the inline one.
+func ResolveSeller(ctx context.Context, c client.Reader, invoice *Invoice) Seller {
+	if invoice.Status.Parties != nil {
+		return invoice.Status.Parties.Seller
+	}
+	if ref := invoice.Spec.SellerRef; ref != nil {
+		profile := &SellerProfile{}
+		if err := c.Get(ctx, client.ObjectKey{Namespace: invoice.Namespace, Name: ref.Name}, profile); err == nil {
+			return profile.Spec.Seller
+		}
+	}
+	return invoice.Spec.InvoiceData.Company.Seller
+}
+
+// sellerKey identifies a seller by its VAT number, or by its name when it has none.
+func sellerKey(seller Seller) string {
+	if seller.VAT != "" {
+		return "vat:" + seller.VAT
+	}
+	return "name:" + seller.Name
+}
+
+// precedes tells whether invoice a was created before invoice b. An invoice
+// which is not created yet comes after all the others.
+func precedes(a, b *Invoice) bool {
+	if b.CreationTimestamp.IsZero() {
+		return true
+	}
+	if a.CreationTimestamp.Equal(&b.CreationTimestamp) {
+		return a.Name < b.Name
+	}
+	return a.CreationTimestamp.Before(&b.CreationTimestamp)
+}
+
+// FindDuplicate returns the oldest invoice of the namespace created before the
+// given one with the same number and seller, or nil. The first invoice
+// carrying a number keeps it; the later ones are the duplicates. The list
+// options may select the candidates through the InvoiceNumberField index.
+func FindDuplicate(ctx context.Context, c client.Reader, invoice *Invoice, opts ...client.ListOption) (*Invoice, error) {
+	number := invoice.Spec.InvoiceData.Number
+	if number == "" {
+		return nil, nil
+	}
+
+	invoices := &InvoiceList{}
+	opts = append([]client.ListOption{client.InNamespace(invoice.Namespace)}, opts...)
+	if err := c.List(ctx, invoices, opts...); err != nil {
+		return nil, fmt.Errorf("unable to list invoices: %w", err)
+	}
+
+	seller := sellerKey(ResolveSeller(ctx, c, invoice))
+	var found *Invoice
+	for i := range invoices.Items {
+		other := &invoices.Items[i]
+		if other.Name == invoice.Name || other.Spec.InvoiceData.Number != number || !precedes(other, invoice) {
+			continue
+		}
+		// Proforma invoices have no legal effect and do not share the numbers of invoices.
+		if (other.Spec.DocumentType == DocumentProforma) != (invoice.Spec.DocumentType == DocumentProforma) {
+			continue
+		}
+		if sellerKey(ResolveSeller(ctx, c, other)) != seller {
+			continue
+		}
+		if found == nil || precedes(other, found) {
+			found = other
+		}
+	}
+
+	return found, nil
+}
@@ -0,0 +1,66 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v2
+
+import "time"
+
+// DateLayout is the layout of the dates in InvoiceData.
+const DateLayout = "02-01-2006"
+
+// transitions lists the lifecycle states reachable from each state.
+var transitions = map[State][]State{
+	Draft:         {Issued, Cancelled},
+	Issued:        {Sent, PartiallyPaid, Paid, Overdue, Cancelled},
+	Sent:          {PartiallyPaid, Paid, Overdue, Cancelled},
+	PartiallyPaid: {Paid, Overdue},
+	Overdue:       {PartiallyPaid, Paid, Cancelled},
+	Paid:          {},
+	Cancelled:     {},
+}
+
+// CanTransitionTo reports whether the lifecycle allows moving from s to next.
+func (s State) CanTransitionTo(next State) bool {
+	if s == next {
+		return true
+	}
+	for _, t := range transitions[s] {
+		if t == next {
+			return true
+		}
+	}
+	return false
+}
+
+// IsOpen reports whether an invoice in state s still awaits payment.
+func (s State) IsOpen() bool {
+	switch s {
+	case Issued, Sent, PartiallyPaid, Overdue:
+		return true
+	}
+	return false
+}
+
+// IsIssued reports whether the controller has issued the invoice, that is
+// moved its status out of Draft.
+func (in *Invoice) IsIssued() bool {
+	return in.Status.State != "" && in.Status.State != Draft
+}
+
+// ParseDate parses a date written in InvoiceData.
+func ParseDate(value string) (time.Time, error) {
+	return time.Parse(DateLayout, value)
+}
@@ -0,0 +1,707 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v2
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+type Phase string
+
+var (
+	Failure Phase = "Failure"
+	Success Phase = "Generated"
+)
+
+// Condition types of an Invoice.
+const (
+	// ConditionReady is True when every other condition of the invoice is True.
+	ConditionReady = "Ready"
+	// ConditionValidated tells whether the invoice data passed validation.
+	ConditionValidated = "Validated"
+	// ConditionPDFRendered tells whether the PDF document was rendered.
+	ConditionPDFRendered = "PDFRendered"
+	// ConditionSecretSynced tells whether the rendered document is stored in the Secret.
+	ConditionSecretSynced = "SecretSynced"
+	// ConditionViewerAvailable tells whether the viewer Deployment is available.
+	ConditionViewerAvailable = "ViewerAvailable"
+	// ConditionExposed tells whether the viewer is reachable through its Service or Ingress.
+	ConditionExposed = "Exposed"
+	// ConditionOverdue is True when the due date passed before the invoice was paid.
+	ConditionOverdue = "Overdue"
+	// ConditionCleared is True when the e-invoicing clearance system accepted the invoice.
+	ConditionCleared = "Cleared"
+	// ConditionCompliant is True when the invoice keeps the EN 16931 business rules.
+	ConditionCompliant = "Compliant"
+)
+
+// State is the business lifecycle state of an Invoice.
+// +kubebuilder:validation:Enum=Draft;Issued;Sent;PartiallyPaid;Paid;Overdue;Cancelled
+type State string
+
+const (
+	Draft         State = "Draft"
+	Issued        State = "Issued"
+	Sent          State = "Sent"
+	PartiallyPaid State = "PartiallyPaid"
+	Paid          State = "Paid"
+	Overdue       State = "Overdue"
+	Cancelled     State = "Cancelled"
+)
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:storageversion
+// +kubebuilder:printcolumn:name="Type",type="string",JSONPath=".spec.documentType"
+// +kubebuilder:printcolumn:name="Status",type="string",JSONPath=".status.phase"
+// +kubebuilder:printcolumn:name="State",type="string",JSONPath=".status.state"
+// +kubebuilder:printcolumn:name="Due",type="string",JSONPath=".spec.invoiceData.dueDate"
+// +kubebuilder:printcolumn:name="Total",type="string",JSONPath=".status.totals.total"
+// +kubebuilder:printcolumn:name="Outstanding",type="string",JSONPath=".status.balance.outstanding"
+// +kubebuilder:printcolumn:name="Dunning",type="string",JSONPath=".status.dunning.level",priority=1
+// +kubebuilder:printcolumn:name="Currency",type="string",JSONPath=".status.totals.currency"
+// +kubebuilder:printcolumn:name="Endpoint",type="string",JSONPath=".status.endpoint"
+// Invoice is the Schema for the invoices API
+type Invoice struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   InvoiceSpec   `json:"spec,omitempty"`
+	Status InvoiceStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// InvoiceList contains a list of Invoice
+type InvoiceList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []Invoice `json:"items"`
+}
+
+// EDIT THIS FILE!  THIS IS SCAFFOLDING FOR YOU TO OWN!
+// NOTE: json tags are required.  Any new fields you add must have json tags for the fields to be serialized.
+
+// InvoiceSpec defines the desired state of Invoice
+type InvoiceSpec struct {
+	// INSERT ADDITIONAL SPEC FIELDS - desired state of cluster
+	// Important: Run "make" to regenerate code after modifying this file
+	Exposure   Exposure   `json:"exposure,omitempty"`
+	Deployment Deployment `json:"deployment,omitempty"`
+
+	// State requested for the invoice. Overdue is never requested, the
+	// controller derives it from the due date.
+	// +kubebuilder:validation:Enum=Draft;Issued;Sent;PartiallyPaid;Paid;Cancelled
+	// +kubebuilder:default:=Draft
+	// +optional
+	State State `json:"state,omitempty"`
+
+	// DocumentType selects the kind of document, its title and its numbering series.
+	// +kubebuilder:validation:Enum=Invoice;Proforma;Advance;Final
+	// +kubebuilder:default:=Invoice
+	// +optional
+	DocumentType DocumentType `json:"documentType,omitempty"`
+	// AdvanceRefs names the advance invoices settled by a final invoice. Their
+	// amounts are subtracted from the amount due.
+	// +optional
+	AdvanceRefs []corev1.LocalObjectReference `json:"advanceRefs,omitempty"`
+
+	// CustomerRef names the Customer billed by the invoice. It replaces the
+	// inline buyer of the invoice data.
+	// +optional
+	CustomerRef *corev1.LocalObjectReference `json:"customerRef,omitempty"`
+	// SellerRef names the SellerProfile issuing the invoice. It replaces the
+	// inline seller of the invoice data.
+	// +optional
+	SellerRef *corev1.LocalObjectReference `json:"sellerRef,omitempty"`
+
+	// SequenceRef names the InvoiceSequence numbering the invoice. The default
+	// InvoiceSequence of the namespace is used when it is not set.
+	// +optional
+	SequenceRef *corev1.LocalObjectReference `json:"sequenceRef,omitempty"`
+
+	// DunningPolicyRef names the DunningPolicy reminding the buyer of the
+	// overdue invoice. The default DunningPolicy of the namespace is used when
+	// it is not set.
+	// +optional
+	DunningPolicyRef *corev1.LocalObjectReference `json:"dunningPolicyRef,omitempty"`
+	// InterestRateTableRef names the InterestRateTable of the late-payment
+	// interest. The default InterestRateTable of the namespace is used when
+	// it is not set.
+	// +optional
+	InterestRateTableRef *corev1.LocalObjectReference `json:"interestRateTableRef,omitempty"`
+
+	InvoiceData InvoiceData `json:"invoiceData"`
+}
+
+// InvoiceStatus defines the observed state of Invoice
+type InvoiceStatus struct {
+	// INSERT ADDITIONAL STATUS FIELD - define observed state of cluster
+	// Important: Run "make" to regenerate code after modifying this file
+	LastProcessedTime  *metav1.Time `json:"lastProcessedTime,omitempty"`
+	ObservedGeneration int64        `json:"observedGeneration,omitempty"`
+	// Current phase of the operator.
+	Message  string `json:"message,omitempty"`
+	Phase    Phase  `json:"phase,omitempty"`
+	Endpoint string `json:"endpoint,omitempty"`
+
+	// Current lifecycle state of the invoice.
+	State State `json:"state,omitempty"`
+	// Time of the last lifecycle state change.
+	StateChangedTime *metav1.Time `json:"stateChangedTime,omitempty"`
+	// History of lifecycle state changes, oldest first.
+	History []StateTransition `json:"history,omitempty"`
+
+	// Conditions describe the state of the rendered document and the viewer.
+	// +listType=map
+	// +listMapKey=type
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty" patchStrategy:"merge" patchMergeKey:"type"`
+
+	// Totals computed from the invoice items.
+	// +optional
+	Totals *InvoiceTotals `json:"totals,omitempty"`
+
+	// Advances lists the advance invoices settled by a final invoice.
+	// +optional
+	Advances []SettledAdvance `json:"advances,omitempty"`
+
+	// Balance of the invoice after its corrections.
+	// +optional
+	Balance *InvoiceBalance `json:"balance,omitempty"`
+	// CreditNotes lists the names of the CreditNotes correcting the invoice.
+	// +optional
+	CreditNotes []string `json:"creditNotes,omitempty"`
+	// Payments lists the names of the Payments received for the invoice,
+	// oldest first.
+	// +optional
+	Payments []string `json:"payments,omitempty"`
+
+	// Interest is the late-payment interest of the amounts paid after the due
+	// date and of the outstanding amount of an overdue invoice.
+	// +optional
+	Interest *InvoiceInterest `json:"interest,omitempty"`
+
+	// Dunning records the reminders sent for the overdue invoice.
+	// +optional
+	Dunning *DunningStatus `json:"dunning,omitempty"`
+
+	// PDFSHA256 is the hex encoded SHA-256 digest of the PDF of the issued
+	// invoice. The controller never replaces a PDF once its digest is recorded.
+	// +optional
+	PDFSHA256 string `json:"pdfSHA256,omitempty"`
+
+	// Parties is the seller and buyer data the invoice was issued with. It is
+	// recorded when the invoice leaves Draft and is not refreshed afterwards.
+	// +optional
+	Parties *Company `json:"parties,omitempty"`
+
+	// Clearance records the submission of the issued invoice to an
+	// e-invoicing clearance system.
+	// +optional
+	Clearance *ClearanceStatus `json:"clearance,omitempty"`
+
+	// RuleViolations lists the EN 16931 business rules the invoice breaks.
+	// +optional
+	RuleViolations []RuleViolation `json:"ruleViolations,omitempty"`
+}
+
+// RuleViolation is a breach of an EN 16931 business rule.
+type RuleViolation struct {
+	// Rule is the identifier of the rule, e.g. BR-CO-10.
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+// ClearancePhase is the progress of the submission of an invoice to a
+// clearance system.
+// +kubebuilder:validation:Enum=Pending;Accepted;Rejected
+type ClearancePhase string
+
+const (
+	ClearancePending  ClearancePhase = "Pending"
+	ClearanceAccepted ClearancePhase = "Accepted"
+	ClearanceRejected ClearancePhase = "Rejected"
+)
+
+// ClearanceStatus is the submission of an invoice to a clearance system.
+type ClearanceStatus struct {
+	// System is the clearance system the invoice was submitted to, e.g. KSeF.
+	System string `json:"system"`
+	// Reference is the number the clearance system gave the submission.
+	Reference string         `json:"reference"`
+	Phase     ClearancePhase `json:"phase"`
+	// SubmittedTime is when the invoice was submitted.
+	// +optional
+	SubmittedTime *metav1.Time `json:"submittedTime,omitempty"`
+	// ID is the identifier the clearance system assigned to the accepted
+	// invoice, the KSeF number for KSeF.
+	// +optional
+	ID string `json:"id,omitempty"`
+	// ClearedTime is when the invoice was found accepted.
+	// +optional
+	ClearedTime *metav1.Time `json:"clearedTime,omitempty"`
+	// Receipt is the official acknowledgement of receipt of the accepted
+	// invoice, the UPO XML for KSeF.
+	// +optional
+	Receipt string `json:"receipt,omitempty"`
+	// Message is the reason the clearance system rejected the invoice.
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+// InvoiceTotals are the amounts of the invoice rounded to the currency precision.
+type InvoiceTotals struct {
+	Currency string `json:"currency"`
+	// Subtotal is the net amount of all items.
+	Subtotal Decimal `json:"subtotal"`
+	// VAT breakdown per rate, ordered by rate.
+	VAT []VATTotal `json:"vat,omitempty"`
+	// VATTotal is the VAT amount of all items.
+	VATTotal Decimal `json:"vatTotal"`
+	// Total is the gross amount of the invoice.
+	Total Decimal `json:"total"`
+}
+
+// VATTotal is the net and VAT amount of all items sharing a VAT rate.
+type VATTotal struct {
+	Rate Decimal `json:"rate"`
+	Net  Decimal `json:"net"`
+	VAT  Decimal `json:"vat"`
+}
+
+// StateTransition records a single lifecycle state change.
+type StateTransition struct {
+	From State       `json:"from,omitempty"`
+	To   State       `json:"to"`
+	Time metav1.Time `json:"time"`
+}
+
+type Deployment struct {
+	// +kubebuilder:default:=viewer
+	// +optional
+	Name string `json:"name,omitempty"`
+	// +optional
+	Image string `json:"image,omitempty"`
+	// +kubebuilder:default:=Never
+	// +optional
+	ImagePullPolicy corev1.PullPolicy `json:"imagePullPolicy,omitempty"`
+	// PaidStamp prints a PAID stamp on the copy served by the viewer once the
+	// invoice is paid. The issued PDF kept in the Secret is not changed.
+	// +optional
+	PaidStamp bool `json:"paidStamp,omitempty"`
+}
+
+type Exposure struct {
+	PublicURL  string     `json:"publicURL,omitempty"`
+	Ingress    Ingress    `json:"ingress,omitempty"`
+	GatewayAPI GatewayAPI `json:"gatewayAPI,omitempty"`
+}
+
+type GatewayAPI struct {
+}
+
+type Ingress struct {
+	// Annotations to be added to the Ingress object
+	Annotations map[string]string `json:"annotations,omitempty"`
+	// Labels to be added to the Ingress object
+	Labels map[string]string `json:"labels,omitempty"`
+	// Enabled allows to turn off the Ingress object (for example for using a LoadBalancer service)
+	// +kubebuilder:default:=true
+	Enabled bool `json:"enabled,omitempty"`
+	// TLSEnabled toggles the TLS configuration on the Ingress object
+	// +optional
+	TLSEnabled bool `json:"tlsEnabled,omitempty"`
+	// TLSEnabled toggles the TLS configuration on the Ingress object
+	// +optional
+	IngressClassName string `json:"ingressClassName,omitempty"`
+	// TLSSecretName overrides the generated name for the TLS certificate Secret object
+	// +optional
+	TLSSecretName string `json:"tlsSecretName,omitempty"`
+}
+
+type InvoiceData struct {
+	// INSERT ADDITIONAL SPEC FIELDS - desired state of cluster
+	// Important: Run "make" to regenerate code after modifying this file
+	// Number is allocated from the InvoiceSequence when the invoice leaves
+	// Draft without one.
+	// +optional
+	Number string `json:"number,omitempty"`
+	// IssueDate defaults to the creation date of the invoice.
+	// +optional
+	IssueDate string `json:"issueDate,omitempty"`
+	// SaleDate defaults to the issue date.
+	// +optional
+	SaleDate string `json:"saleDate,omitempty"`
+	// DueDate defaults to the issue date plus the payment terms of the SellerProfile.
+	// +optional
+	DueDate string `json:"dueDate,omitempty"`
+	// +optional
+	Notes   string  `json:"notes,omitempty"`
+	Company Company `json:"company"`
+	// Bank defaults to the bank details of the SellerProfile.
+	// +optional
+	Bank  Bank    `json:"bank,omitempty"`
+	Items []*Item `json:"items"`
+	// Currency defaults to the currency of the SellerProfile.
+	// +optional
+	Currency string `json:"currency,omitempty"`
+	// Signature defaults to the signature of the SellerProfile.
+	// +optional
+	Signature string `json:"signature,omitempty"`
+	// +optional
+	Options Options `json:"options,omitempty"`
+	// Rounding of the computed amounts.
+	// +optional
+	Rounding Rounding `json:"rounding,omitempty"`
+}
+
+// Company details of buyer and seller.
+type Company struct {
+	// Buyer is taken from the Customer when customerRef is set.
+	// +optional
+	Buyer Buyer `json:"buyer,omitempty"`
+	// Seller defaults to the seller of the SellerProfile and is taken from
+	// it when sellerRef is set.
+	// +optional
+	Seller Seller `json:"seller,omitempty"`
+}
+
+// Buyer company details.
+type Buyer struct {
+	Name    string `json:"name"`
+	Address string `json:"address"`
+	VAT     string `json:"vat"`
+	// NIP is the Polish tax identification number, identifying the buyer
+	// on KSeF invoices.
+	// +kubebuilder:validation:Pattern=`^[0-9]{10}$`
+	// +optional
+	NIP string `json:"nip,omitempty"`
+	// AddressLines is the address split into lines for structured invoices.
+	// The address is used as a single line when not set.
+	// +kubebuilder:validation:MaxItems=2
+	// +optional
+	AddressLines []string `json:"addressLines,omitempty"`
+	// CountryCode is the ISO 3166-1 alpha-2 code of the country of the address.
+	// +kubebuilder:validation:Pattern=`^[A-Z]{2}$`
+	// +optional
+	CountryCode string `json:"countryCode,omitempty"`
+	// City of the address on structured invoices.
+	// +optional
+	City string `json:"city,omitempty"`
+	// PostalCode of the address on structured invoices.
+	// +optional
+	PostalCode string `json:"postalCode,omitempty"`
+	// EndpointID is the electronic address the party receives structured
+	// invoices at, e.g. its Peppol participant identifier.
+	// +optional
+	EndpointID string `json:"endpointID,omitempty"`
+	// EndpointScheme is the code of the scheme of the electronic address in
+	// the EAS code list, e.g. 0088 for a GLN, 0204 for a Leitweg-ID or EM
+	// for an email address.
+	// +kubebuilder:validation:Pattern=`^([0-9]{4}|[A-Z]{2})$`
+	// +optional
+	EndpointScheme string `json:"endpointScheme,omitempty"`
+	// BuyerReference is the reference the buyer asks to quote on its
+	// invoices, the Leitweg-ID of German public-sector buyers.
+	// +optional
+	BuyerReference string `json:"buyerReference,omitempty"`
+	// Italy holds the fields of Italian FatturaPA invoices.
+	// +optional
+	Italy *ItalianBuyer `json:"italy,omitempty"`
+}
+
+// Seller company details.
+type Seller struct {
+	Name    string `json:"name"`
+	Address string `json:"address"`
+	VAT     string `json:"vat"`
+	// NIP is the Polish tax identification number. The invoice is exported
+	// as a KSeF FA(2) invoice when the seller has one.
+	// +kubebuilder:validation:Pattern=`^[0-9]{10}$`
+	// +optional
+	NIP string `json:"nip,omitempty"`
+	// AddressLines is the address split into lines for structured invoices.
+	// The address is used as a single line when not set.
+	// +kubebuilder:validation:MaxItems=2
+	// +optional
+	AddressLines []string `json:"addressLines,omitempty"`
+	// CountryCode is the ISO 3166-1 alpha-2 code of the country of the address.
+	// +kubebuilder:validation:Pattern=`^[A-Z]{2}$`
+	// +optional
+	CountryCode string `json:"countryCode,omitempty"`
+	// City of the address on structured invoices.
+	// +optional
+	City string `json:"city,omitempty"`
+	// PostalCode of the address on structured invoices.
+	// +optional
+	PostalCode string `json:"postalCode,omitempty"`
+	// EndpointID is the electronic address the party receives structured
+	// invoices at, e.g. its Peppol participant identifier.
+	// +optional
+	EndpointID string `json:"endpointID,omitempty"`
+	// EndpointScheme is the code of the scheme of the electronic address in
+	// the EAS code list, e.g. 0088 for a GLN, 0204 for a Leitweg-ID or EM
+	// for an email address.
+	// +kubebuilder:validation:Pattern=`^([0-9]{4}|[A-Z]{2})$`
+	// +optional
+	EndpointScheme string `json:"endpointScheme,omitempty"`
+	// Contact is the contact point of the seller.
+	// +optional
+	Contact *Contact `json:"contact,omitempty"`
+	// Italy holds the fields of Italian FatturaPA invoices. The invoice is
+	// exported as a FatturaPA invoice when the seller has them.
+	// +optional
+	Italy *ItalianSeller `json:"italy,omitempty"`
+}
+
+// ItalianBuyer holds the fields of the buyer on FatturaPA invoices.
+type ItalianBuyer struct {
+	// CodiceDestinatario is the code of the channel the buyer receives
+	// invoices on through the SDI. Buyers without a code receive them at
+	// their PEC address, foreign buyers are addressed as XXXXXXX.
+	// +kubebuilder:validation:Pattern=`^[A-Z0-9]{7}$`
+	// +optional
+	CodiceDestinatario string `json:"codiceDestinatario,omitempty"`
+	// PEC is the certified email address the buyer receives invoices at.
+	// +optional
+	PEC string `json:"pec,omitempty"`
+	// CodiceFiscale is the Italian tax code of the buyer, which identifies
+	// buyers without a VAT number.
+	// +kubebuilder:validation:Pattern=`^[A-Z0-9]{11,16}$`
+	// +optional
+	CodiceFiscale string `json:"codiceFiscale,omitempty"`
+	// Provincia is the code of the province of the address.
+	// +kubebuilder:validation:Pattern=`^[A-Z]{2}$`
+	// +optional
+	Provincia string `json:"provincia,omitempty"`
+	// Natura is the reason zero-rated lines carry no VAT, e.g. N3.2 for
+	// intra-community supplies or N6.9 for reverse charge.
+	// +kubebuilder:validation:Pattern=`^N([1457]|2\.[12]|3\.[1-6]|6\.[1-9])$`
+	// +optional
+	Natura string `json:"natura,omitempty"`
+}
+
+// ItalianSeller holds the fields of the seller on FatturaPA invoices.
+type ItalianSeller struct {
+	// RegimeFiscale is the tax regime of the seller, RF01 for the ordinary
+	// regime.
+	// +kubebuilder:validation:Pattern=`^RF(0[124-9]|1[0-9])$`
+	// +kubebuilder:default:=RF01
+	// +optional
+	RegimeFiscale string `json:"regimeFiscale,omitempty"`
+	// CodiceFiscale is the Italian tax code of the seller.
+	// +kubebuilder:validation:Pattern=`^[A-Z0-9]{11,16}$`
+	// +optional
+	CodiceFiscale string `json:"codiceFiscale,omitempty"`
+	// Provincia is the code of the province of the address.
+	// +kubebuilder:validation:Pattern=`^[A-Z]{2}$`
+	// +optional
+	Provincia string `json:"provincia,omitempty"`
+}
+
+// Contact point of a party.
+type Contact struct {
+	// +optional
+	Name string `json:"name,omitempty"`
+	// +optional
+	Phone string `json:"phone,omitempty"`
+	// +optional
+	Email string `json:"email,omitempty"`
+}
+
+// Bank details on the invoice.
+type Bank struct {
+	AccountNumber string `json:"accountNumber"`
+	Swift         string `json:"swift"`
+}
+
+// DocumentType is the kind of document issued for an invoice.
+type DocumentType string
+
+const (
+	// DocumentInvoice is a regular VAT invoice.
+	DocumentInvoice DocumentType = "Invoice"
+	// DocumentProforma is an offer in the form of an invoice, without legal effect.
+	DocumentProforma DocumentType = "Proforma"
+	// DocumentAdvance is an invoice for a prepayment received before the sale.
+	DocumentAdvance DocumentType = "Advance"
+	// DocumentFinal is an invoice for the whole sale, settling the earlier advances.
+	DocumentFinal DocumentType = "Final"
+	// DocumentCreditNote is a CreditNote, numbered in its own series.
+	DocumentCreditNote DocumentType = "CreditNote"
+	// DocumentInterestNote is an InterestNote, numbered in its own series.
+	DocumentInterestNote DocumentType = "InterestNote"
+)
+
+// Title returns the title printed on a document of the type.
+func (t DocumentType) Title() string {
+	switch t {
+	case DocumentProforma:
+		return "Proforma invoice"
+	case DocumentAdvance:
+		return "Advance invoice"
+	case DocumentFinal:
+		return "Final invoice"
+	case DocumentCreditNote:
+		return "Credit note"
+	case DocumentInterestNote:
+		return "Interest note"
+	}
+	return "Invoice"
+}
+
+// SettledAdvance is an advance invoice deducted from a final invoice.
+type SettledAdvance struct {
+	// Name of the advance Invoice.
+	Name string `json:"name"`
+	// Number of the advance invoice.
+	Number string `json:"number"`
+	// Total gross amount of the advance invoice.
+	Total Decimal `json:"total"`
+}
+
+// InvoiceBalance holds the amount still to be paid for an invoice.
+type InvoiceBalance struct {
+	// Prepaid is the sum of the advance invoices settled by a final invoice.
+	// +optional
+	Prepaid Decimal `json:"prepaid,omitempty"`
+	// Corrections is the sum of the CreditNote totals, negative when the
+	// invoice is credited.
+	Corrections Decimal `json:"corrections"`
+	// Paid is the sum of the Payments received for the invoice.
+	// +optional
+	Paid Decimal `json:"paid,omitempty"`
+	// Outstanding is the invoice total less the prepaid and paid amounts,
+	// adjusted by the corrections.
+	Outstanding Decimal `json:"outstanding"`
+}
+
+// Decimal is an exact decimal number written as a string, e.g. "3.05".
+// +kubebuilder:validation:Pattern=`^-?[0-9]+(\.[0-9]+)?$`
+type Decimal string
+
+// Item parameters.
+type Item struct {
+	Description string `json:"description"`
+	// Quantity of the item.
+	Quantity Decimal `json:"quantity"`
+	// UnitPrice is the net price of a single unit.
+	UnitPrice Decimal `json:"unitPrice"`
+	// VATRate in percent.
+	VATRate Decimal `json:"vatRate"`
+}
+
+// RoundingMode selects how amounts are rounded to the currency precision.
+// +kubebuilder:validation:Enum=HalfUp;HalfEven
+type RoundingMode string
+
+const (
+	// RoundHalfUp rounds ties away from zero.
+	RoundHalfUp RoundingMode = "HalfUp"
+	// RoundHalfEven rounds ties to the even digit (banker's rounding).
+	RoundHalfEven RoundingMode = "HalfEven"
+)
+
+// RoundingScope selects where amounts are rounded.
+// +kubebuilder:validation:Enum=Line;Document
+type RoundingScope string
+
+const (
+	// RoundPerLine rounds the net and VAT amount of every line, totals are sums of rounded lines.
+	RoundPerLine RoundingScope = "Line"
+	// RoundPerDocument rounds the net and VAT totals of every VAT rate once.
+	RoundPerDocument RoundingScope = "Document"
+)
+
+// Rounding configures the computation of the invoice totals.
+type Rounding struct {
+	// +kubebuilder:default:=HalfUp
+	// +optional
+	Mode RoundingMode `json:"mode,omitempty"`
+	// +kubebuilder:default:=Line
+	// +optional
+	Scope RoundingScope `json:"scope,omitempty"`
+}
+
+// OutputFormat is the format of the invoice document.
+// +kubebuilder:validation:Enum=PDF;FacturX
+type OutputFormat string
+
+const (
+	// OutputPDF is a plain PDF document.
+	OutputPDF OutputFormat = "PDF"
+	// OutputFacturX is a Factur-X PDF/A-3 with the Cross-Industry Invoice XML
+	// of the EN 16931 (COMFORT) profile attached.
+	OutputFacturX OutputFormat = "FacturX"
+)
+
+// EInvoiceProfile is the specification the structured invoice XML follows.
+// +kubebuilder:validation:Enum=EN16931;XRechnungUBL;XRechnungCII;PeppolBIS
+type EInvoiceProfile string
+
+const (
+	// ProfileEN16931 is the core EN 16931 invoice in UBL syntax.
+	ProfileEN16931 EInvoiceProfile = "EN16931"
+	// ProfileXRechnungUBL is the German XRechnung in UBL syntax.
+	ProfileXRechnungUBL EInvoiceProfile = "XRechnungUBL"
+	// ProfileXRechnungCII is the German XRechnung in CII syntax.
+	ProfileXRechnungCII EInvoiceProfile = "XRechnungCII"
+	// ProfilePeppolBIS is the Peppol BIS Billing 3.0 invoice in UBL syntax.
+	ProfilePeppolBIS EInvoiceProfile = "PeppolBIS"
+)
+
+// RuleMode is how the EN 16931 business rules are enforced.
+// +kubebuilder:validation:Enum=Report;Strict
+type RuleMode string
+
+const (
+	// RulesReport records the rules the invoice breaks in its status.
+	RulesReport RuleMode = "Report"
+	// RulesStrict also keeps the invoice in Draft until it keeps all rules.
+	RulesStrict RuleMode = "Strict"
+)
+
+// Options of the invoice documents.
+type Options struct {
+	FontFamily string `json:"font,omitempty"`
+	// Output selects the format of the document, a plain PDF by default.
+	// PDF/A embeds all fonts, so Factur-X requires the font to be set.
+	// +kubebuilder:default:=PDF
+	// +optional
+	Output OutputFormat `json:"output,omitempty"`
+	// Profile selects the specification of the structured invoice XML, the
+	// core EN 16931 by default. XRechnung requires the buyer reference, the
+	// electronic addresses, the cities and postal codes of both parties and
+	// the contact of the seller, Peppol BIS the buyer reference and the
+	// electronic addresses.
+	// +kubebuilder:default:=EN16931
+	// +optional
+	Profile EInvoiceProfile `json:"profile,omitempty"`
+	// Rules selects how the EN 16931 business rules are enforced. They are
+	// checked before the documents are rendered and the rules the invoice
+	// breaks are listed in the status. Strict invoices are not issued until
+	// they keep all rules.
+	// +kubebuilder:default:=Report
+	// +optional
+	Rules RuleMode `json:"rules,omitempty"`
+}
+
+func init() {
+	SchemeBuilder.Register(&Invoice{}, &InvoiceList{})
+}
@@ -0,0 +1,479 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v2
+
+import (
+	"fmt"
+	"math/big"
+	"net/url"
+	"strings"
+
+	"gopkg.in/inf.v0"
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+// currencies are the active ISO 4217 currency codes.
+var currencies = sets(
+	"AED", "AFN", "ALL", "AMD", "ANG", "AOA", "ARS", "AUD", "AWG", "AZN", "BAM", "BBD", "BDT", "BGN", "BHD",
+	"BIF", "BMD", "BND", "BOB", "BRL", "BSD", "BTN", "BWP", "BYN", "BZD", "CAD", "CDF", "CHF", "CLP", "CNY",
+	"COP", "CRC", "CUP", "CVE", "CZK", "DJF", "DKK", "DOP", "DZD", "EGP", "ERN", "ETB", "EUR", "FJD", "FKP",
+	"GBP", "GEL", "GHS", "GIP", "GMD", "GNF", "GTQ", "GYD", "HKD", "HNL", "HTG", "HUF", "IDR", "ILS", "INR",
+	"IQD", "IRR", "ISK", "JMD", "JOD", "JPY", "KES", "KGS", "KHR", "KMF", "KPW", "KRW", "KWD", "KYD", "KZT",
+	"LAK", "LBP", "LKR", "LRD", "LSL", "LYD", "MAD", "MDL", "MGA", "MKD", "MMK", "MNT", "MOP", "MRU", "MUR",
+	"MVR", "MWK", "MXN", "MYR", "MZN", "NAD", "NGN", "NIO", "NOK", "NPR", "NZD", "OMR", "PAB", "PEN", "PGK",
+	"PHP", "PKR", "PLN", "PYG", "QAR", "RON", "RSD", "RUB", "RWF", "SAR", "SBD", "SCR", "SDG", "SEK", "SGD",
+	"SHP", "SLE", "SOS", "SRD", "SSP", "STN", "SVC", "SYP", "SZL", "THB", "TJS", "TMT", "TND", "TOP", "TRY",
+	"TTD", "TWD", "TZS", "UAH", "UGX", "USD", "UYU", "UZS", "VES", "VND", "VUV", "WST", "XAF", "XCD", "XOF",
+	"XPF", "YER", "ZAR", "ZMW", "ZWL",
+)
+
+// ibanLengths are the IBAN lengths of the countries using IBAN.
+var ibanLengths = map[string]int{
+	"AD": 24, "AE": 23, "AL": 28, "AT": 20, "AZ": 28, "BA": 20, "BE": 16, "BG": 22, "BH": 22, "BR": 29,
+	"BY": 28, "CH": 21, "CR": 22, "CY": 28, "CZ": 24, "DE": 22, "DK": 18, "DO": 28, "EE": 20, "EG": 29,
+	"ES": 24, "FI": 18, "FO": 18, "FR": 27, "GB": 22, "GE": 22, "GI": 23, "GL": 18, "GR": 27, "GT": 28,
+	"HR": 21, "HU": 28, "IE": 22, "IL": 23, "IQ": 23, "IS": 26, "IT": 27, "JO": 30, "KW": 30, "KZ": 20,
+	"LB": 28, "LC": 32, "LI": 21, "LT": 20, "LU": 20, "LV": 21, "MC": 27, "MD": 24, "ME": 22, "MK": 19,
+	"MR": 27, "MT": 31, "MU": 30, "NL": 18, "NO": 15, "PK": 24, "PL": 28, "PS": 29, "PT": 25, "QA": 29,
+	"RO": 24, "RS": 22, "SA": 24, "SC": 31, "SE": 24, "SI": 19, "SK": 24, "SM": 27, "ST": 25, "SV": 28,
+	"TL": 23, "TN": 24, "TR": 26, "UA": 29, "VA": 22, "VG": 24, "XK": 20,
+}
+
+var hundred = inf.NewDec(100, 0)
+
+func sets(values ...string) map[string]struct{} {
+	s := make(map[string]struct{}, len(values))
+	for _, v := range values {
+		s[v] = struct{}{}
+	}
+	return s
+}
+
+// ValidateInvoice returns the errors of an Invoice object.
+func ValidateInvoice(invoice *Invoice) field.ErrorList {
+	specPath := field.NewPath("spec")
+	allErrs := ValidateInvoiceData(&invoice.Spec.InvoiceData, specPath.Child("invoiceData"))
+	allErrs = append(allErrs, validateParties(&invoice.Spec, specPath)...)
+	allErrs = append(allErrs, validateProfile(&invoice.Spec, specPath)...)
+	allErrs = append(allErrs, validateAdvances(&invoice.Spec, specPath)...)
+	allErrs = append(allErrs, validateExposure(&invoice.Spec.Exposure, specPath.Child("exposure"))...)
+
+	return allErrs
+}
+
+// ValidateInvoiceData returns the errors of the invoice data.
+func ValidateInvoiceData(data *InvoiceData, path *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	issueDate, issueErr := ParseDate(data.IssueDate)
+	if issueErr != nil {
+		allErrs = append(allErrs, field.Invalid(path.Child("issueDate"), data.IssueDate, "must be a date in DD-MM-YYYY format"))
+	}
+	if _, err := ParseDate(data.SaleDate); err != nil {
+		allErrs = append(allErrs, field.Invalid(path.Child("saleDate"), data.SaleDate, "must be a date in DD-MM-YYYY format"))
+	}
+	dueDate, dueErr := ParseDate(data.DueDate)
+	if dueErr != nil {
+		allErrs = append(allErrs, field.Invalid(path.Child("dueDate"), data.DueDate, "must be a date in DD-MM-YYYY format"))
+	}
+	if issueErr == nil && dueErr == nil && dueDate.Before(issueDate) {
+		allErrs = append(allErrs, field.Invalid(path.Child("dueDate"), data.DueDate, "must not be before the issue date"))
+	}
+
+	if _, ok := currencies[data.Currency]; !ok {
+		allErrs = append(allErrs, field.Invalid(path.Child("currency"), data.Currency, "must be an ISO 4217 currency code"))
+	}
+
+	if err := validateAccountNumber(data.Bank.AccountNumber); err != nil {
+		allErrs = append(allErrs, field.Invalid(path.Child("bank", "accountNumber"), data.Bank.AccountNumber, err.Error()))
+	}
+
+	companyPath := path.Child("company")
+	if err := validateNIP(data.Company.Buyer.NIP); err != nil {
+		allErrs = append(allErrs, field.Invalid(companyPath.Child("buyer", "nip"), data.Company.Buyer.NIP, err.Error()))
+	}
+	if err := validateNIP(data.Company.Seller.NIP); err != nil {
+		allErrs = append(allErrs, field.Invalid(companyPath.Child("seller", "nip"), data.Company.Seller.NIP, err.Error()))
+	}
+	allErrs = append(allErrs, validateEndpoint(data.Company.Buyer.EndpointID, data.Company.Buyer.EndpointScheme, companyPath.Child("buyer"))...)
+	allErrs = append(allErrs, validateEndpoint(data.Company.Seller.EndpointID, data.Company.Seller.EndpointScheme, companyPath.Child("seller"))...)
+
+	if data.Options.Output == OutputFacturX && data.Options.FontFamily == "" {
+		allErrs = append(allErrs, field.Required(path.Child("options", "font"), "Factur-X embeds its fonts, a font is required"))
+	}
+
+	itemsPath := path.Child("items")
+	if len(data.Items) == 0 {
+		allErrs = append(allErrs, field.Required(itemsPath, "at least one item is required"))
+	}
+	for i, item := range data.Items {
+		allErrs = append(allErrs, validateItem(item, itemsPath.Index(i))...)
+	}
+
+	return allErrs
+}
+
+func validateItem(item *Item, path *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+	if item == nil {
+		return append(allErrs, field.Required(path, "item must not be null"))
+	}
+
+	if strings.TrimSpace(item.Description) == "" {
+		allErrs = append(allErrs, field.Required(path.Child("description"), "item description is required"))
+	}
+	if d, ok := parseDecimal(item.Quantity); !ok {
+		allErrs = append(allErrs, field.Invalid(path.Child("quantity"), item.Quantity, "must be a decimal number"))
+	} else if d.Sign() <= 0 {
+		allErrs = append(allErrs, field.Invalid(path.Child("quantity"), item.Quantity, "must be greater than zero"))
+	}
+	if d, ok := parseDecimal(item.UnitPrice); !ok {
+		allErrs = append(allErrs, field.Invalid(path.Child("unitPrice"), item.UnitPrice, "must be a decimal number"))
+	} else if d.Sign() < 0 {
+		allErrs = append(allErrs, field.Invalid(path.Child("unitPrice"), item.UnitPrice, "must not be negative"))
+	}
+	if d, ok := parseDecimal(item.VATRate); !ok {
+		allErrs = append(allErrs, field.Invalid(path.Child("vatRate"), item.VATRate, "must be a decimal number"))
+	} else if d.Sign() < 0 || d.Cmp(hundred) > 0 {
+		allErrs = append(allErrs, field.Invalid(path.Child("vatRate"), item.VATRate, "must be between 0 and 100"))
+	}
+
+	return allErrs
+}
+
+// ValidateIssuedUpdate rejects changes to the document of an issued invoice.
+// Only the number, allocated by the controller, may be set afterwards. An
+// invoice counts as issued once the controller has moved its status out of
+// Draft, so an invoice held in Draft by strict rules can still be fixed.
+func ValidateIssuedUpdate(old, invoice *Invoice) field.ErrorList {
+	if !old.IsIssued() {
+		return nil
+	}
+
+	allErrs := field.ErrorList{}
+	specPath := field.NewPath("spec")
+	const detail = "cannot be changed once the invoice is issued, issue a correction instead"
+
+	before := old.Spec.InvoiceData.DeepCopy()
+	if before.Number == "" {
+		before.Number = invoice.Spec.InvoiceData.Number
+	}
+	if !apiequality.Semantic.DeepEqual(before, &invoice.Spec.InvoiceData) {
+		allErrs = append(allErrs, field.Forbidden(specPath.Child("invoiceData"), detail))
+	}
+	if old.Spec.DocumentType != invoice.Spec.DocumentType {
+		allErrs = append(allErrs, field.Forbidden(specPath.Child("documentType"), detail))
+	}
+	if !apiequality.Semantic.DeepEqual(old.Spec.AdvanceRefs, invoice.Spec.AdvanceRefs) {
+		allErrs = append(allErrs, field.Forbidden(specPath.Child("advanceRefs"), detail))
+	}
+	if !apiequality.Semantic.DeepEqual(old.Spec.CustomerRef, invoice.Spec.CustomerRef) {
+		allErrs = append(allErrs, field.Forbidden(specPath.Child("customerRef"), detail))
+	}
+	if !apiequality.Semantic.DeepEqual(old.Spec.SellerRef, invoice.Spec.SellerRef) {
+		allErrs = append(allErrs, field.Forbidden(specPath.Child("sellerRef"), detail))
+	}
+
+	return allErrs
+}
+
+// validateAdvances checks that only final invoices settle advance invoices.
+func validateAdvances(spec *InvoiceSpec, path *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+	refsPath := path.Child("advanceRefs")
+	if len(spec.AdvanceRefs) > 0 && spec.DocumentType != DocumentFinal {
+		allErrs = append(allErrs, field.Forbidden(refsPath, "only final invoices settle advance invoices"))
+	}
+	seen := map[string]bool{}
+	for i, ref := range spec.AdvanceRefs {
+		switch {
+		case ref.Name == "":
+			allErrs = append(allErrs, field.Required(refsPath.Index(i).Child("name"), "advance invoice name is required"))
+		case seen[ref.Name]:
+			allErrs = append(allErrs, field.Duplicate(refsPath.Index(i).Child("name"), ref.Name))
+		}
+		seen[ref.Name] = true
+	}
+	return allErrs
+}
+
+// validateParties checks that both parties are given, either inline or by reference.
+func validateParties(spec *InvoiceSpec, path *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+	company := spec.InvoiceData.Company
+	companyPath := path.Child("invoiceData", "company")
+
+	if spec.CustomerRef != nil {
+		if spec.CustomerRef.Name == "" {
+			allErrs = append(allErrs, field.Required(path.Child("customerRef", "name"), "customer name is required"))
+		}
+	} else if company.Buyer.Name == "" {
+		allErrs = append(allErrs, field.Required(companyPath.Child("buyer", "name"), "buyer is required unless customerRef is set"))
+	}
+
+	if spec.SellerRef != nil {
+		if spec.SellerRef.Name == "" {
+			allErrs = append(allErrs, field.Required(path.Child("sellerRef", "name"), "seller profile name is required"))
+		}
+	} else if company.Seller.Name == "" {
+		allErrs = append(allErrs, field.Required(companyPath.Child("seller", "name"), "seller is required unless sellerRef is set"))
+	}
+
+	return allErrs
+}
+
+// validateProfile checks the fields the e-invoice profile of the invoice makes
+// mandatory. A buyer taken from a Customer and a seller taken from a
+// SellerProfile are checked when the invoice is reconciled.
+func validateProfile(spec *InvoiceSpec, path *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+	data := &spec.InvoiceData
+	profile := data.Options.Profile
+	xrechnung := profile == ProfileXRechnungUBL || profile == ProfileXRechnungCII
+	if !xrechnung && profile != ProfilePeppolBIS {
+		return allErrs
+	}
+
+	detail := "is required by the " + string(profile) + " profile"
+	require := func(value string, path *field.Path) {
+		if value == "" {
+			allErrs = append(allErrs, field.Required(path, detail))
+		}
+	}
+	dataPath := path.Child("invoiceData")
+	companyPath := dataPath.Child("company")
+	if spec.CustomerRef == nil {
+		buyer, buyerPath := &data.Company.Buyer, companyPath.Child("buyer")
+		require(buyer.BuyerReference, buyerPath.Child("buyerReference"))
+		require(buyer.EndpointID, buyerPath.Child("endpointID"))
+		require(buyer.CountryCode, buyerPath.Child("countryCode"))
+		if xrechnung {
+			require(buyer.City, buyerPath.Child("city"))
+			require(buyer.PostalCode, buyerPath.Child("postalCode"))
+		}
+	}
+	if spec.SellerRef == nil {
+		seller, sellerPath := &data.Company.Seller, companyPath.Child("seller")
+		require(seller.EndpointID, sellerPath.Child("endpointID"))
+		require(seller.CountryCode, sellerPath.Child("countryCode"))
+		if xrechnung {
+			require(seller.City, sellerPath.Child("city"))
+			require(seller.PostalCode, sellerPath.Child("postalCode"))
+			contact, contactPath := seller.Contact, sellerPath.Child("contact")
+			if contact == nil {
+				contact = &Contact{}
+			}
+			require(contact.Name, contactPath.Child("name"))
+			require(contact.Phone, contactPath.Child("phone"))
+			require(contact.Email, contactPath.Child("email"))
+		}
+	}
+	if xrechnung {
+		require(strings.TrimSpace(data.Bank.AccountNumber), dataPath.Child("bank", "accountNumber"))
+	}
+
+	return allErrs
+}
+
+func validateExposure(exposure *Exposure, path *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+	if exposure.PublicURL == "" {
+		return allErrs
+	}
+
+	u, err := url.Parse(exposure.PublicURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		allErrs = append(allErrs, field.Invalid(path.Child("publicURL"), exposure.PublicURL, "must be an absolute http or https URL"))
+	}
+
+	return allErrs
+}
+
+// validateAccountNumber checks account numbers written as IBAN, that is
+// starting with a country code. Other account numbers may only contain
+// digits, spaces and dashes.
+func validateAccountNumber(number string) error {
+	if number == "" {
+		return nil
+	}
+	iban := strings.ToUpper(strings.ReplaceAll(number, " ", ""))
+	if len(iban) < 2 || iban[0] < 'A' || iban[0] > 'Z' || iban[1] < 'A' || iban[1] > 'Z' {
+		for _, c := range number {
+			if (c < '0' || c > '9') && c != ' ' && c != '-' {
+				return fmt.Errorf("account number may only contain digits, spaces and dashes")
+			}
+		}
+		return nil
+	}
+
+	length, ok := ibanLengths[iban[:2]]
+	if !ok {
+		return fmt.Errorf("unknown IBAN country code %s", iban[:2])
+	}
+	if len(iban) != length {
+		return fmt.Errorf("IBAN of %s must have %d characters", iban[:2], length)
+	}
+
+	var digits strings.Builder
+	for _, c := range iban[4:] + iban[:4] {
+		switch {
+		case c >= '0' && c <= '9':
+			digits.WriteRune(c)
+		case c >= 'A' && c <= 'Z':
+			fmt.Fprintf(&digits, "%d", c-'A'+10)
+		default:
+			return fmt.Errorf("IBAN may only contain letters and digits")
+		}
+	}
+	n, _ := new(big.Int).SetString(digits.String(), 10)
+	if new(big.Int).Mod(n, big.NewInt(97)).Int64() != 1 {
+		return fmt.Errorf("IBAN check digits are invalid")
+	}
+
+	return nil
+}
+
+// validateNIP checks the check digit of a Polish tax identification number.
+func validateNIP(nip string) error {
+	if nip == "" {
+		return nil
+	}
+	if len(nip) != 10 || strings.Trim(nip, "0123456789") != "" {
+		return fmt.Errorf("NIP must have 10 digits")
+	}
+	sum := 0
+	for i, w := range []int{6, 5, 7, 2, 3, 4, 5, 6, 7} {
+		sum += w * int(nip[i]-'0')
+	}
+	if sum%11 != int(nip[9]-'0') {
+		return fmt.Errorf("NIP check digit is invalid")
+	}
+	return nil
+}
+
+// validateEndpoint checks that an electronic address comes with its scheme.
+func validateEndpoint(id, scheme string, path *field.Path) field.ErrorList {
+	switch {
+	case id != "" && scheme == "":
+		return field.ErrorList{field.Required(path.Child("endpointScheme"), "the scheme of the electronic address is required")}
+	case id == "" && scheme != "":
+		return field.ErrorList{field.Required(path.Child("endpointID"), "the electronic address of the scheme is required")}
+	}
+	return nil
+}
+
+func parseDecimal(value Decimal) (*inf.Dec, bool) {
+	return new(inf.Dec).SetString(string(value))
+}
+
+// ValidateCreditNote returns the errors of a CreditNote correcting the invoice.
+// The lines of the note refer to the corrected data, the data of the invoice
+// corrected by the credit notes issued before.
+func ValidateCreditNote(note *CreditNote, invoice *Invoice, corrected *InvoiceData) field.ErrorList {
+	allErrs := field.ErrorList{}
+	specPath := field.NewPath("spec")
+
+	if invoice.Status.State == "" || invoice.Status.State == Draft {
+		allErrs = append(allErrs, field.Invalid(specPath.Child("invoiceRef", "name"), note.Spec.InvoiceRef.Name,
+			"only issued invoices are corrected, a draft invoice can be edited"))
+	}
+	if _, err := ParseDate(note.Spec.IssueDate); err != nil {
+		allErrs = append(allErrs, field.Invalid(specPath.Child("issueDate"), note.Spec.IssueDate, "must be a date in DD-MM-YYYY format"))
+	}
+	if strings.TrimSpace(note.Spec.Reason) == "" {
+		allErrs = append(allErrs, field.Required(specPath.Child("reason"), "reason of the correction is required"))
+	}
+
+	itemsPath := specPath.Child("items")
+	if len(note.Spec.Items) == 0 {
+		allErrs = append(allErrs, field.Required(itemsPath, "at least one item is required"))
+	}
+	lines := len(corrected.Items)
+	for i, item := range note.Spec.Items {
+		path := itemsPath.Index(i)
+		if item.Line != nil && (*item.Line < 1 || int(*item.Line) > lines) {
+			allErrs = append(allErrs, field.Invalid(path.Child("line"), *item.Line, fmt.Sprintf("must be between 1 and %d", lines)))
+		}
+		switch {
+		case item.Negate && item.Line == nil:
+			allErrs = append(allErrs, field.Required(path.Child("line"), "only lines of the invoice can be negated"))
+		case item.Negate && item.Corrected != nil:
+			allErrs = append(allErrs, field.Forbidden(path.Child("corrected"), "a negated line has no corrected values"))
+		case !item.Negate:
+			allErrs = append(allErrs, validateItem(item.Corrected, path.Child("corrected"))...)
+		}
+	}
+
+	return allErrs
+}
+
+// ValidatePayment returns the errors of a Payment recorded against the invoice.
+func ValidatePayment(payment *Payment, invoice *Invoice) field.ErrorList {
+	allErrs := field.ErrorList{}
+	specPath := field.NewPath("spec")
+
+	if invoice.Status.State == "" || invoice.Status.State == Draft {
+		allErrs = append(allErrs, field.Invalid(specPath.Child("invoiceRef", "name"), payment.Spec.InvoiceRef.Name,
+			"only issued invoices are paid"))
+	}
+	if d, ok := parseDecimal(payment.Spec.Amount); !ok {
+		allErrs = append(allErrs, field.Invalid(specPath.Child("amount"), payment.Spec.Amount, "must be a decimal number"))
+	} else if d.Sign() == 0 {
+		allErrs = append(allErrs, field.Invalid(specPath.Child("amount"), payment.Spec.Amount, "must not be zero"))
+	}
+	if _, err := ParseDate(payment.Spec.Date); err != nil {
+		allErrs = append(allErrs, field.Invalid(specPath.Child("date"), payment.Spec.Date, "must be a date in DD-MM-YYYY format"))
+	}
+
+	return allErrs
+}
+
+// ValidateInterestNote returns the errors of an InterestNote.
+func ValidateInterestNote(note *InterestNote) field.ErrorList {
+	allErrs := field.ErrorList{}
+	specPath := field.NewPath("spec")
+
+	if _, err := ParseDate(note.Spec.IssueDate); err != nil {
+		allErrs = append(allErrs, field.Invalid(specPath.Child("issueDate"), note.Spec.IssueDate, "must be a date in DD-MM-YYYY format"))
+	}
+	if note.Spec.DueDate != "" {
+		if _, err := ParseDate(note.Spec.DueDate); err != nil {
+			allErrs = append(allErrs, field.Invalid(specPath.Child("dueDate"), note.Spec.DueDate, "must be a date in DD-MM-YYYY format"))
+		}
+	}
+	from, fromErr := ParseDate(note.Spec.From)
+	if fromErr != nil {
+		allErrs = append(allErrs, field.Invalid(specPath.Child("from"), note.Spec.From, "must be a date in DD-MM-YYYY format"))
+	}
+	to, toErr := ParseDate(note.Spec.To)
+	if toErr != nil {
+		allErrs = append(allErrs, field.Invalid(specPath.Child("to"), note.Spec.To, "must be a date in DD-MM-YYYY format"))
+	}
+	if fromErr == nil && toErr == nil && to.Before(from) {
+		allErrs = append(allErrs, field.Invalid(specPath.Child("to"), note.Spec.To, "must not be before the start of the period"))
+	}
+
+	switch {
+	case note.Spec.CustomerRef == nil && note.Spec.BuyerVAT == "":
+		allErrs = append(allErrs, field.Required(specPath.Child("customerRef"), "customerRef or buyerVAT selecting the invoices is required"))
+	case note.Spec.CustomerRef != nil && note.Spec.BuyerVAT != "":
+		allErrs = append(allErrs, field.Forbidden(specPath.Child("buyerVAT"), "cannot be set together with customerRef"))
+	}
+
+	return allErrs
+}
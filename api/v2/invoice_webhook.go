@@ -0,0 +1,198 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v2
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// SetupWebhookWithManager registers the Invoice webhooks, including the
+// conversion webhook serving the older API versions.
+func (r *Invoice) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(r).
+		WithDefaulter(&InvoiceDefaulter{Client: mgr.GetClient()}).
+		WithValidator(&InvoiceValidator{Client: mgr.GetClient()}).
+		Complete()
+}
+
+//+kubebuilder:webhook:path=/mutate-facturnetes-cnvergence-io-v2-invoice,mutating=true,failurePolicy=fail,sideEffects=None,groups=facturnetes.cnvergence.io,resources=invoices,verbs=create,versions=v2,name=minvoice.kb.io,admissionReviewVersions=v1
+
+// InvoiceDefaulter fills the missing invoice data from the SellerProfile of
+// the namespace.
+// +kubebuilder:object:generate=false
+type InvoiceDefaulter struct {
+	Client client.Reader
+}
+
+var _ admission.CustomDefaulter = &InvoiceDefaulter{}
+
+// Default implements admission.CustomDefaulter so a webhook will be registered for the type
+func (d *InvoiceDefaulter) Default(ctx context.Context, obj runtime.Object) error {
+	invoice := obj.(*Invoice)
+	data := &invoice.Spec.InvoiceData
+
+	if data.IssueDate == "" {
+		data.IssueDate = time.Now().Format(DateLayout)
+	}
+	if data.SaleDate == "" {
+		data.SaleDate = data.IssueDate
+	}
+
+	namespace := invoice.Namespace
+	if req, err := admission.RequestFromContext(ctx); err == nil && req.Namespace != "" {
+		namespace = req.Namespace
+	}
+	profile, err := d.profile(ctx, namespace, invoice.Spec.SellerRef)
+	if err != nil {
+		return err
+	}
+	if profile != nil {
+		profile.Spec.ApplyTo(data)
+	}
+
+	return nil
+}
+
+// profile returns the referenced SellerProfile, or the default one of the
+// namespace when the invoice has no sellerRef. A missing referenced profile is
+// left to the controller to report.
+func (d *InvoiceDefaulter) profile(ctx context.Context, namespace string, ref *corev1.LocalObjectReference) (*SellerProfile, error) {
+	if ref == nil {
+		return d.defaultProfile(ctx, namespace)
+	}
+
+	profile := &SellerProfile{}
+	err := d.Client.Get(ctx, client.ObjectKey{Namespace: namespace, Name: ref.Name}, profile)
+	if apierrors.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return profile, nil
+}
+
+// defaultProfile returns the SellerProfile marked as default in the
+// namespace, or the only SellerProfile of the namespace.
+func (d *InvoiceDefaulter) defaultProfile(ctx context.Context, namespace string) (*SellerProfile, error) {
+	profiles := &SellerProfileList{}
+	if err := d.Client.List(ctx, profiles, client.InNamespace(namespace)); err != nil {
+		return nil, err
+	}
+
+	var found *SellerProfile
+	for i := range profiles.Items {
+		if !profiles.Items[i].Spec.Default {
+			continue
+		}
+		if found != nil {
+			return nil, fmt.Errorf("namespace %s has more than one default SellerProfile: %s, %s",
+				namespace, found.Name, profiles.Items[i].Name)
+		}
+		found = &profiles.Items[i]
+	}
+	if found == nil && len(profiles.Items) == 1 {
+		found = &profiles.Items[0]
+	}
+
+	return found, nil
+}
+
+//+kubebuilder:webhook:path=/validate-facturnetes-cnvergence-io-v2-invoice,mutating=false,failurePolicy=fail,sideEffects=None,groups=facturnetes.cnvergence.io,resources=invoices,verbs=create;update,versions=v2,name=vinvoice.kb.io,admissionReviewVersions=v1
+
+// InvoiceValidator checks the invoices written to the cluster, including the
+// numbers already used in the namespace.
+// +kubebuilder:object:generate=false
+type InvoiceValidator struct {
+	Client client.Reader
+}
+
+var _ admission.CustomValidator = &InvoiceValidator{}
+
+// ValidateCreate implements admission.CustomValidator so a webhook will be registered for the type
+func (v *InvoiceValidator) ValidateCreate(ctx context.Context, obj runtime.Object) error {
+	invoice := obj.(*Invoice)
+	allErrs := ValidateInvoice(invoice)
+	allErrs = append(allErrs, v.validateNumber(ctx, invoice)...)
+
+	return invalid(invoice, allErrs)
+}
+
+// ValidateUpdate implements admission.CustomValidator so a webhook will be registered for the type
+func (v *InvoiceValidator) ValidateUpdate(ctx context.Context, oldObj, newObj runtime.Object) error {
+	invoice := newObj.(*Invoice)
+	allErrs := ValidateInvoice(invoice)
+	allErrs = append(allErrs, v.validateNumber(ctx, invoice)...)
+
+	prev := oldObj.(*Invoice)
+	allErrs = append(allErrs, ValidateIssuedUpdate(prev, invoice)...)
+	from, to := prev.Spec.State, invoice.Spec.State
+	if from == "" {
+		from = Draft
+	}
+	if to == "" {
+		to = Draft
+	}
+	// An invoice the controller has not issued yet, such as one held in Draft
+	// by strict rules, may be taken back to Draft.
+	if !from.CanTransitionTo(to) && !(to == Draft && !prev.IsIssued()) {
+		allErrs = append(allErrs, field.Invalid(field.NewPath("spec", "state"), invoice.Spec.State,
+			"cannot change state from "+string(from)+" to "+string(to)))
+	}
+
+	return invalid(invoice, allErrs)
+}
+
+// ValidateDelete implements admission.CustomValidator so a webhook will be registered for the type
+func (v *InvoiceValidator) ValidateDelete(ctx context.Context, obj runtime.Object) error {
+	return nil
+}
+
+// validateNumber rejects a number already used by an older invoice of the
+// same seller. The webhook may run without the controller and its field
+// index, so the namespace is listed as a whole.
+func (v *InvoiceValidator) validateNumber(ctx context.Context, invoice *Invoice) field.ErrorList {
+	path := field.NewPath("spec", "invoiceData", "number")
+	duplicate, err := FindDuplicate(ctx, v.Client, invoice)
+	if err != nil {
+		return field.ErrorList{field.InternalError(path, err)}
+	}
+	if duplicate != nil {
+		err := field.Duplicate(path, invoice.Spec.InvoiceData.Number)
+		err.Detail = "already used by invoice " + duplicate.Name
+		return field.ErrorList{err}
+	}
+	return nil
+}
+
+func invalid(invoice *Invoice, allErrs field.ErrorList) error {
+	if len(allErrs) == 0 {
+		return nil
+	}
+	return apierrors.NewInvalid(GroupVersion.WithKind("Invoice").GroupKind(), invoice.Name, allErrs)
+}
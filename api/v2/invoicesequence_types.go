@@ -0,0 +1,124 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v2
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ResetPeriod tells when the counter of an InvoiceSequence starts again from one.
+// +kubebuilder:validation:Enum=Never;Yearly;Monthly
+type ResetPeriod string
+
+const (
+	ResetNever   ResetPeriod = "Never"
+	ResetYearly  ResetPeriod = "Yearly"
+	ResetMonthly ResetPeriod = "Monthly"
+)
+
+// SequenceDocumentType is a document type numbered by an InvoiceSequence.
+// +kubebuilder:validation:Enum=Invoice;Proforma;Advance;Final;CreditNote;InterestNote
+type SequenceDocumentType DocumentType
+
+// InvoiceSequenceSpec defines the numbering of the invoices.
+type InvoiceSequenceSpec struct {
+	// Default marks the sequence numbering the invoices of the namespace
+	// without a sequenceRef.
+	// +optional
+	Default bool `json:"default,omitempty"`
+
+	// DocumentTypes lists the document types numbered by the sequence when
+	// they have no sequenceRef, so that every type can have its own series. A
+	// sequence listing the type is preferred over the default sequence.
+	// +optional
+	DocumentTypes []SequenceDocumentType `json:"documentTypes,omitempty"`
+
+	// Format of the invoice numbers. {YYYY}, {YY}, {MM} and {DD} are replaced
+	// with the issue date and {seq} or {seq:N} with the counter, padded with
+	// zeros to N digits, e.g. FV/{YYYY}/{MM}/{seq:4}.
+	// +kubebuilder:validation:Pattern=`\{seq(:[0-9]+)?\}`
+	Format string `json:"format"`
+
+	// Reset period of the counter. The format must contain the year, or the
+	// year and the month, of a yearly or monthly reset.
+	// +kubebuilder:default:=Never
+	// +optional
+	Reset ResetPeriod `json:"reset,omitempty"`
+}
+
+// SequencePeriod is the counter of one reset period.
+type SequencePeriod struct {
+	// Period is the year (2022) or the month (2022-01) of the counter, empty
+	// when the sequence never resets.
+	Period string `json:"period"`
+	// Last allocated value of the counter.
+	Last int64 `json:"last"`
+}
+
+// InvoiceSequenceStatus defines the allocated numbers of the sequence.
+type InvoiceSequenceStatus struct {
+	// Periods holds the counter of every period numbers were allocated in,
+	// so that backdated invoices continue their own period.
+	// +listType=map
+	// +listMapKey=period
+	// +optional
+	Periods []SequencePeriod `json:"periods,omitempty"`
+	// LastNumber is the last allocated invoice number.
+	// +optional
+	LastNumber string `json:"lastNumber,omitempty"`
+	// Gaps lists the counter values missing from the invoices of the
+	// namespace numbered with the format of the sequence, for auditors.
+	// +optional
+	Gaps []SequenceGap `json:"gaps,omitempty"`
+}
+
+// SequenceGap lists the missing counter values of one period.
+type SequenceGap struct {
+	// Period is the year (2022) or the month (2022-01) of the gap.
+	Period string `json:"period"`
+	// Missing counter values, lowest first. At most 100 values are listed.
+	Missing []int64 `json:"missing"`
+	// Count of the missing counter values.
+	Count int64 `json:"count"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Format",type="string",JSONPath=".spec.format"
+// +kubebuilder:printcolumn:name="Reset",type="string",JSONPath=".spec.reset"
+// +kubebuilder:printcolumn:name="Last",type="string",JSONPath=".status.lastNumber"
+// InvoiceSequence is the Schema for the invoicesequences API
+type InvoiceSequence struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   InvoiceSequenceSpec   `json:"spec,omitempty"`
+	Status InvoiceSequenceStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// InvoiceSequenceList contains a list of InvoiceSequence
+type InvoiceSequenceList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []InvoiceSequence `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&InvoiceSequence{}, &InvoiceSequenceList{})
+}
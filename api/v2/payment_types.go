@@ -0,0 +1,78 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v2
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// PaymentMethod is the way a payment was made.
+// +kubebuilder:validation:Enum=BankTransfer;Card;Cash;DirectDebit;Other
+type PaymentMethod string
+
+const (
+	PaymentBankTransfer PaymentMethod = "BankTransfer"
+	PaymentCard         PaymentMethod = "Card"
+	PaymentCash         PaymentMethod = "Cash"
+	PaymentDirectDebit  PaymentMethod = "DirectDebit"
+	PaymentOther        PaymentMethod = "Other"
+)
+
+// PaymentSpec records a payment received for an invoice.
+type PaymentSpec struct {
+	// InvoiceRef names the paid Invoice.
+	InvoiceRef corev1.LocalObjectReference `json:"invoiceRef"`
+
+	// Amount received in the currency of the invoice. A negative amount
+	// records a refund.
+	Amount Decimal `json:"amount"`
+	// Date the payment was received.
+	Date string `json:"date"`
+	// +kubebuilder:default:=BankTransfer
+	// +optional
+	Method PaymentMethod `json:"method,omitempty"`
+	// Reference of the payment, e.g. the title of the bank transfer.
+	// +optional
+	Reference string `json:"reference,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:printcolumn:name="Invoice",type="string",JSONPath=".spec.invoiceRef.name"
+// +kubebuilder:printcolumn:name="Amount",type="string",JSONPath=".spec.amount"
+// +kubebuilder:printcolumn:name="Date",type="string",JSONPath=".spec.date"
+// +kubebuilder:printcolumn:name="Method",type="string",JSONPath=".spec.method"
+// Payment is the Schema for the payments API
+type Payment struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec PaymentSpec `json:"spec,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// PaymentList contains a list of Payment
+type PaymentList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []Payment `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&Payment{}, &PaymentList{})
+}
@@ -0,0 +1,141 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v2
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// InvoiceTemplate describes the invoices created by a RecurringInvoice.
+type InvoiceTemplate struct {
+	// Labels and annotations of the created invoices.
+	// +optional
+	Metadata TemplateMetadata `json:"metadata,omitempty"`
+
+	// Spec of the created invoices. The number is allocated from the
+	// InvoiceSequence and the issue date is the scheduled date. The notes, the
+	// item descriptions, the sale date and the due date may use Go templates
+	// with the scheduled date as .IssueDate, e.g. {{ .IssueDate.Format "01/2006" }}.
+	Spec InvoiceSpec `json:"spec"`
+}
+
+// TemplateMetadata holds the labels and annotations of the created invoices.
+type TemplateMetadata struct {
+	// +optional
+	Labels map[string]string `json:"labels,omitempty"`
+	// +optional
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// RecurringInvoiceSpec defines the schedule of the recurring invoices.
+type RecurringInvoiceSpec struct {
+	// Schedule in cron format, e.g. "0 6 1 * *" for 6:00 on the first day of the month.
+	// +kubebuilder:validation:MinLength=1
+	Schedule string `json:"schedule"`
+
+	// TimeZone of the schedule and of the issue dates, e.g. Europe/Warsaw.
+	// +kubebuilder:default:=UTC
+	// +optional
+	TimeZone string `json:"timeZone,omitempty"`
+
+	// StartingDeadlineSeconds is the deadline for creating an invoice that
+	// missed its scheduled time, e.g. while the controller was down. Only the
+	// invoice of the latest missed run is created, and only within the
+	// deadline. Without it, the invoice of the latest missed run is created
+	// however late, the earlier missed runs are skipped.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	StartingDeadlineSeconds *int64 `json:"startingDeadlineSeconds,omitempty"`
+
+	// Suspend stops the creation of invoices. On resume, the invoice of the
+	// latest run missed while suspended is created unless it is past the
+	// starting deadline.
+	// +optional
+	Suspend *bool `json:"suspend,omitempty"`
+
+	// PaymentTermDays sets the due date of the created invoices, when the
+	// template has none, to the issue date plus the given days.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	PaymentTermDays *int32 `json:"paymentTermDays,omitempty"`
+
+	// HistoryLimit is the number of created invoices listed in the status.
+	// Created invoices are accounting records and are never deleted.
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:default:=12
+	// +optional
+	HistoryLimit *int32 `json:"historyLimit,omitempty"`
+
+	Template InvoiceTemplate `json:"template"`
+}
+
+// RecurringRun is an invoice created by a RecurringInvoice.
+type RecurringRun struct {
+	ScheduledTime metav1.Time `json:"scheduledTime"`
+	// Invoice is the name of the created Invoice.
+	Invoice string `json:"invoice"`
+}
+
+// RecurringInvoiceStatus defines the observed state of RecurringInvoice
+type RecurringInvoiceStatus struct {
+	ObservedGeneration int64  `json:"observedGeneration,omitempty"`
+	Message            string `json:"message,omitempty"`
+
+	// LastScheduleTime is the last time an invoice was scheduled.
+	// +optional
+	LastScheduleTime *metav1.Time `json:"lastScheduleTime,omitempty"`
+	// NextScheduleTime is the next time an invoice is scheduled.
+	// +optional
+	NextScheduleTime *metav1.Time `json:"nextScheduleTime,omitempty"`
+	// History of the created invoices, newest first.
+	// +optional
+	History []RecurringRun `json:"history,omitempty"`
+
+	// +listType=map
+	// +listMapKey=type
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty" patchStrategy:"merge" patchMergeKey:"type"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Schedule",type="string",JSONPath=".spec.schedule"
+// +kubebuilder:printcolumn:name="TimeZone",type="string",JSONPath=".spec.timeZone"
+// +kubebuilder:printcolumn:name="Suspend",type="boolean",JSONPath=".spec.suspend"
+// +kubebuilder:printcolumn:name="Last",type="date",JSONPath=".status.lastScheduleTime"
+// +kubebuilder:printcolumn:name="Next",type="date",JSONPath=".status.nextScheduleTime"
+// RecurringInvoice is the Schema for the recurringinvoices API
+type RecurringInvoice struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   RecurringInvoiceSpec   `json:"spec,omitempty"`
+	Status RecurringInvoiceStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// RecurringInvoiceList contains a list of RecurringInvoice
+type RecurringInvoiceList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []RecurringInvoice `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&RecurringInvoice{}, &RecurringInvoiceList{})
+}
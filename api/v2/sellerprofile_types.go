@@ -0,0 +1,92 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v2
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// SellerProfileSpec defines the seller data shared by the invoices of a namespace.
+type SellerProfileSpec struct {
+	// Default marks the profile applied to the invoices of the namespace.
+	// +optional
+	Default bool `json:"default,omitempty"`
+
+	Seller Seller `json:"seller"`
+	// +optional
+	Bank Bank `json:"bank,omitempty"`
+	// +optional
+	Currency string `json:"currency,omitempty"`
+	// +optional
+	Signature string `json:"signature,omitempty"`
+	// +optional
+	Options Options `json:"options,omitempty"`
+
+	// PaymentTermDays is the number of days between the issue date and the due date.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	PaymentTermDays *int32 `json:"paymentTermDays,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:printcolumn:name="Seller",type="string",JSONPath=".spec.seller.name"
+// +kubebuilder:printcolumn:name="Default",type="boolean",JSONPath=".spec.default"
+// +kubebuilder:printcolumn:name="Currency",type="string",JSONPath=".spec.currency"
+// SellerProfile is the Schema for the sellerprofiles API
+type SellerProfile struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec SellerProfileSpec `json:"spec,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// SellerProfileList contains a list of SellerProfile
+type SellerProfileList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []SellerProfile `json:"items"`
+}
+
+// ApplyTo fills the empty fields of the invoice data with the profile.
+func (p *SellerProfileSpec) ApplyTo(data *InvoiceData) {
+	if data.Company.Seller.Name == "" {
+		data.Company.Seller = p.Seller
+	}
+	if data.Bank.AccountNumber == "" {
+		data.Bank = p.Bank
+	}
+	if data.Currency == "" {
+		data.Currency = p.Currency
+	}
+	if data.Signature == "" {
+		data.Signature = p.Signature
+	}
+	if data.Options.FontFamily == "" {
+		data.Options.FontFamily = p.Options.FontFamily
+	}
+	if data.DueDate == "" && p.PaymentTermDays != nil {
+		if issued, err := ParseDate(data.IssueDate); err == nil {
+			data.DueDate = issued.AddDate(0, 0, int(*p.PaymentTermDays)).Format(DateLayout)
+		}
+	}
+}
+
+func init() {
+	SchemeBuilder.Register(&SellerProfile{}, &SellerProfileList{})
+}
@@ -0,0 +1,1722 @@
+//go:build !ignore_autogenerated
+// +build !ignore_autogenerated
+
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Code generated by controller-gen. DO NOT EDIT.
+
+package v2
+
+import (
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Bank) DeepCopyInto(out *Bank) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Bank.
+func (in *Bank) DeepCopy() *Bank {
+	if in == nil {
+		return nil
+	}
+	out := new(Bank)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Buyer) DeepCopyInto(out *Buyer) {
+	*out = *in
+	if in.AddressLines != nil {
+		in, out := &in.AddressLines, &out.AddressLines
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Italy != nil {
+		in, out := &in.Italy, &out.Italy
+		*out = new(ItalianBuyer)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Buyer.
+func (in *Buyer) DeepCopy() *Buyer {
+	if in == nil {
+		return nil
+	}
+	out := new(Buyer)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClearanceStatus) DeepCopyInto(out *ClearanceStatus) {
+	*out = *in
+	if in.SubmittedTime != nil {
+		in, out := &in.SubmittedTime, &out.SubmittedTime
+		*out = (*in).DeepCopy()
+	}
+	if in.ClearedTime != nil {
+		in, out := &in.ClearedTime, &out.ClearedTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClearanceStatus.
+func (in *ClearanceStatus) DeepCopy() *ClearanceStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ClearanceStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Company) DeepCopyInto(out *Company) {
+	*out = *in
+	in.Buyer.DeepCopyInto(&out.Buyer)
+	in.Seller.DeepCopyInto(&out.Seller)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Company.
+func (in *Company) DeepCopy() *Company {
+	if in == nil {
+		return nil
+	}
+	out := new(Company)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Contact) DeepCopyInto(out *Contact) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Contact.
+func (in *Contact) DeepCopy() *Contact {
+	if in == nil {
+		return nil
+	}
+	out := new(Contact)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CorrectedItem) DeepCopyInto(out *CorrectedItem) {
+	*out = *in
+	if in.Line != nil {
+		in, out := &in.Line, &out.Line
+		*out = new(int32)
+		**out = **in
+	}
+	if in.Corrected != nil {
+		in, out := &in.Corrected, &out.Corrected
+		*out = new(Item)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CorrectedItem.
+func (in *CorrectedItem) DeepCopy() *CorrectedItem {
+	if in == nil {
+		return nil
+	}
+	out := new(CorrectedItem)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CreditNote) DeepCopyInto(out *CreditNote) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CreditNote.
+func (in *CreditNote) DeepCopy() *CreditNote {
+	if in == nil {
+		return nil
+	}
+	out := new(CreditNote)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *CreditNote) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CreditNoteList) DeepCopyInto(out *CreditNoteList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]CreditNote, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CreditNoteList.
+func (in *CreditNoteList) DeepCopy() *CreditNoteList {
+	if in == nil {
+		return nil
+	}
+	out := new(CreditNoteList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *CreditNoteList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CreditNoteSpec) DeepCopyInto(out *CreditNoteSpec) {
+	*out = *in
+	out.InvoiceRef = in.InvoiceRef
+	if in.SequenceRef != nil {
+		in, out := &in.SequenceRef, &out.SequenceRef
+		*out = new(v1.LocalObjectReference)
+		**out = **in
+	}
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]CorrectedItem, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CreditNoteSpec.
+func (in *CreditNoteSpec) DeepCopy() *CreditNoteSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(CreditNoteSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CreditNoteStatus) DeepCopyInto(out *CreditNoteStatus) {
+	*out = *in
+	if in.Totals != nil {
+		in, out := &in.Totals, &out.Totals
+		*out = new(InvoiceTotals)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.IssuedTime != nil {
+		in, out := &in.IssuedTime, &out.IssuedTime
+		*out = (*in).DeepCopy()
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]metav1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CreditNoteStatus.
+func (in *CreditNoteStatus) DeepCopy() *CreditNoteStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(CreditNoteStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Customer) DeepCopyInto(out *Customer) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Customer.
+func (in *Customer) DeepCopy() *Customer {
+	if in == nil {
+		return nil
+	}
+	out := new(Customer)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *Customer) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CustomerList) DeepCopyInto(out *CustomerList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]Customer, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CustomerList.
+func (in *CustomerList) DeepCopy() *CustomerList {
+	if in == nil {
+		return nil
+	}
+	out := new(CustomerList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *CustomerList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CustomerSpec) DeepCopyInto(out *CustomerSpec) {
+	*out = *in
+	in.Buyer.DeepCopyInto(&out.Buyer)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CustomerSpec.
+func (in *CustomerSpec) DeepCopy() *CustomerSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(CustomerSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Deployment) DeepCopyInto(out *Deployment) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Deployment.
+func (in *Deployment) DeepCopy() *Deployment {
+	if in == nil {
+		return nil
+	}
+	out := new(Deployment)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DunningLevel) DeepCopyInto(out *DunningLevel) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DunningLevel.
+func (in *DunningLevel) DeepCopy() *DunningLevel {
+	if in == nil {
+		return nil
+	}
+	out := new(DunningLevel)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DunningPolicy) DeepCopyInto(out *DunningPolicy) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DunningPolicy.
+func (in *DunningPolicy) DeepCopy() *DunningPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(DunningPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *DunningPolicy) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DunningPolicyList) DeepCopyInto(out *DunningPolicyList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]DunningPolicy, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DunningPolicyList.
+func (in *DunningPolicyList) DeepCopy() *DunningPolicyList {
+	if in == nil {
+		return nil
+	}
+	out := new(DunningPolicyList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *DunningPolicyList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DunningPolicySpec) DeepCopyInto(out *DunningPolicySpec) {
+	*out = *in
+	if in.Levels != nil {
+		in, out := &in.Levels, &out.Levels
+		*out = make([]DunningLevel, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DunningPolicySpec.
+func (in *DunningPolicySpec) DeepCopy() *DunningPolicySpec {
+	if in == nil {
+		return nil
+	}
+	out := new(DunningPolicySpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DunningStatus) DeepCopyInto(out *DunningStatus) {
+	*out = *in
+	if in.NextLevelTime != nil {
+		in, out := &in.NextLevelTime, &out.NextLevelTime
+		*out = (*in).DeepCopy()
+	}
+	if in.Reminders != nil {
+		in, out := &in.Reminders, &out.Reminders
+		*out = make([]Reminder, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DunningStatus.
+func (in *DunningStatus) DeepCopy() *DunningStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(DunningStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Exposure) DeepCopyInto(out *Exposure) {
+	*out = *in
+	in.Ingress.DeepCopyInto(&out.Ingress)
+	out.GatewayAPI = in.GatewayAPI
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Exposure.
+func (in *Exposure) DeepCopy() *Exposure {
+	if in == nil {
+		return nil
+	}
+	out := new(Exposure)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GatewayAPI) DeepCopyInto(out *GatewayAPI) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GatewayAPI.
+func (in *GatewayAPI) DeepCopy() *GatewayAPI {
+	if in == nil {
+		return nil
+	}
+	out := new(GatewayAPI)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Ingress) DeepCopyInto(out *Ingress) {
+	*out = *in
+	if in.Annotations != nil {
+		in, out := &in.Annotations, &out.Annotations
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Labels != nil {
+		in, out := &in.Labels, &out.Labels
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Ingress.
+func (in *Ingress) DeepCopy() *Ingress {
+	if in == nil {
+		return nil
+	}
+	out := new(Ingress)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *InterestNote) DeepCopyInto(out *InterestNote) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new InterestNote.
+func (in *InterestNote) DeepCopy() *InterestNote {
+	if in == nil {
+		return nil
+	}
+	out := new(InterestNote)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *InterestNote) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *InterestNoteLine) DeepCopyInto(out *InterestNoteLine) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new InterestNoteLine.
+func (in *InterestNoteLine) DeepCopy() *InterestNoteLine {
+	if in == nil {
+		return nil
+	}
+	out := new(InterestNoteLine)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *InterestNoteList) DeepCopyInto(out *InterestNoteList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]InterestNote, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new InterestNoteList.
+func (in *InterestNoteList) DeepCopy() *InterestNoteList {
+	if in == nil {
+		return nil
+	}
+	out := new(InterestNoteList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *InterestNoteList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *InterestNoteSpec) DeepCopyInto(out *InterestNoteSpec) {
+	*out = *in
+	if in.SequenceRef != nil {
+		in, out := &in.SequenceRef, &out.SequenceRef
+		*out = new(v1.LocalObjectReference)
+		**out = **in
+	}
+	if in.CustomerRef != nil {
+		in, out := &in.CustomerRef, &out.CustomerRef
+		*out = new(v1.LocalObjectReference)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new InterestNoteSpec.
+func (in *InterestNoteSpec) DeepCopy() *InterestNoteSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(InterestNoteSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *InterestNoteStatus) DeepCopyInto(out *InterestNoteStatus) {
+	*out = *in
+	if in.Invoices != nil {
+		in, out := &in.Invoices, &out.Invoices
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Lines != nil {
+		in, out := &in.Lines, &out.Lines
+		*out = make([]InterestNoteLine, len(*in))
+		copy(*out, *in)
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]metav1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new InterestNoteStatus.
+func (in *InterestNoteStatus) DeepCopy() *InterestNoteStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(InterestNoteStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *InterestPeriod) DeepCopyInto(out *InterestPeriod) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new InterestPeriod.
+func (in *InterestPeriod) DeepCopy() *InterestPeriod {
+	if in == nil {
+		return nil
+	}
+	out := new(InterestPeriod)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *InterestRate) DeepCopyInto(out *InterestRate) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new InterestRate.
+func (in *InterestRate) DeepCopy() *InterestRate {
+	if in == nil {
+		return nil
+	}
+	out := new(InterestRate)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *InterestRateTable) DeepCopyInto(out *InterestRateTable) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new InterestRateTable.
+func (in *InterestRateTable) DeepCopy() *InterestRateTable {
+	if in == nil {
+		return nil
+	}
+	out := new(InterestRateTable)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *InterestRateTable) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *InterestRateTableList) DeepCopyInto(out *InterestRateTableList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]InterestRateTable, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new InterestRateTableList.
+func (in *InterestRateTableList) DeepCopy() *InterestRateTableList {
+	if in == nil {
+		return nil
+	}
+	out := new(InterestRateTableList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *InterestRateTableList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *InterestRateTableSpec) DeepCopyInto(out *InterestRateTableSpec) {
+	*out = *in
+	if in.Rates != nil {
+		in, out := &in.Rates, &out.Rates
+		*out = make([]InterestRate, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new InterestRateTableSpec.
+func (in *InterestRateTableSpec) DeepCopy() *InterestRateTableSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(InterestRateTableSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Invoice) DeepCopyInto(out *Invoice) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Invoice.
+func (in *Invoice) DeepCopy() *Invoice {
+	if in == nil {
+		return nil
+	}
+	out := new(Invoice)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *Invoice) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *InvoiceBalance) DeepCopyInto(out *InvoiceBalance) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new InvoiceBalance.
+func (in *InvoiceBalance) DeepCopy() *InvoiceBalance {
+	if in == nil {
+		return nil
+	}
+	out := new(InvoiceBalance)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *InvoiceData) DeepCopyInto(out *InvoiceData) {
+	*out = *in
+	in.Company.DeepCopyInto(&out.Company)
+	out.Bank = in.Bank
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]*Item, len(*in))
+		for i := range *in {
+			if (*in)[i] != nil {
+				in, out := &(*in)[i], &(*out)[i]
+				*out = new(Item)
+				**out = **in
+			}
+		}
+	}
+	out.Options = in.Options
+	out.Rounding = in.Rounding
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new InvoiceData.
+func (in *InvoiceData) DeepCopy() *InvoiceData {
+	if in == nil {
+		return nil
+	}
+	out := new(InvoiceData)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *InvoiceInterest) DeepCopyInto(out *InvoiceInterest) {
+	*out = *in
+	if in.Periods != nil {
+		in, out := &in.Periods, &out.Periods
+		*out = make([]InterestPeriod, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new InvoiceInterest.
+func (in *InvoiceInterest) DeepCopy() *InvoiceInterest {
+	if in == nil {
+		return nil
+	}
+	out := new(InvoiceInterest)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *InvoiceList) DeepCopyInto(out *InvoiceList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]Invoice, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new InvoiceList.
+func (in *InvoiceList) DeepCopy() *InvoiceList {
+	if in == nil {
+		return nil
+	}
+	out := new(InvoiceList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *InvoiceList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *InvoiceSequence) DeepCopyInto(out *InvoiceSequence) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new InvoiceSequence.
+func (in *InvoiceSequence) DeepCopy() *InvoiceSequence {
+	if in == nil {
+		return nil
+	}
+	out := new(InvoiceSequence)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *InvoiceSequence) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *InvoiceSequenceList) DeepCopyInto(out *InvoiceSequenceList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]InvoiceSequence, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new InvoiceSequenceList.
+func (in *InvoiceSequenceList) DeepCopy() *InvoiceSequenceList {
+	if in == nil {
+		return nil
+	}
+	out := new(InvoiceSequenceList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *InvoiceSequenceList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *InvoiceSequenceSpec) DeepCopyInto(out *InvoiceSequenceSpec) {
+	*out = *in
+	if in.DocumentTypes != nil {
+		in, out := &in.DocumentTypes, &out.DocumentTypes
+		*out = make([]SequenceDocumentType, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new InvoiceSequenceSpec.
+func (in *InvoiceSequenceSpec) DeepCopy() *InvoiceSequenceSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(InvoiceSequenceSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *InvoiceSequenceStatus) DeepCopyInto(out *InvoiceSequenceStatus) {
+	*out = *in
+	if in.Periods != nil {
+		in, out := &in.Periods, &out.Periods
+		*out = make([]SequencePeriod, len(*in))
+		copy(*out, *in)
+	}
+	if in.Gaps != nil {
+		in, out := &in.Gaps, &out.Gaps
+		*out = make([]SequenceGap, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new InvoiceSequenceStatus.
+func (in *InvoiceSequenceStatus) DeepCopy() *InvoiceSequenceStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(InvoiceSequenceStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *InvoiceSpec) DeepCopyInto(out *InvoiceSpec) {
+	*out = *in
+	in.Exposure.DeepCopyInto(&out.Exposure)
+	out.Deployment = in.Deployment
+	if in.AdvanceRefs != nil {
+		in, out := &in.AdvanceRefs, &out.AdvanceRefs
+		*out = make([]v1.LocalObjectReference, len(*in))
+		copy(*out, *in)
+	}
+	if in.CustomerRef != nil {
+		in, out := &in.CustomerRef, &out.CustomerRef
+		*out = new(v1.LocalObjectReference)
+		**out = **in
+	}
+	if in.SellerRef != nil {
+		in, out := &in.SellerRef, &out.SellerRef
+		*out = new(v1.LocalObjectReference)
+		**out = **in
+	}
+	if in.SequenceRef != nil {
+		in, out := &in.SequenceRef, &out.SequenceRef
+		*out = new(v1.LocalObjectReference)
+		**out = **in
+	}
+	if in.DunningPolicyRef != nil {
+		in, out := &in.DunningPolicyRef, &out.DunningPolicyRef
+		*out = new(v1.LocalObjectReference)
+		**out = **in
+	}
+	if in.InterestRateTableRef != nil {
+		in, out := &in.InterestRateTableRef, &out.InterestRateTableRef
+		*out = new(v1.LocalObjectReference)
+		**out = **in
+	}
+	in.InvoiceData.DeepCopyInto(&out.InvoiceData)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new InvoiceSpec.
+func (in *InvoiceSpec) DeepCopy() *InvoiceSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(InvoiceSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *InvoiceStatus) DeepCopyInto(out *InvoiceStatus) {
+	*out = *in
+	if in.LastProcessedTime != nil {
+		in, out := &in.LastProcessedTime, &out.LastProcessedTime
+		*out = (*in).DeepCopy()
+	}
+	if in.StateChangedTime != nil {
+		in, out := &in.StateChangedTime, &out.StateChangedTime
+		*out = (*in).DeepCopy()
+	}
+	if in.History != nil {
+		in, out := &in.History, &out.History
+		*out = make([]StateTransition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]metav1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Totals != nil {
+		in, out := &in.Totals, &out.Totals
+		*out = new(InvoiceTotals)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Advances != nil {
+		in, out := &in.Advances, &out.Advances
+		*out = make([]SettledAdvance, len(*in))
+		copy(*out, *in)
+	}
+	if in.Balance != nil {
+		in, out := &in.Balance, &out.Balance
+		*out = new(InvoiceBalance)
+		**out = **in
+	}
+	if in.CreditNotes != nil {
+		in, out := &in.CreditNotes, &out.CreditNotes
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Payments != nil {
+		in, out := &in.Payments, &out.Payments
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Interest != nil {
+		in, out := &in.Interest, &out.Interest
+		*out = new(InvoiceInterest)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Dunning != nil {
+		in, out := &in.Dunning, &out.Dunning
+		*out = new(DunningStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Parties != nil {
+		in, out := &in.Parties, &out.Parties
+		*out = new(Company)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Clearance != nil {
+		in, out := &in.Clearance, &out.Clearance
+		*out = new(ClearanceStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.RuleViolations != nil {
+		in, out := &in.RuleViolations, &out.RuleViolations
+		*out = make([]RuleViolation, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new InvoiceStatus.
+func (in *InvoiceStatus) DeepCopy() *InvoiceStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(InvoiceStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *InvoiceTemplate) DeepCopyInto(out *InvoiceTemplate) {
+	*out = *in
+	in.Metadata.DeepCopyInto(&out.Metadata)
+	in.Spec.DeepCopyInto(&out.Spec)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new InvoiceTemplate.
+func (in *InvoiceTemplate) DeepCopy() *InvoiceTemplate {
+	if in == nil {
+		return nil
+	}
+	out := new(InvoiceTemplate)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *InvoiceTotals) DeepCopyInto(out *InvoiceTotals) {
+	*out = *in
+	if in.VAT != nil {
+		in, out := &in.VAT, &out.VAT
+		*out = make([]VATTotal, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new InvoiceTotals.
+func (in *InvoiceTotals) DeepCopy() *InvoiceTotals {
+	if in == nil {
+		return nil
+	}
+	out := new(InvoiceTotals)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ItalianBuyer) DeepCopyInto(out *ItalianBuyer) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ItalianBuyer.
+func (in *ItalianBuyer) DeepCopy() *ItalianBuyer {
+	if in == nil {
+		return nil
+	}
+	out := new(ItalianBuyer)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ItalianSeller) DeepCopyInto(out *ItalianSeller) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ItalianSeller.
+func (in *ItalianSeller) DeepCopy() *ItalianSeller {
+	if in == nil {
+		return nil
+	}
+	out := new(ItalianSeller)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Item) DeepCopyInto(out *Item) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Item.
+func (in *Item) DeepCopy() *Item {
+	if in == nil {
+		return nil
+	}
+	out := new(Item)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Options) DeepCopyInto(out *Options) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Options.
+func (in *Options) DeepCopy() *Options {
+	if in == nil {
+		return nil
+	}
+	out := new(Options)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Payment) DeepCopyInto(out *Payment) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	out.Spec = in.Spec
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Payment.
+func (in *Payment) DeepCopy() *Payment {
+	if in == nil {
+		return nil
+	}
+	out := new(Payment)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *Payment) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PaymentList) DeepCopyInto(out *PaymentList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]Payment, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PaymentList.
+func (in *PaymentList) DeepCopy() *PaymentList {
+	if in == nil {
+		return nil
+	}
+	out := new(PaymentList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *PaymentList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PaymentSpec) DeepCopyInto(out *PaymentSpec) {
+	*out = *in
+	out.InvoiceRef = in.InvoiceRef
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PaymentSpec.
+func (in *PaymentSpec) DeepCopy() *PaymentSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(PaymentSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RecurringInvoice) DeepCopyInto(out *RecurringInvoice) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RecurringInvoice.
+func (in *RecurringInvoice) DeepCopy() *RecurringInvoice {
+	if in == nil {
+		return nil
+	}
+	out := new(RecurringInvoice)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *RecurringInvoice) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RecurringInvoiceList) DeepCopyInto(out *RecurringInvoiceList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]RecurringInvoice, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RecurringInvoiceList.
+func (in *RecurringInvoiceList) DeepCopy() *RecurringInvoiceList {
+	if in == nil {
+		return nil
+	}
+	out := new(RecurringInvoiceList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *RecurringInvoiceList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RecurringInvoiceSpec) DeepCopyInto(out *RecurringInvoiceSpec) {
+	*out = *in
+	if in.StartingDeadlineSeconds != nil {
+		in, out := &in.StartingDeadlineSeconds, &out.StartingDeadlineSeconds
+		*out = new(int64)
+		**out = **in
+	}
+	if in.Suspend != nil {
+		in, out := &in.Suspend, &out.Suspend
+		*out = new(bool)
+		**out = **in
+	}
+	if in.PaymentTermDays != nil {
+		in, out := &in.PaymentTermDays, &out.PaymentTermDays
+		*out = new(int32)
+		**out = **in
+	}
+	if in.HistoryLimit != nil {
+		in, out := &in.HistoryLimit, &out.HistoryLimit
+		*out = new(int32)
+		**out = **in
+	}
+	in.Template.DeepCopyInto(&out.Template)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RecurringInvoiceSpec.
+func (in *RecurringInvoiceSpec) DeepCopy() *RecurringInvoiceSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(RecurringInvoiceSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RecurringInvoiceStatus) DeepCopyInto(out *RecurringInvoiceStatus) {
+	*out = *in
+	if in.LastScheduleTime != nil {
+		in, out := &in.LastScheduleTime, &out.LastScheduleTime
+		*out = (*in).DeepCopy()
+	}
+	if in.NextScheduleTime != nil {
+		in, out := &in.NextScheduleTime, &out.NextScheduleTime
+		*out = (*in).DeepCopy()
+	}
+	if in.History != nil {
+		in, out := &in.History, &out.History
+		*out = make([]RecurringRun, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]metav1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RecurringInvoiceStatus.
+func (in *RecurringInvoiceStatus) DeepCopy() *RecurringInvoiceStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(RecurringInvoiceStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RecurringRun) DeepCopyInto(out *RecurringRun) {
+	*out = *in
+	in.ScheduledTime.DeepCopyInto(&out.ScheduledTime)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RecurringRun.
+func (in *RecurringRun) DeepCopy() *RecurringRun {
+	if in == nil {
+		return nil
+	}
+	out := new(RecurringRun)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Reminder) DeepCopyInto(out *Reminder) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Reminder.
+func (in *Reminder) DeepCopy() *Reminder {
+	if in == nil {
+		return nil
+	}
+	out := new(Reminder)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Rounding) DeepCopyInto(out *Rounding) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Rounding.
+func (in *Rounding) DeepCopy() *Rounding {
+	if in == nil {
+		return nil
+	}
+	out := new(Rounding)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RuleViolation) DeepCopyInto(out *RuleViolation) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RuleViolation.
+func (in *RuleViolation) DeepCopy() *RuleViolation {
+	if in == nil {
+		return nil
+	}
+	out := new(RuleViolation)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Seller) DeepCopyInto(out *Seller) {
+	*out = *in
+	if in.AddressLines != nil {
+		in, out := &in.AddressLines, &out.AddressLines
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Contact != nil {
+		in, out := &in.Contact, &out.Contact
+		*out = new(Contact)
+		**out = **in
+	}
+	if in.Italy != nil {
+		in, out := &in.Italy, &out.Italy
+		*out = new(ItalianSeller)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Seller.
+func (in *Seller) DeepCopy() *Seller {
+	if in == nil {
+		return nil
+	}
+	out := new(Seller)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SellerProfile) DeepCopyInto(out *SellerProfile) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SellerProfile.
+func (in *SellerProfile) DeepCopy() *SellerProfile {
+	if in == nil {
+		return nil
+	}
+	out := new(SellerProfile)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *SellerProfile) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SellerProfileList) DeepCopyInto(out *SellerProfileList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]SellerProfile, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SellerProfileList.
+func (in *SellerProfileList) DeepCopy() *SellerProfileList {
+	if in == nil {
+		return nil
+	}
+	out := new(SellerProfileList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *SellerProfileList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SellerProfileSpec) DeepCopyInto(out *SellerProfileSpec) {
+	*out = *in
+	in.Seller.DeepCopyInto(&out.Seller)
+	out.Bank = in.Bank
+	out.Options = in.Options
+	if in.PaymentTermDays != nil {
+		in, out := &in.PaymentTermDays, &out.PaymentTermDays
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SellerProfileSpec.
+func (in *SellerProfileSpec) DeepCopy() *SellerProfileSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(SellerProfileSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SequenceGap) DeepCopyInto(out *SequenceGap) {
+	*out = *in
+	if in.Missing != nil {
+		in, out := &in.Missing, &out.Missing
+		*out = make([]int64, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SequenceGap.
+func (in *SequenceGap) DeepCopy() *SequenceGap {
+	if in == nil {
+		return nil
+	}
+	out := new(SequenceGap)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SequencePeriod) DeepCopyInto(out *SequencePeriod) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SequencePeriod.
+func (in *SequencePeriod) DeepCopy() *SequencePeriod {
+	if in == nil {
+		return nil
+	}
+	out := new(SequencePeriod)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SettledAdvance) DeepCopyInto(out *SettledAdvance) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SettledAdvance.
+func (in *SettledAdvance) DeepCopy() *SettledAdvance {
+	if in == nil {
+		return nil
+	}
+	out := new(SettledAdvance)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *StateTransition) DeepCopyInto(out *StateTransition) {
+	*out = *in
+	in.Time.DeepCopyInto(&out.Time)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StateTransition.
+func (in *StateTransition) DeepCopy() *StateTransition {
+	if in == nil {
+		return nil
+	}
+	out := new(StateTransition)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TemplateMetadata) DeepCopyInto(out *TemplateMetadata) {
+	*out = *in
+	if in.Labels != nil {
+		in, out := &in.Labels, &out.Labels
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Annotations != nil {
+		in, out := &in.Annotations, &out.Annotations
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TemplateMetadata.
+func (in *TemplateMetadata) DeepCopy() *TemplateMetadata {
+	if in == nil {
+		return nil
+	}
+	out := new(TemplateMetadata)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VATTotal) DeepCopyInto(out *VATTotal) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VATTotal.
+func (in *VATTotal) DeepCopy() *VATTotal {
+	if in == nil {
+		return nil
+	}
+	out := new(VATTotal)
+	in.DeepCopyInto(out)
+	return out
+}
@@ -0,0 +1,50 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Command ksef-mock serves the KSeF mock, to run the manager against a KSeF
+// environment without a network. It writes the public key of the mock to a
+// PEM file, pass it to the manager with --ksef-public-key.
+package main
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"flag"
+	"log"
+	"net/http"
+	"os"
+
+	"github.com/cnvergence/facturnetes/pkg/ksef"
+)
+
+func main() {
+	var addr, publicKey string
+	flag.StringVar(&addr, "bind-address", ":8090", "The address the KSeF mock binds to.")
+	flag.StringVar(&publicKey, "public-key", "ksef-mock.pem", "The PEM file the public key of the KSeF mock is written to.")
+	flag.Parse()
+
+	mock := ksef.NewMock(os.Getenv("KSEF_TOKEN"))
+	der, err := x509.MarshalPKIXPublicKey(mock.PublicKey())
+	if err != nil {
+		log.Fatalf("unable to encode the public key: %v", err)
+	}
+	if err := os.WriteFile(publicKey, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), 0o644); err != nil {
+		log.Fatalf("unable to write the public key: %v", err)
+	}
+
+	log.Printf("serving the KSeF mock at %s, public key in %s", addr, publicKey)
+	log.Fatal(http.ListenAndServe(addr, mock))
+}
@@ -0,0 +1,79 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	facturnetesv2 "github.com/cnvergence/facturnetes/api/v2"
+	"github.com/cnvergence/facturnetes/pkg/document"
+	"github.com/cnvergence/facturnetes/pkg/money"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// settleAdvances records the issued advance invoices settled by a final
+// invoice and returns them for printing. Their amounts are deducted from the
+// outstanding balance. An advance is settled by one final invoice only.
+func (r *InvoiceReconciler) settleAdvances(ctx context.Context, invoice *facturnetesv2.Invoice) ([]document.Advance, error) {
+	invoice.Status.Advances = nil
+	settled, err := r.settledAdvances(ctx, invoice)
+	if err != nil {
+		return nil, err
+	}
+
+	var advances []document.Advance
+	for _, ref := range invoice.Spec.AdvanceRefs {
+		advance := &facturnetesv2.Invoice{}
+		if err := r.client.Get(ctx, client.ObjectKey{Namespace: invoice.Namespace, Name: ref.Name}, advance); err != nil {
+			return nil, fmt.Errorf("unable to get advance Invoice %s: %w", ref.Name, err)
+		}
+		switch {
+		case advance.Spec.DocumentType != facturnetesv2.DocumentAdvance:
+			return nil, fmt.Errorf("invoice %s is not an advance invoice", ref.Name)
+		case isDraft(advance):
+			return nil, fmt.Errorf("advance invoice %s is not issued yet", ref.Name)
+		case settled[ref.Name] != "":
+			return nil, fmt.Errorf("advance invoice %s is already settled by invoice %s", ref.Name, settled[ref.Name])
+		case advance.Spec.InvoiceData.Currency != invoice.Spec.InvoiceData.Currency:
+			return nil, fmt.Errorf("advance invoice %s is in %s, not in %s", ref.Name,
+				advance.Spec.InvoiceData.Currency, invoice.Spec.InvoiceData.Currency)
+		}
+
+		totals, err := money.Compute(&advance.Spec.InvoiceData)
+		if err != nil {
+			return nil, fmt.Errorf("advance invoice %s: %w", ref.Name, err)
+		}
+		advances = append(advances, document.Advance{Number: advance.Spec.InvoiceData.Number, Totals: totals})
+		invoice.Status.Advances = append(invoice.Status.Advances, facturnetesv2.SettledAdvance{
+			Name:   advance.Name,
+			Number: advance.Spec.InvoiceData.Number,
+			Total:  facturnetesv2.Decimal(money.Format(totals.Gross, totals.Scale)),
+		})
+	}
+
+	return advances, nil
+}
+
+// settledAdvances maps the advance invoices settled by the other issued final
+// invoices of the namespace to the final invoice settling them. The invoices
+// are read from the API server, so an advance settled just before is never
+// deducted twice. An issued invoice keeps the advances it settled.
+func (r *InvoiceReconciler) settledAdvances(ctx context.Context, invoice *facturnetesv2.Invoice) (map[string]string, error) {
+	if len(invoice.Spec.AdvanceRefs) == 0 || invoice.IsIssued() {
+		return nil, nil
+	}
+	invoices := &facturnetesv2.InvoiceList{}
+	if err := r.apiReader.List(ctx, invoices, client.InNamespace(invoice.Namespace)); err != nil {
+		return nil, err
+	}
+	settled := map[string]string{}
+	for i := range invoices.Items {
+		other := &invoices.Items[i]
+		if other.Name == invoice.Name || other.Spec.DocumentType != facturnetesv2.DocumentFinal || !other.IsIssued() {
+			continue
+		}
+		for _, ref := range other.Spec.AdvanceRefs {
+			settled[ref.Name] = other.Name
+		}
+	}
+	return settled, nil
+}
@@ -0,0 +1,36 @@
+package controllers
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	facturnetesv2 "github.com/cnvergence/facturnetes/api/v2"
+)
+
+var _ = Describe("Advance invoices", func() {
+	It("settles an advance with one final invoice only", func() {
+		advance := newInvoice("advance")
+		advance.Spec.DocumentType = facturnetesv2.DocumentAdvance
+		advance.Status.State = facturnetesv2.Issued
+		settled := newInvoice("final-1")
+		settled.Spec.DocumentType = facturnetesv2.DocumentFinal
+		settled.Spec.AdvanceRefs = []corev1.LocalObjectReference{{Name: advance.Name}}
+		settled.Status.State = facturnetesv2.Issued
+		invoice := newInvoice("final-2")
+		invoice.Spec.DocumentType = facturnetesv2.DocumentFinal
+		invoice.Spec.AdvanceRefs = []corev1.LocalObjectReference{{Name: advance.Name}}
+
+		c := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(advance, settled, invoice).Build()
+		reconciler := &InvoiceReconciler{client: c, apiReader: c, log: zap.S()}
+
+		_, err := reconciler.settleAdvances(ctx, invoice)
+		Expect(err).To(MatchError("advance invoice advance is already settled by invoice final-1"))
+		advances, err := reconciler.settleAdvances(ctx, settled)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(advances).To(HaveLen(1))
+	})
+})
@@ -0,0 +1,154 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	facturnetesv2 "github.com/cnvergence/facturnetes/api/v2"
+	"github.com/cnvergence/facturnetes/pkg/money"
+	"gopkg.in/inf.v0"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// Field indexes of the invoices corrected by CreditNotes and paid by Payments.
+const (
+	creditNoteInvoiceField = "spec.invoiceRef.name"
+	paymentInvoiceField    = "spec.invoiceRef.name"
+)
+
+func indexCreditNoteInvoice(obj client.Object) []string {
+	return []string{obj.(*facturnetesv2.CreditNote).Spec.InvoiceRef.Name}
+}
+
+// invoiceOfCreditNote enqueues the invoice corrected by a changed CreditNote.
+func invoiceOfCreditNote(obj client.Object) []reconcile.Request {
+	note := obj.(*facturnetesv2.CreditNote)
+	return []reconcile.Request{{NamespacedName: client.ObjectKey{
+		Namespace: note.Namespace,
+		Name:      note.Spec.InvoiceRef.Name,
+	}}}
+}
+
+func indexPaymentInvoice(obj client.Object) []string {
+	return []string{obj.(*facturnetesv2.Payment).Spec.InvoiceRef.Name}
+}
+
+// invoiceOfPayment enqueues the invoice paid by a changed Payment.
+func invoiceOfPayment(obj client.Object) []reconcile.Request {
+	payment := obj.(*facturnetesv2.Payment)
+	return []reconcile.Request{{NamespacedName: client.ObjectKey{
+		Namespace: payment.Namespace,
+		Name:      payment.Spec.InvoiceRef.Name,
+	}}}
+}
+
+// updateBalance links the issued CreditNotes and the Payments of the invoice
+// and computes its outstanding balance from the total, the settled advances,
+// the corrections and the payments.
+func (r *InvoiceReconciler) updateBalance(ctx context.Context, invoice *facturnetesv2.Invoice) error {
+	if invoice.Status.Totals == nil {
+		return nil
+	}
+	notes := &facturnetesv2.CreditNoteList{}
+	if err := r.client.List(ctx, notes, client.InNamespace(invoice.Namespace),
+		client.MatchingFields{creditNoteInvoiceField: invoice.Name}); err != nil {
+		return err
+	}
+
+	scale := money.CurrencyScale(invoice.Status.Totals.Currency)
+	corrections := new(inf.Dec)
+	var names []string
+	for _, note := range notes.Items {
+		if note.Status.Totals == nil || note.Status.PDFSHA256 == "" {
+			continue
+		}
+		total, err := money.Parse(note.Status.Totals.Total)
+		if err != nil {
+			return err
+		}
+		corrections.Add(corrections, total)
+		names = append(names, note.Name)
+	}
+	sort.Strings(names)
+
+	prepaid := new(inf.Dec)
+	for _, advance := range invoice.Status.Advances {
+		total, err := money.Parse(advance.Total)
+		if err != nil {
+			return err
+		}
+		prepaid.Add(prepaid, total)
+	}
+
+	total, err := money.Parse(invoice.Status.Totals.Total)
+	if err != nil {
+		return err
+	}
+	paid, payments, err := r.payments(ctx, invoice)
+	if err != nil {
+		return err
+	}
+	var paymentNames []string
+	for _, payment := range payments {
+		paymentNames = append(paymentNames, payment.Name)
+	}
+
+	outstanding := new(inf.Dec).Sub(total, prepaid)
+	outstanding.Add(outstanding, corrections)
+	outstanding.Sub(outstanding, paid)
+
+	invoice.Status.CreditNotes = names
+	invoice.Status.Payments = paymentNames
+	invoice.Status.Balance = &facturnetesv2.InvoiceBalance{
+		Corrections: facturnetesv2.Decimal(money.Format(corrections, scale)),
+		Outstanding: facturnetesv2.Decimal(money.Format(outstanding, scale)),
+	}
+	if len(invoice.Status.Advances) > 0 {
+		invoice.Status.Balance.Prepaid = facturnetesv2.Decimal(money.Format(prepaid, scale))
+	}
+	if len(paymentNames) > 0 {
+		invoice.Status.Balance.Paid = facturnetesv2.Decimal(money.Format(paid, scale))
+	}
+
+	return nil
+}
+
+// payments returns the sum of the Payments received for the invoice and the
+// Payments, oldest first. Payments of a draft invoice are not counted.
+func (r *InvoiceReconciler) payments(ctx context.Context, invoice *facturnetesv2.Invoice) (*inf.Dec, []facturnetesv2.Payment, error) {
+	paid := new(inf.Dec)
+	if isDraft(invoice) {
+		return paid, nil, nil
+	}
+	list := &facturnetesv2.PaymentList{}
+	if err := r.client.List(ctx, list, client.InNamespace(invoice.Namespace),
+		client.MatchingFields{paymentInvoiceField: invoice.Name}); err != nil {
+		return nil, nil, err
+	}
+
+	payments := list.Items
+	for i := range payments {
+		if err := facturnetesv2.ValidatePayment(&payments[i], invoice).ToAggregate(); err != nil {
+			return nil, nil, fmt.Errorf("invalid Payment %s: %w", payments[i].Name, err)
+		}
+	}
+	sort.SliceStable(payments, func(i, j int) bool {
+		a, _ := facturnetesv2.ParseDate(payments[i].Spec.Date)
+		b, _ := facturnetesv2.ParseDate(payments[j].Spec.Date)
+		if !a.Equal(b) {
+			return a.Before(b)
+		}
+		return payments[i].Name < payments[j].Name
+	})
+
+	for _, payment := range payments {
+		amount, err := money.Parse(payment.Spec.Amount)
+		if err != nil {
+			return nil, nil, err
+		}
+		paid.Add(paid, amount)
+	}
+	return paid, payments, nil
+}
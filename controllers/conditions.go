@@ -0,0 +1,84 @@
+package controllers
+
+import (
+	facturnetesv2 "github.com/cnvergence/facturnetes/api/v2"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Reasons used by the Invoice conditions.
+const (
+	ReasonValid                 = "Valid"
+	ReasonInvalid               = "Invalid"
+	ReasonUnresolvedReference   = "UnresolvedReference"
+	ReasonNumberingFailed       = "NumberingFailed"
+	ReasonDuplicateNumber       = "DuplicateNumber"
+	ReasonRendered              = "Rendered"
+	ReasonRenderFailed          = "RenderFailed"
+	ReasonIssued                = "Issued"
+	ReasonDigestMismatch        = "DigestMismatch"
+	ReasonSynced                = "Synced"
+	ReasonSyncFailed            = "SyncFailed"
+	ReasonExportFailed          = "ExportFailed"
+	ReasonDeploymentAvailable   = "DeploymentAvailable"
+	ReasonDeploymentProgressing = "DeploymentProgressing"
+	ReasonDeploymentFailed      = "DeploymentFailed"
+	ReasonServiceReady          = "ServiceReady"
+	ReasonServiceFailed         = "ServiceFailed"
+	ReasonIngressAdmitted       = "IngressAdmitted"
+	ReasonIngressPending        = "IngressPending"
+	ReasonIngressFailed         = "IngressFailed"
+	ReasonReconciled            = "Reconciled"
+	ReasonNotReady              = "NotReady"
+	ReasonReconcileFailed       = "ReconcileFailed"
+	ReasonPastDue               = "PastDue"
+	ReasonNotDue                = "NotDue"
+	ReasonNotAwaitingPayment    = "NotAwaitingPayment"
+	ReasonReminderSent          = "ReminderSent"
+	ReasonDunningFailed         = "DunningFailed"
+	ReasonInterestFailed        = "InterestFailed"
+	ReasonScheduled             = "Scheduled"
+	ReasonSuspended             = "Suspended"
+	ReasonCreateFailed          = "CreateFailed"
+	ReasonSubmitted             = "Submitted"
+	ReasonSubmissionFailed      = "SubmissionFailed"
+	ReasonCleared               = "Cleared"
+	ReasonRejected              = "Rejected"
+	ReasonCompliant             = "Compliant"
+	ReasonRulesViolated         = "RulesViolated"
+)
+
+// readinessConditions must all be True for the invoice to be Ready.
+var readinessConditions = []string{
+	facturnetesv2.ConditionValidated,
+	facturnetesv2.ConditionPDFRendered,
+	facturnetesv2.ConditionSecretSynced,
+	facturnetesv2.ConditionViewerAvailable,
+	facturnetesv2.ConditionExposed,
+}
+
+func setCondition(invoice *facturnetesv2.Invoice, conditionType string, status metav1.ConditionStatus, reason, message string) {
+	meta.SetStatusCondition(&invoice.Status.Conditions, metav1.Condition{
+		Type:               conditionType,
+		Status:             status,
+		ObservedGeneration: invoice.Generation,
+		Reason:             reason,
+		Message:            message,
+	})
+}
+
+// setReadyCondition summarizes the readiness conditions into the Ready condition.
+func setReadyCondition(invoice *facturnetesv2.Invoice) {
+	for _, t := range readinessConditions {
+		c := meta.FindStatusCondition(invoice.Status.Conditions, t)
+		if c == nil || c.Status != metav1.ConditionTrue {
+			message := t + " condition is not True"
+			if c != nil && c.Message != "" {
+				message = c.Message
+			}
+			setCondition(invoice, facturnetesv2.ConditionReady, metav1.ConditionFalse, ReasonNotReady, message)
+			return
+		}
+	}
+	setCondition(invoice, facturnetesv2.ConditionReady, metav1.ConditionTrue, ReasonReconciled, "Invoice is ready")
+}
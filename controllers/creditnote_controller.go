@@ -0,0 +1,254 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	facturnetesv2 "github.com/cnvergence/facturnetes/api/v2"
+	"github.com/cnvergence/facturnetes/pkg/document"
+	"github.com/cnvergence/facturnetes/pkg/money"
+	"github.com/cnvergence/facturnetes/pkg/resource"
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+)
+
+// CreditNoteReconciler reconciles a CreditNote object
+type CreditNoteReconciler struct {
+	client    client.Client
+	apiReader client.Reader
+	Scheme    *runtime.Scheme
+	log       *zap.SugaredLogger
+}
+
+func NewCreditNoteReconciler(mgr manager.Manager) *CreditNoteReconciler {
+	return &CreditNoteReconciler{
+		client:    mgr.GetClient(),
+		apiReader: mgr.GetAPIReader(),
+		Scheme:    mgr.GetScheme(),
+		log:       zap.S(),
+	}
+}
+
+// +kubebuilder:rbac:groups=facturnetes.cnvergence.io,resources=creditnotes,verbs=get;list;watch;update;patch
+// +kubebuilder:rbac:groups=facturnetes.cnvergence.io,resources=creditnotes/status,verbs=get;update;patch
+// Reconcile renders the credit note of an issued invoice and stores it in a
+// Secret next to the invoice.
+func (r *CreditNoteReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	r.log = zap.S().With("CreditNote", req.NamespacedName)
+	r.log.Info("Reconciling CreditNote")
+
+	note := &facturnetesv2.CreditNote{}
+	if err := r.client.Get(ctx, req.NamespacedName, note); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	invoice := &facturnetesv2.Invoice{}
+	key := client.ObjectKey{Namespace: note.Namespace, Name: note.Spec.InvoiceRef.Name}
+	if err := r.client.Get(ctx, key, invoice); err != nil {
+		err = fmt.Errorf("unable to get Invoice %s: %w", key.Name, err)
+		r.setCondition(note, facturnetesv2.ConditionValidated, metav1.ConditionFalse, ReasonUnresolvedReference, err.Error())
+		return r.setFailureStatus(ctx, note, err)
+	}
+
+	// The credit note corrects the invoice as it was issued and corrected by
+	// the credit notes issued before, its lines refer to that data.
+	original := invoice.DeepCopy()
+	if invoice.Status.Parties != nil {
+		original.Spec.InvoiceData.Company = *invoice.Status.Parties
+	}
+	data, err := r.corrected(ctx, note, &original.Spec.InvoiceData)
+	if err != nil {
+		r.setCondition(note, facturnetesv2.ConditionValidated, metav1.ConditionFalse, ReasonUnresolvedReference, err.Error())
+		return r.setFailureStatus(ctx, note, err)
+	}
+	original.Spec.InvoiceData = *data
+
+	if err := facturnetesv2.ValidateCreditNote(note, invoice, data).ToAggregate(); err != nil {
+		r.setCondition(note, facturnetesv2.ConditionValidated, metav1.ConditionFalse, ReasonInvalid, err.Error())
+		return r.setFailureStatus(ctx, note, err)
+	}
+
+	if note.Spec.Number == "" {
+		latest := &facturnetesv2.CreditNote{}
+		if err := r.apiReader.Get(ctx, client.ObjectKeyFromObject(note), latest); err != nil {
+			return ctrl.Result{}, err
+		}
+		if latest.Spec.Number != "" {
+			// The cache is stale, the update of the number triggers the next reconciliation.
+			return ctrl.Result{}, nil
+		}
+		issued, _ := facturnetesv2.ParseDate(note.Spec.IssueDate)
+		number, err := nextNumber(ctx, r.client, r.apiReader, note.Namespace, note.Spec.SequenceRef,
+			facturnetesv2.DocumentCreditNote, issued)
+		if err != nil {
+			r.setCondition(note, facturnetesv2.ConditionValidated, metav1.ConditionFalse, ReasonNumberingFailed, err.Error())
+			return r.setFailureStatus(ctx, note, err)
+		}
+		r.log.Infow("Allocated credit note number", "number", number)
+		// Updating the number triggers the next reconciliation.
+		return ctrl.Result{}, setNumber(ctx, r.client, r.apiReader, note, &note.Spec.Number, number)
+	}
+	r.setCondition(note, facturnetesv2.ConditionValidated, metav1.ConditionTrue, ReasonValid, "Credit note is valid")
+
+	var pdf []byte
+	if note.Status.PDFSHA256 != "" {
+		// An issued credit note keeps its totals and its PDF.
+		var err error
+		if pdf, err = storedPDF(ctx, r.client, client.ObjectKey{Namespace: note.Namespace, Name: resource.CreditNoteSecretName(note)}, note.Status.PDFSHA256); err != nil {
+			r.setCondition(note, facturnetesv2.ConditionPDFRendered, metav1.ConditionFalse, ReasonDigestMismatch, err.Error())
+			return r.setFailureStatus(ctx, note, err)
+		}
+		r.setCondition(note, facturnetesv2.ConditionPDFRendered, metav1.ConditionTrue, ReasonIssued,
+			fmt.Sprintf("PDF document of the issued credit note kept (sha256 %s)", note.Status.PDFSHA256))
+	} else {
+		before, err := money.Compute(data)
+		if err != nil {
+			r.setCondition(note, facturnetesv2.ConditionPDFRendered, metav1.ConditionFalse, ReasonRenderFailed, err.Error())
+			return r.setFailureStatus(ctx, note, err)
+		}
+		after, err := money.Compute(note.Spec.Apply(data))
+		if err != nil {
+			r.setCondition(note, facturnetesv2.ConditionPDFRendered, metav1.ConditionFalse, ReasonRenderFailed, err.Error())
+			return r.setFailureStatus(ctx, note, err)
+		}
+		note.Status.InvoiceNumber = data.Number
+		note.Status.Totals = money.Subtract(after, before).Status(data.Currency)
+
+		if pdf, err = document.CreditNote(note, original, before, after).Render(); err != nil {
+			r.setCondition(note, facturnetesv2.ConditionPDFRendered, metav1.ConditionFalse, ReasonRenderFailed, err.Error())
+			return r.setFailureStatus(ctx, note, err)
+		}
+		r.setCondition(note, facturnetesv2.ConditionPDFRendered, metav1.ConditionTrue, ReasonRendered,
+			fmt.Sprintf("PDF document rendered (%d bytes)", len(pdf)))
+	}
+
+	if err := r.ensureSecret(ctx, note, pdf); err != nil {
+		r.setCondition(note, facturnetesv2.ConditionSecretSynced, metav1.ConditionFalse, ReasonSyncFailed, err.Error())
+		return r.setFailureStatus(ctx, note, err)
+	}
+	if note.Status.PDFSHA256 == "" {
+		note.Status.PDFSHA256 = digest(pdf)
+		note.Status.IssuedTime = &metav1.Time{Time: time.Now()}
+	}
+	r.setCondition(note, facturnetesv2.ConditionSecretSynced, metav1.ConditionTrue, ReasonSynced,
+		fmt.Sprintf("Document stored in Secret %s", resource.CreditNoteSecretName(note)))
+
+	note.Status.ObservedGeneration = note.Generation
+	note.Status.Message = ""
+	r.setCondition(note, facturnetesv2.ConditionReady, metav1.ConditionTrue, ReasonReconciled, "Credit note is ready")
+	return ctrl.Result{}, r.client.Status().Update(ctx, note)
+}
+
+// corrected returns the invoice data corrected by the credit notes issued
+// before the note, in the order they were issued. The notes are read from the
+// API server, so a note issued a moment ago is never missed.
+func (r *CreditNoteReconciler) corrected(ctx context.Context, note *facturnetesv2.CreditNote, data *facturnetesv2.InvoiceData) (*facturnetesv2.InvoiceData, error) {
+	notes := &facturnetesv2.CreditNoteList{}
+	if err := r.apiReader.List(ctx, notes, client.InNamespace(note.Namespace)); err != nil {
+		return nil, err
+	}
+	var issued []*facturnetesv2.CreditNote
+	for i := range notes.Items {
+		other := &notes.Items[i]
+		if other.Name != note.Name && other.Spec.InvoiceRef.Name == note.Spec.InvoiceRef.Name && other.Status.PDFSHA256 != "" {
+			issued = append(issued, other)
+		}
+	}
+	sort.Slice(issued, func(i, j int) bool {
+		a, b := issuedTime(issued[i]), issuedTime(issued[j])
+		if !a.Equal(b) {
+			return a.Before(b)
+		}
+		return issued[i].Name < issued[j].Name
+	})
+
+	for _, other := range issued {
+		data = other.Spec.Apply(data)
+	}
+	return data, nil
+}
+
+// issuedTime returns when the credit note was issued, its creation for the
+// notes issued before the time was recorded.
+func issuedTime(note *facturnetesv2.CreditNote) time.Time {
+	if note.Status.IssuedTime != nil {
+		return note.Status.IssuedTime.Time
+	}
+	return note.CreationTimestamp.Time
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *CreditNoteReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&facturnetesv2.CreditNote{}).
+		Owns(&corev1.Secret{}).
+		Complete(r)
+}
+
+func (r *CreditNoteReconciler) ensureSecret(ctx context.Context, note *facturnetesv2.CreditNote, pdf []byte) error {
+	sc := resource.CreditNoteSecret(note, pdf)
+	if err := ctrl.SetControllerReference(note, sc, r.Scheme); err != nil {
+		return err
+	}
+
+	sco := sc.DeepCopyObject().(*corev1.Secret)
+	op, err := ctrl.CreateOrUpdate(ctx, r.client, sco, func() error {
+		if err := claimSecret(sco, note); err != nil {
+			return err
+		}
+		sco.Data = sc.Data
+		return nil
+	})
+	if err != nil {
+		r.log.Errorf("Could not create or patch the Secret: %s", err)
+		return err
+	}
+	r.log.Infow("Create/Update operation succeeded", "operation", op)
+
+	return nil
+}
+
+func (r *CreditNoteReconciler) setCondition(note *facturnetesv2.CreditNote, conditionType string, status metav1.ConditionStatus, reason, message string) {
+	meta.SetStatusCondition(&note.Status.Conditions, metav1.Condition{
+		Type:               conditionType,
+		Status:             status,
+		ObservedGeneration: note.Generation,
+		Reason:             reason,
+		Message:            message,
+	})
+}
+
+func (r *CreditNoteReconciler) setFailureStatus(ctx context.Context, note *facturnetesv2.CreditNote, msg error) (ctrl.Result, error) {
+	r.log.Error(msg)
+	note.Status.Message = msg.Error()
+	note.Status.ObservedGeneration = note.Generation
+	r.setCondition(note, facturnetesv2.ConditionReady, metav1.ConditionFalse, ReasonReconcileFailed, msg.Error())
+
+	return ctrl.Result{
+		RequeueAfter: 15 * time.Second,
+	}, r.client.Status().Update(ctx, note)
+}
@@ -0,0 +1,45 @@
+package controllers
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	facturnetesv2 "github.com/cnvergence/facturnetes/api/v2"
+)
+
+var _ = Describe("Credit notes", func() {
+	It("corrects a line added by a credit note issued before", func() {
+		invoice := newInvoice("corrected")
+		invoice.Status.State = facturnetesv2.Issued
+		line := int32(2)
+		newCreditNote := func(name string, item facturnetesv2.CorrectedItem) *facturnetesv2.CreditNote {
+			return &facturnetesv2.CreditNote{
+				ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+				Spec: facturnetesv2.CreditNoteSpec{
+					InvoiceRef: corev1.LocalObjectReference{Name: invoice.Name},
+					IssueDate:  "01-02-2022",
+					Reason:     "Wrong delivery",
+					Items:      []facturnetesv2.CorrectedItem{item},
+				},
+			}
+		}
+		added := newCreditNote("added", facturnetesv2.CorrectedItem{
+			Corrected: &facturnetesv2.Item{Description: "Carrots", Quantity: "10", UnitPrice: "2.00", VATRate: "23"},
+		})
+		added.Status.PDFSHA256 = "issued"
+		note := newCreditNote("negated", facturnetesv2.CorrectedItem{Line: &line, Negate: true})
+
+		Expect(facturnetesv2.ValidateCreditNote(note, invoice, &invoice.Spec.InvoiceData)).NotTo(BeEmpty())
+
+		c := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(invoice, added, note).Build()
+		reconciler := &CreditNoteReconciler{client: c, apiReader: c, log: zap.S()}
+		data, err := reconciler.corrected(ctx, note, &invoice.Spec.InvoiceData)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(facturnetesv2.ValidateCreditNote(note, invoice, data)).To(BeEmpty())
+	})
+})
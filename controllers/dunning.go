@@ -0,0 +1,207 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	facturnetesv2 "github.com/cnvergence/facturnetes/api/v2"
+	"github.com/cnvergence/facturnetes/pkg/document"
+	"github.com/cnvergence/facturnetes/pkg/dunning"
+	"github.com/cnvergence/facturnetes/pkg/money"
+	"github.com/cnvergence/facturnetes/pkg/resource"
+	"gopkg.in/inf.v0"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// dunningPolicy returns the DunningPolicy of the invoice, or nil when it has
+// no reference and the namespace has no default policy.
+func (r *InvoiceReconciler) dunningPolicy(ctx context.Context, invoice *facturnetesv2.Invoice) (*facturnetesv2.DunningPolicy, error) {
+	if ref := invoice.Spec.DunningPolicyRef; ref != nil {
+		policy := &facturnetesv2.DunningPolicy{}
+		key := client.ObjectKey{Namespace: invoice.Namespace, Name: ref.Name}
+		if err := r.client.Get(ctx, key, policy); err != nil {
+			return nil, fmt.Errorf("unable to get DunningPolicy %s: %w", ref.Name, err)
+		}
+		return policy, nil
+	}
+
+	policies := &facturnetesv2.DunningPolicyList{}
+	if err := r.client.List(ctx, policies, client.InNamespace(invoice.Namespace)); err != nil {
+		return nil, err
+	}
+	var defaults []*facturnetesv2.DunningPolicy
+	for i := range policies.Items {
+		if policies.Items[i].Spec.Default {
+			defaults = append(defaults, &policies.Items[i])
+		}
+	}
+	switch len(defaults) {
+	case 0:
+		return nil, nil
+	case 1:
+		return defaults[0], nil
+	}
+	return nil, fmt.Errorf("namespace %s has more than one default DunningPolicy: %s, %s",
+		invoice.Namespace, defaults[0].Name, defaults[1].Name)
+}
+
+// overdueInvoicesOf enqueues the overdue invoices, and the invoices charged
+// interest, of the namespace of a changed DunningPolicy or InterestRateTable.
+func (r *InvoiceReconciler) overdueInvoicesOf(obj client.Object) []reconcile.Request {
+	invoices := &facturnetesv2.InvoiceList{}
+	if err := r.client.List(context.Background(), invoices, client.InNamespace(obj.GetNamespace())); err != nil {
+		r.log.Errorw("Unable to list invoices", "namespace", obj.GetNamespace(), "error", err)
+		return nil
+	}
+
+	var requests []reconcile.Request
+	for _, invoice := range invoices.Items {
+		if invoice.Status.State != facturnetesv2.Overdue && invoice.Status.Interest == nil {
+			continue
+		}
+		requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&invoice)})
+	}
+	return requests
+}
+
+// dun sends the reminder of the dunning level reached by an overdue invoice.
+// Every level is sent once; its PDF is kept in a Secret owned by the invoice
+// and listed in the status.
+func (r *InvoiceReconciler) dun(ctx context.Context, invoice *facturnetesv2.Invoice, now time.Time) error {
+	if invoice.Status.State != facturnetesv2.Overdue {
+		if invoice.Status.Dunning != nil {
+			invoice.Status.Dunning.NextLevelTime = nil
+		}
+		return nil
+	}
+	policy, err := r.dunningPolicy(ctx, invoice)
+	if err != nil || policy == nil {
+		return err
+	}
+
+	due, err := facturnetesv2.ParseDate(invoice.Spec.InvoiceData.DueDate)
+	if err != nil {
+		return err
+	}
+	status := invoice.Status.Dunning
+	if status == nil {
+		status = &facturnetesv2.DunningStatus{}
+		invoice.Status.Dunning = status
+	}
+	status.Policy = policy.Name
+	level, next := dunning.Reached(policy, due, now)
+	status.NextLevelTime = nil
+	if !next.IsZero() {
+		status.NextLevelTime = &metav1.Time{Time: next}
+	}
+	if level == nil || sent(status, level.Name) || invoice.Status.Balance == nil {
+		return nil
+	}
+
+	scale := money.CurrencyScale(invoice.Spec.InvoiceData.Currency)
+	outstanding, err := money.Parse(invoice.Status.Balance.Outstanding)
+	if err != nil {
+		return err
+	}
+	// Nothing is claimed from a buyer who owes nothing.
+	if outstanding.Sign() <= 0 {
+		return nil
+	}
+	var accrued *inf.Dec
+	if invoice.Status.Interest != nil {
+		if accrued, err = money.Parse(invoice.Status.Interest.Amount); err != nil {
+			return err
+		}
+	}
+	daysLate := dunning.DaysLate(due, now)
+	claim, err := dunning.Compute(level, outstanding, due, today(now), accrued, scale)
+	if err != nil {
+		return err
+	}
+	issueDate := now.Format(facturnetesv2.DateLayout)
+	doc, err := document.Reminder(invoice, level, issueDate, daysLate, claim, scale)
+	if err != nil {
+		return err
+	}
+	pdf, err := doc.Render()
+	if err != nil {
+		return err
+	}
+
+	secret := resource.ReminderSecret(invoice, level.Name, pdf)
+	if err := ctrl.SetControllerReference(invoice, secret, r.Scheme); err != nil {
+		return err
+	}
+	// A reminder is never rendered again once it was stored, but a Secret of
+	// the same name owned by another object is not taken for it.
+	if err := r.client.Create(ctx, secret); apierrors.IsAlreadyExists(err) {
+		existing := &corev1.Secret{}
+		if err := r.apiReader.Get(ctx, client.ObjectKeyFromObject(secret), existing); err != nil {
+			return fmt.Errorf("unable to read the stored reminder: %w", err)
+		}
+		if err := claimSecret(existing, invoice); err != nil {
+			return err
+		}
+	} else if err != nil {
+		return fmt.Errorf("unable to store the reminder: %w", err)
+	}
+
+	status.Level = level.Name
+	status.Reminders = append(status.Reminders, facturnetesv2.Reminder{
+		Level:       level.Name,
+		IssueDate:   issueDate,
+		Secret:      secret.Name,
+		DaysLate:    daysLate,
+		Outstanding: facturnetesv2.Decimal(money.Format(claim.Outstanding, scale)),
+		LateFee:     nonZero(claim.LateFee, scale),
+		Interest:    nonZero(claim.Interest, scale),
+		Total:       facturnetesv2.Decimal(money.Format(claim.Total, scale)),
+	})
+	// The reminder is recorded right after it is stored and only announced
+	// once recorded, so a failure later in the reconciliation never sends it
+	// again.
+	if err := r.storeStatus(ctx, invoice); err != nil {
+		return fmt.Errorf("unable to record the reminder: %w", err)
+	}
+
+	r.log.Infow("Sent dunning reminder", "level", level.Name, "daysLate", daysLate)
+	r.recorder.Eventf(invoice, corev1.EventTypeNormal, ReasonReminderSent,
+		"Reminder %s sent %d days after the due date", level.Name, daysLate)
+	return nil
+}
+
+// sent tells whether the reminder of the level was already sent.
+func sent(status *facturnetesv2.DunningStatus, level string) bool {
+	for _, reminder := range status.Reminders {
+		if reminder.Level == level {
+			return true
+		}
+	}
+	return false
+}
+
+func nonZero(d *inf.Dec, scale inf.Scale) facturnetesv2.Decimal {
+	if d.Sign() == 0 {
+		return ""
+	}
+	return facturnetesv2.Decimal(money.Format(d, scale))
+}
+
+// untilNextLevel returns how long an overdue invoice has until it reaches the
+// next dunning level, or zero when there is no next level.
+func untilNextLevel(invoice *facturnetesv2.Invoice, now time.Time) time.Duration {
+	status := invoice.Status.Dunning
+	if invoice.Status.State != facturnetesv2.Overdue || status == nil || status.NextLevelTime == nil {
+		return 0
+	}
+	if d := status.NextLevelTime.Sub(now); d > 0 {
+		return d
+	}
+	return 0
+}
@@ -2,18 +2,21 @@ package controllers
 
 import (
 	"context"
+	"fmt"
 
-	facturnetesv1 "github.com/cnvergence/facturnetes/api/v1"
+	facturnetesv2 "github.com/cnvergence/facturnetes/api/v2"
+	"github.com/cnvergence/facturnetes/pkg/document"
+	"github.com/cnvergence/facturnetes/pkg/einvoice"
+	"github.com/cnvergence/facturnetes/pkg/money"
 	"github.com/cnvergence/facturnetes/pkg/resource"
-	generator "github.com/cnvergence/invoice-generator/invoice"
-	"gopkg.in/yaml.v2"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	ctrl "sigs.k8s.io/controller-runtime"
 )
 
-func (r *InvoiceReconciler) ensureService(invoice *facturnetesv1.Invoice) error {
+func (r *InvoiceReconciler) ensureService(invoice *facturnetesv2.Invoice) error {
 	svc := resource.Service(invoice)
 	if err := ctrl.SetControllerReference(invoice, svc, r.Scheme); err != nil {
 		return nil
@@ -26,15 +29,18 @@ func (r *InvoiceReconciler) ensureService(invoice *facturnetesv1.Invoice) error
 	})
 	if err != nil {
 		r.log.Errorf("Could not create or patch the service: %s", err)
+		setCondition(invoice, facturnetesv2.ConditionExposed, metav1.ConditionFalse, ReasonServiceFailed, err.Error())
 		return err
 	}
 
 	r.log.Infow("Create/Update operation succeeded", "operation", op)
+	setCondition(invoice, facturnetesv2.ConditionExposed, metav1.ConditionTrue, ReasonServiceReady,
+		fmt.Sprintf("Viewer is reachable through Service %s", svc.Name))
 
 	return nil
 }
 
-func (r *InvoiceReconciler) ensureDeployment(invoice *facturnetesv1.Invoice) error {
+func (r *InvoiceReconciler) ensureDeployment(invoice *facturnetesv2.Invoice) error {
 	dep := resource.Deployment(invoice)
 	if err := ctrl.SetControllerReference(invoice, dep, r.Scheme); err != nil {
 		return nil
@@ -47,15 +53,24 @@ func (r *InvoiceReconciler) ensureDeployment(invoice *facturnetesv1.Invoice) err
 	})
 	if err != nil {
 		r.log.Errorf("Could not create or patch the Deployment: %s", err)
+		setCondition(invoice, facturnetesv2.ConditionViewerAvailable, metav1.ConditionFalse, ReasonDeploymentFailed, err.Error())
 		return err
 	}
 	r.log.Infow("Create/Update operation succeeded", "operation", op)
 
+	if deploymentAvailable(depo) {
+		setCondition(invoice, facturnetesv2.ConditionViewerAvailable, metav1.ConditionTrue, ReasonDeploymentAvailable,
+			fmt.Sprintf("Deployment %s is available", depo.Name))
+	} else {
+		setCondition(invoice, facturnetesv2.ConditionViewerAvailable, metav1.ConditionFalse, ReasonDeploymentProgressing,
+			fmt.Sprintf("Waiting for Deployment %s to become available", depo.Name))
+	}
+
 	return nil
 }
 
-func (r *InvoiceReconciler) ensureSecret(invoice *facturnetesv1.Invoice, pdf []byte) error {
-	sc := resource.Secret(invoice, pdf)
+func (r *InvoiceReconciler) ensureSecret(invoice *facturnetesv2.Invoice, documents map[string][]byte) error {
+	sc := resource.Secret(invoice, documents)
 	if err := ctrl.SetControllerReference(invoice, sc, r.Scheme); err != nil {
 		return nil
 	}
@@ -63,20 +78,36 @@ func (r *InvoiceReconciler) ensureSecret(invoice *facturnetesv1.Invoice, pdf []b
 	sco := sc.DeepCopyObject().(*corev1.Secret)
 	r.log.Infow("Reconciling Secret", "name", sco.Name)
 	op, err := ctrl.CreateOrUpdate(context.TODO(), r.client, sco, func() error {
+		if err := claimSecret(sco, invoice); err != nil {
+			return err
+		}
 		sco.Data = sc.Data
 		return nil
 	})
 	if err != nil {
 		r.log.Errorf("Could not create or patch the Secret: %s", err)
+		setCondition(invoice, facturnetesv2.ConditionSecretSynced, metav1.ConditionFalse, ReasonSyncFailed, err.Error())
 		return err
 	}
 
 	r.log.Infow("Create/Update operation succeeded", "operation", op)
+	setCondition(invoice, facturnetesv2.ConditionSecretSynced, metav1.ConditionTrue, ReasonSynced,
+		fmt.Sprintf("Document stored in Secret %s", sco.Name))
 
 	return nil
 }
 
-func (r *InvoiceReconciler) ensureIngress(invoice *facturnetesv1.Invoice) error {
+// claimSecret refuses an existing Secret the owner does not control, such as
+// the Secret of another document with the same name, so it is never updated
+// or taken for a document of the owner.
+func claimSecret(secret *corev1.Secret, owner metav1.Object) error {
+	if secret.ResourceVersion != "" && !metav1.IsControlledBy(secret, owner) {
+		return fmt.Errorf("the Secret %s exists and is not owned by %s", secret.Name, owner.GetName())
+	}
+	return nil
+}
+
+func (r *InvoiceReconciler) ensureIngress(invoice *facturnetesv2.Invoice) error {
 	ing := resource.Ingress(invoice)
 	if err := ctrl.SetControllerReference(invoice, ing, r.Scheme); err != nil {
 		return err
@@ -89,30 +120,144 @@ func (r *InvoiceReconciler) ensureIngress(invoice *facturnetesv1.Invoice) error
 	})
 	if err != nil {
 		r.log.Errorf("Could not create or patch the Ingress: %s", err)
+		setCondition(invoice, facturnetesv2.ConditionExposed, metav1.ConditionFalse, ReasonIngressFailed, err.Error())
 		return err
 	}
 
 	r.log.Infow("Create/Update operation succeeded", "operation", op)
 
+	if len(ingo.Status.LoadBalancer.Ingress) > 0 {
+		setCondition(invoice, facturnetesv2.ConditionExposed, metav1.ConditionTrue, ReasonIngressAdmitted,
+			fmt.Sprintf("Ingress %s is admitted", ingo.Name))
+	} else {
+		setCondition(invoice, facturnetesv2.ConditionExposed, metav1.ConditionFalse, ReasonIngressPending,
+			fmt.Sprintf("Waiting for Ingress %s to be admitted", ingo.Name))
+	}
+
 	return nil
 }
 
-func (r *InvoiceReconciler) generateInvoice(invoice facturnetesv1.Invoice) ([]byte, error) {
-	data, err := yaml.Marshal(invoice.Spec.InvoiceData)
+func deploymentAvailable(dep *appsv1.Deployment) bool {
+	for _, c := range dep.Status.Conditions {
+		if c.Type == appsv1.DeploymentAvailable {
+			return c.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
+
+func (r *InvoiceReconciler) computeTotals(invoice *facturnetesv2.Invoice) (*money.Totals, error) {
+	totals, err := money.Compute(&invoice.Spec.InvoiceData)
 	if err != nil {
-		r.log.Error(err, "unable to marshal yaml")
+		r.log.Error(err, "unable to compute invoice totals")
 		return nil, err
 	}
-	inv, err := generator.New(data)
+	invoice.Status.Totals = totals.Status(invoice.Spec.InvoiceData.Currency)
+
+	return totals, nil
+}
+
+func (r *InvoiceReconciler) generateInvoice(invoice facturnetesv2.Invoice, totals *money.Totals, advances []document.Advance, stamp string) ([]byte, error) {
+	doc := document.Invoice(&invoice, totals, advances...)
+	doc.Stamp = stamp
+	bytes, err := doc.Render()
 	if err != nil {
 		r.log.Error(err, "unable to create invoice")
 		return nil, err
 	}
-	bytes, err := inv.SaveAsBytes()
+
+	if invoice.Spec.InvoiceData.Options.Output == facturnetesv2.OutputFacturX {
+		inv, err := einvoice.New(&invoice, totals, advances...)
+		if err != nil {
+			return nil, err
+		}
+		if bytes, err = inv.FacturX(bytes); err != nil {
+			r.log.Error(err, "unable to create Factur-X invoice")
+			return nil, err
+		}
+	}
+
+	return bytes, nil
+}
+
+// generateUBL returns the UBL 2.1 Invoice XML of the invoice in the selected
+// profile, or in the core EN 16931 when the profile is written in CII.
+func (r *InvoiceReconciler) generateUBL(invoice facturnetesv2.Invoice, totals *money.Totals, advances []document.Advance) ([]byte, error) {
+	inv, err := einvoice.New(&invoice, totals, advances...)
 	if err != nil {
-		r.log.Error(err, "unable to create invoice")
 		return nil, err
 	}
+	profile, cii := einvoice.ProfileOf(invoice.Spec.InvoiceData.Options.Profile)
+	if cii {
+		profile = einvoice.EN16931
+	}
+	return inv.UBL(profile)
+}
 
-	return bytes, nil
+// generateCII returns the CII invoice XML of the invoice, or nil unless the
+// selected profile is written in CII.
+func (r *InvoiceReconciler) generateCII(invoice facturnetesv2.Invoice, totals *money.Totals, advances []document.Advance) ([]byte, error) {
+	profile, cii := einvoice.ProfileOf(invoice.Spec.InvoiceData.Options.Profile)
+	if !cii {
+		return nil, nil
+	}
+	inv, err := einvoice.New(&invoice, totals, advances...)
+	if err != nil {
+		return nil, err
+	}
+	return inv.CII(profile)
+}
+
+// generateKSeF returns the KSeF FA(2) invoice XML of the invoice, or nil when
+// the seller has no NIP or the invoice is a proforma, which KSeF does not take.
+// The XML is checked against the bundled schema when schema validation is enabled.
+func (r *InvoiceReconciler) generateKSeF(invoice facturnetesv2.Invoice, totals *money.Totals, advances []document.Advance) ([]byte, error) {
+	if invoice.Spec.InvoiceData.Company.Seller.NIP == "" || invoice.Spec.DocumentType == facturnetesv2.DocumentProforma {
+		return nil, nil
+	}
+	inv, err := einvoice.New(&invoice, totals, advances...)
+	if err != nil {
+		return nil, err
+	}
+	doc, err := inv.KSeF(invoice.Spec.DocumentType)
+	if err != nil {
+		return nil, err
+	}
+	return doc, r.validateSchema(einvoice.SchemaFA2, doc)
+}
+
+// generateFatturaPA returns the FatturaPA invoice XML of the invoice, or nil
+// when the seller has no Italian fields or the invoice is a proforma. The XML
+// is checked against the bundled schema when schema validation is enabled.
+func (r *InvoiceReconciler) generateFatturaPA(invoice facturnetesv2.Invoice, totals *money.Totals, advances []document.Advance) ([]byte, error) {
+	if invoice.Spec.InvoiceData.Company.Seller.Italy == nil || invoice.Spec.DocumentType == facturnetesv2.DocumentProforma {
+		return nil, nil
+	}
+	inv, err := einvoice.New(&invoice, totals, advances...)
+	if err != nil {
+		return nil, err
+	}
+	doc, err := inv.FatturaPA(invoice.Spec.DocumentType)
+	if err != nil {
+		return nil, err
+	}
+	return doc, r.validateSchema(einvoice.SchemaFatturaPA, doc)
+}
+
+// validateSchema checks a structured invoice against a bundled schema before
+// it is stored, when the reconciler has schemas.
+func (r *InvoiceReconciler) validateSchema(schema string, doc []byte) error {
+	if r.Schemas == nil {
+		return nil
+	}
+	return r.Schemas.Validate(schema, doc)
+}
+
+// viewerCopy returns the stamped copy of a paid invoice served by the viewer,
+// or nil when the viewer serves the PDF itself. The issued PDF is kept intact.
+func (r *InvoiceReconciler) viewerCopy(invoice facturnetesv2.Invoice, totals *money.Totals, advances []document.Advance) ([]byte, error) {
+	if resource.ViewerKey(&invoice) != resource.ViewerPDFKey {
+		return nil, nil
+	}
+	return r.generateInvoice(invoice, totals, advances, "PAID")
 }
@@ -0,0 +1,156 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	facturnetesv2 "github.com/cnvergence/facturnetes/api/v2"
+	"github.com/cnvergence/facturnetes/pkg/interest"
+	"github.com/cnvergence/facturnetes/pkg/money"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// interestRateTable returns the InterestRateTable of the invoice, or nil when
+// it has no reference and the namespace has no default table.
+func (r *InvoiceReconciler) interestRateTable(ctx context.Context, invoice *facturnetesv2.Invoice) (*facturnetesv2.InterestRateTable, error) {
+	if ref := invoice.Spec.InterestRateTableRef; ref != nil {
+		table := &facturnetesv2.InterestRateTable{}
+		key := client.ObjectKey{Namespace: invoice.Namespace, Name: ref.Name}
+		if err := r.client.Get(ctx, key, table); err != nil {
+			return nil, fmt.Errorf("unable to get InterestRateTable %s: %w", ref.Name, err)
+		}
+		return table, nil
+	}
+
+	tables := &facturnetesv2.InterestRateTableList{}
+	if err := r.client.List(ctx, tables, client.InNamespace(invoice.Namespace)); err != nil {
+		return nil, err
+	}
+	var defaults []*facturnetesv2.InterestRateTable
+	for i := range tables.Items {
+		if tables.Items[i].Spec.Default {
+			defaults = append(defaults, &tables.Items[i])
+		}
+	}
+	switch len(defaults) {
+	case 0:
+		return nil, nil
+	case 1:
+		return defaults[0], nil
+	}
+	return nil, fmt.Errorf("namespace %s has more than one default InterestRateTable: %s, %s",
+		invoice.Namespace, defaults[0].Name, defaults[1].Name)
+}
+
+// accrueInterest computes the late-payment interest of the invoice: the
+// interest on every payment received after the due date until its payment
+// date, and the interest on the outstanding amount of an overdue invoice
+// until today.
+func (r *InvoiceReconciler) accrueInterest(ctx context.Context, invoice *facturnetesv2.Invoice, now time.Time) error {
+	invoice.Status.Interest = nil
+	if isDraft(invoice) || invoice.Status.State == facturnetesv2.Cancelled || invoice.Status.Balance == nil {
+		return nil
+	}
+	table, err := r.interestRateTable(ctx, invoice)
+	if err != nil || table == nil {
+		return err
+	}
+	rates, err := interest.NewTable(&table.Spec)
+	if err != nil {
+		return fmt.Errorf("invalid InterestRateTable %s: %w", table.Name, err)
+	}
+	due, err := facturnetesv2.ParseDate(invoice.Spec.InvoiceData.DueDate)
+	if err != nil {
+		return err
+	}
+
+	var periods []interest.Period
+	var paidBy []string
+	until := due
+	_, payments, err := r.payments(ctx, invoice)
+	if err != nil {
+		return err
+	}
+	for _, payment := range payments {
+		date, _ := facturnetesv2.ParseDate(payment.Spec.Date)
+		amount, err := money.Parse(payment.Spec.Amount)
+		if err != nil {
+			return err
+		}
+		if !date.After(due) || amount.Sign() <= 0 {
+			continue
+		}
+		late, err := rates.Simple(amount, due, date)
+		if err != nil {
+			return err
+		}
+		periods = append(periods, late...)
+		for range late {
+			paidBy = append(paidBy, payment.Name)
+		}
+		if date.After(until) {
+			until = date
+		}
+	}
+
+	outstanding, err := money.Parse(invoice.Status.Balance.Outstanding)
+	if err != nil {
+		return err
+	}
+	if today := today(now); outstanding.Sign() > 0 && today.After(due) {
+		accrued, err := rates.Simple(outstanding, due, today)
+		if err != nil {
+			return err
+		}
+		periods = append(periods, accrued...)
+		until = today
+	}
+	if len(periods) == 0 {
+		return nil
+	}
+
+	scale := money.CurrencyScale(invoice.Spec.InvoiceData.Currency)
+	status := &facturnetesv2.InvoiceInterest{
+		Table:    table.Name,
+		DayCount: table.Spec.DayCount,
+		Until:    until.Format(facturnetesv2.DateLayout),
+		Amount:   facturnetesv2.Decimal(money.Format(interest.Sum(periods, scale), scale)),
+	}
+	if status.DayCount == "" {
+		status.DayCount = facturnetesv2.Actual365
+	}
+	for i, p := range periods {
+		var payment string
+		if i < len(paidBy) {
+			payment = paidBy[i]
+		}
+		status.Periods = append(status.Periods, facturnetesv2.InterestPeriod{
+			Payment:  payment,
+			Amount:   facturnetesv2.Decimal(money.Format(p.Amount, scale)),
+			From:     p.From.Format(facturnetesv2.DateLayout),
+			To:       p.To.Format(facturnetesv2.DateLayout),
+			Days:     int32(p.Days),
+			Rate:     facturnetesv2.Decimal(p.Rate.String()),
+			Interest: facturnetesv2.Decimal(money.Format(p.Interest, scale)),
+		})
+	}
+	invoice.Status.Interest = status
+
+	return nil
+}
+
+// today returns the start of the current day in UTC, the time zone of the
+// dates of the invoice.
+func today(now time.Time) time.Time {
+	return now.UTC().Truncate(24 * time.Hour)
+}
+
+// untilNextAccrual returns how long the interest of an overdue invoice has
+// until it accrues for the next day, or zero when it does not accrue.
+func untilNextAccrual(invoice *facturnetesv2.Invoice, now time.Time) time.Duration {
+	if invoice.Status.State != facturnetesv2.Overdue || invoice.Status.Interest == nil {
+		return 0
+	}
+	return today(now).AddDate(0, 0, 1).Sub(now)
+}
@@ -0,0 +1,354 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	facturnetesv2 "github.com/cnvergence/facturnetes/api/v2"
+	"github.com/cnvergence/facturnetes/pkg/document"
+	"github.com/cnvergence/facturnetes/pkg/money"
+	"github.com/cnvergence/facturnetes/pkg/resource"
+	"go.uber.org/zap"
+	"gopkg.in/inf.v0"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+// InterestNoteReconciler reconciles an InterestNote object
+type InterestNoteReconciler struct {
+	client    client.Client
+	apiReader client.Reader
+	Scheme    *runtime.Scheme
+	log       *zap.SugaredLogger
+}
+
+func NewInterestNoteReconciler(mgr manager.Manager) *InterestNoteReconciler {
+	return &InterestNoteReconciler{
+		client:    mgr.GetClient(),
+		apiReader: mgr.GetAPIReader(),
+		Scheme:    mgr.GetScheme(),
+		log:       zap.S(),
+	}
+}
+
+// +kubebuilder:rbac:groups=facturnetes.cnvergence.io,resources=interestnotes,verbs=get;list;watch;update;patch
+// +kubebuilder:rbac:groups=facturnetes.cnvergence.io,resources=interestnotes/status,verbs=get;update;patch
+// Reconcile collects the invoices of the buyer paid late in the period of the
+// interest note, renders the note and stores it in a Secret.
+func (r *InterestNoteReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	r.log = zap.S().With("InterestNote", req.NamespacedName)
+	r.log.Info("Reconciling InterestNote")
+
+	note := &facturnetesv2.InterestNote{}
+	if err := r.client.Get(ctx, req.NamespacedName, note); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	if err := facturnetesv2.ValidateInterestNote(note).ToAggregate(); err != nil {
+		r.setCondition(note, facturnetesv2.ConditionValidated, metav1.ConditionFalse, ReasonInvalid, err.Error())
+		return r.setFailureStatus(ctx, note, err)
+	}
+
+	// The lines of an issued note are kept as they were issued.
+	if note.Status.PDFSHA256 == "" {
+		if err := r.collectLines(ctx, note); err != nil {
+			r.setCondition(note, facturnetesv2.ConditionValidated, metav1.ConditionFalse, ReasonInvalid, err.Error())
+			return r.setFailureStatus(ctx, note, err)
+		}
+	}
+
+	if note.Spec.Number == "" {
+		latest := &facturnetesv2.InterestNote{}
+		if err := r.apiReader.Get(ctx, client.ObjectKeyFromObject(note), latest); err != nil {
+			return ctrl.Result{}, err
+		}
+		if latest.Spec.Number != "" {
+			// The cache is stale, the update of the number triggers the next reconciliation.
+			return ctrl.Result{}, nil
+		}
+		issued, _ := facturnetesv2.ParseDate(note.Spec.IssueDate)
+		number, err := nextNumber(ctx, r.client, r.apiReader, note.Namespace, note.Spec.SequenceRef,
+			facturnetesv2.DocumentInterestNote, issued)
+		if err != nil {
+			r.setCondition(note, facturnetesv2.ConditionValidated, metav1.ConditionFalse, ReasonNumberingFailed, err.Error())
+			return r.setFailureStatus(ctx, note, err)
+		}
+		r.log.Infow("Allocated interest note number", "number", number)
+		// Updating the number triggers the next reconciliation.
+		return ctrl.Result{}, setNumber(ctx, r.client, r.apiReader, note, &note.Spec.Number, number)
+	}
+	r.setCondition(note, facturnetesv2.ConditionValidated, metav1.ConditionTrue, ReasonValid, "Interest note is valid")
+
+	first := &facturnetesv2.Invoice{}
+	key := client.ObjectKey{Namespace: note.Namespace, Name: note.Status.Lines[0].Invoice}
+	if err := r.client.Get(ctx, key, first); err != nil {
+		err = fmt.Errorf("unable to get Invoice %s: %w", key.Name, err)
+		r.setCondition(note, facturnetesv2.ConditionPDFRendered, metav1.ConditionFalse, ReasonUnresolvedReference, err.Error())
+		return r.setFailureStatus(ctx, note, err)
+	}
+
+	var pdf []byte
+	var err error
+	if note.Status.PDFSHA256 != "" {
+		if pdf, err = storedPDF(ctx, r.client, client.ObjectKey{Namespace: note.Namespace, Name: resource.InterestNoteSecretName(note)}, note.Status.PDFSHA256); err != nil {
+			r.setCondition(note, facturnetesv2.ConditionPDFRendered, metav1.ConditionFalse, ReasonDigestMismatch, err.Error())
+			return r.setFailureStatus(ctx, note, err)
+		}
+		r.setCondition(note, facturnetesv2.ConditionPDFRendered, metav1.ConditionTrue, ReasonIssued,
+			fmt.Sprintf("PDF document of the issued interest note kept (sha256 %s)", note.Status.PDFSHA256))
+	} else {
+		if pdf, err = document.InterestNote(note, first).Render(); err != nil {
+			r.setCondition(note, facturnetesv2.ConditionPDFRendered, metav1.ConditionFalse, ReasonRenderFailed, err.Error())
+			return r.setFailureStatus(ctx, note, err)
+		}
+		r.setCondition(note, facturnetesv2.ConditionPDFRendered, metav1.ConditionTrue, ReasonRendered,
+			fmt.Sprintf("PDF document rendered (%d bytes)", len(pdf)))
+	}
+
+	if err := r.ensureSecret(ctx, note, pdf); err != nil {
+		r.setCondition(note, facturnetesv2.ConditionSecretSynced, metav1.ConditionFalse, ReasonSyncFailed, err.Error())
+		return r.setFailureStatus(ctx, note, err)
+	}
+	note.Status.PDFSHA256 = digest(pdf)
+	r.setCondition(note, facturnetesv2.ConditionSecretSynced, metav1.ConditionTrue, ReasonSynced,
+		fmt.Sprintf("Document stored in Secret %s", resource.InterestNoteSecretName(note)))
+
+	note.Status.ObservedGeneration = note.Generation
+	note.Status.Message = ""
+	r.setCondition(note, facturnetesv2.ConditionReady, metav1.ConditionTrue, ReasonReconciled, "Interest note is ready")
+	return ctrl.Result{}, r.client.Status().Update(ctx, note)
+}
+
+// collectLines lists the amounts of the selected invoices paid late in the
+// period of the note, with the interest computed for the invoices. Payments
+// charged by another issued interest note are left out. The invoices and the
+// notes are read from the API server, so a note issued just before is never
+// missed and no payment is charged twice.
+func (r *InterestNoteReconciler) collectLines(ctx context.Context, note *facturnetesv2.InterestNote) error {
+	claimed, err := r.claimedPayments(ctx, note)
+	if err != nil {
+		return err
+	}
+	invoices := &facturnetesv2.InvoiceList{}
+	if err := r.apiReader.List(ctx, invoices, client.InNamespace(note.Namespace)); err != nil {
+		return err
+	}
+	from, _ := facturnetesv2.ParseDate(note.Spec.From)
+	to, _ := facturnetesv2.ParseDate(note.Spec.To)
+
+	var lines []facturnetesv2.InterestNoteLine
+	currency := ""
+	for i := range invoices.Items {
+		invoice := &invoices.Items[i]
+		if invoice.Status.Interest == nil || !note.Spec.Selects(invoice) {
+			continue
+		}
+		due, err := facturnetesv2.ParseDate(invoice.Spec.InvoiceData.DueDate)
+		if err != nil {
+			return err
+		}
+		scale := money.CurrencyScale(invoice.Spec.InvoiceData.Currency)
+
+		byPayment := map[string]*facturnetesv2.InterestNoteLine{}
+		var order []string
+		for _, period := range invoice.Status.Interest.Periods {
+			if period.Payment == "" || claimed[invoice.Name+"/"+period.Payment] {
+				continue
+			}
+			paid, err := facturnetesv2.ParseDate(period.To)
+			if err != nil {
+				return err
+			}
+			if paid.Before(from) || paid.After(to) {
+				continue
+			}
+			amount, err := money.Parse(period.Interest)
+			if err != nil {
+				return err
+			}
+			line, ok := byPayment[period.Payment]
+			if !ok {
+				line = &facturnetesv2.InterestNoteLine{
+					Invoice:     invoice.Name,
+					Number:      invoice.Spec.InvoiceData.Number,
+					Payment:     period.Payment,
+					Amount:      period.Amount,
+					DueDate:     invoice.Spec.InvoiceData.DueDate,
+					PaymentDate: period.To,
+					DaysLate:    int32(paid.Sub(due) / (24 * time.Hour)),
+					Interest:    "0",
+				}
+				byPayment[period.Payment] = line
+				order = append(order, period.Payment)
+			}
+			sum, err := money.Parse(line.Interest)
+			if err != nil {
+				return err
+			}
+			line.Interest = facturnetesv2.Decimal(money.Format(sum.Add(sum, amount), scale))
+		}
+		if len(order) == 0 {
+			continue
+		}
+
+		if currency != "" && currency != invoice.Spec.InvoiceData.Currency {
+			return fmt.Errorf("invoices paid late are in %s and %s, issue an interest note per currency",
+				currency, invoice.Spec.InvoiceData.Currency)
+		}
+		currency = invoice.Spec.InvoiceData.Currency
+		for _, payment := range order {
+			lines = append(lines, *byPayment[payment])
+		}
+	}
+	if len(lines) == 0 {
+		return fmt.Errorf("no invoices of the buyer were paid late between %s and %s", note.Spec.From, note.Spec.To)
+	}
+
+	sort.SliceStable(lines, func(i, j int) bool {
+		a, _ := facturnetesv2.ParseDate(lines[i].PaymentDate)
+		b, _ := facturnetesv2.ParseDate(lines[j].PaymentDate)
+		if !a.Equal(b) {
+			return a.Before(b)
+		}
+		return lines[i].Number < lines[j].Number
+	})
+
+	scale := money.CurrencyScale(currency)
+	total := new(inf.Dec)
+	names := map[string]bool{}
+	note.Status.Invoices = nil
+	for _, line := range lines {
+		interest, err := money.Parse(line.Interest)
+		if err != nil {
+			return err
+		}
+		total.Add(total, interest)
+		if !names[line.Invoice] {
+			names[line.Invoice] = true
+			note.Status.Invoices = append(note.Status.Invoices, line.Invoice)
+		}
+	}
+	note.Status.Lines = lines
+	note.Status.Currency = currency
+	note.Status.Total = facturnetesv2.Decimal(money.Format(total, scale))
+
+	return nil
+}
+
+// claimedPayments returns the invoice/payment keys charged by the other issued
+// interest notes of the namespace.
+func (r *InterestNoteReconciler) claimedPayments(ctx context.Context, note *facturnetesv2.InterestNote) (map[string]bool, error) {
+	notes := &facturnetesv2.InterestNoteList{}
+	if err := r.apiReader.List(ctx, notes, client.InNamespace(note.Namespace)); err != nil {
+		return nil, err
+	}
+	claimed := map[string]bool{}
+	for _, other := range notes.Items {
+		if other.Name == note.Name || other.Status.PDFSHA256 == "" {
+			continue
+		}
+		for _, line := range other.Status.Lines {
+			claimed[line.Invoice+"/"+line.Payment] = true
+		}
+	}
+	return claimed, nil
+}
+
+// pendingInterestNotes enqueues the interest notes of the namespace of a
+// changed Invoice which are not issued yet.
+func (r *InterestNoteReconciler) pendingInterestNotes(obj client.Object) []reconcile.Request {
+	notes := &facturnetesv2.InterestNoteList{}
+	if err := r.client.List(context.Background(), notes, client.InNamespace(obj.GetNamespace())); err != nil {
+		r.log.Errorw("Unable to list interest notes", "namespace", obj.GetNamespace(), "error", err)
+		return nil
+	}
+
+	var requests []reconcile.Request
+	for _, note := range notes.Items {
+		if note.Status.PDFSHA256 != "" {
+			continue
+		}
+		requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&note)})
+	}
+	return requests
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *InterestNoteReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&facturnetesv2.InterestNote{}).
+		Watches(&source.Kind{Type: &facturnetesv2.Invoice{}},
+			handler.EnqueueRequestsFromMapFunc(r.pendingInterestNotes)).
+		Owns(&corev1.Secret{}).
+		Complete(r)
+}
+
+func (r *InterestNoteReconciler) ensureSecret(ctx context.Context, note *facturnetesv2.InterestNote, pdf []byte) error {
+	sc := resource.InterestNoteSecret(note, pdf)
+	if err := ctrl.SetControllerReference(note, sc, r.Scheme); err != nil {
+		return err
+	}
+
+	sco := sc.DeepCopyObject().(*corev1.Secret)
+	op, err := ctrl.CreateOrUpdate(ctx, r.client, sco, func() error {
+		if err := claimSecret(sco, note); err != nil {
+			return err
+		}
+		sco.Data = sc.Data
+		return nil
+	})
+	if err != nil {
+		r.log.Errorf("Could not create or patch the Secret: %s", err)
+		return err
+	}
+	r.log.Infow("Create/Update operation succeeded", "operation", op)
+
+	return nil
+}
+
+func (r *InterestNoteReconciler) setCondition(note *facturnetesv2.InterestNote, conditionType string, status metav1.ConditionStatus, reason, message string) {
+	meta.SetStatusCondition(&note.Status.Conditions, metav1.Condition{
+		Type:               conditionType,
+		Status:             status,
+		ObservedGeneration: note.Generation,
+		Reason:             reason,
+		Message:            message,
+	})
+}
+
+func (r *InterestNoteReconciler) setFailureStatus(ctx context.Context, note *facturnetesv2.InterestNote, msg error) (ctrl.Result, error) {
+	r.log.Error(msg)
+	note.Status.Message = msg.Error()
+	note.Status.ObservedGeneration = note.Generation
+	r.setCondition(note, facturnetesv2.ConditionReady, metav1.ConditionFalse, ReasonReconcileFailed, msg.Error())
+
+	return ctrl.Result{
+		RequeueAfter: 15 * time.Second,
+	}, r.client.Status().Update(ctx, note)
+}
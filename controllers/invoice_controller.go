@@ -18,17 +18,27 @@ package controllers
 
 import (
 	"context"
+	"fmt"
 	"os"
 	"time"
 
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/retry"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 
-	facturnetesv1 "github.com/cnvergence/facturnetes/api/v1"
+	facturnetesv2 "github.com/cnvergence/facturnetes/api/v2"
+	"github.com/cnvergence/facturnetes/pkg/einvoice"
+	"github.com/cnvergence/facturnetes/pkg/resource"
 	"go.uber.org/zap"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
@@ -36,16 +46,25 @@ import (
 
 // InvoiceReconciler reconciles a Invoice object
 type InvoiceReconciler struct {
-	client client.Client
-	Scheme *runtime.Scheme
-	log    *zap.SugaredLogger
+	client    client.Client
+	apiReader client.Reader
+	Scheme    *runtime.Scheme
+	recorder  record.EventRecorder
+	log       *zap.SugaredLogger
+	// Submitter sends the issued invoices to a clearance system, none when nil.
+	Submitter Submitter
+	// Schemas validates the structured invoices before they are stored, no
+	// validation when nil.
+	Schemas *einvoice.SchemaValidator
 }
 
 func NewReconciler(mgr manager.Manager) *InvoiceReconciler {
 	return &InvoiceReconciler{
-		client: mgr.GetClient(),
-		Scheme: mgr.GetScheme(),
-		log:    zap.S(),
+		client:    mgr.GetClient(),
+		apiReader: mgr.GetAPIReader(),
+		Scheme:    mgr.GetScheme(),
+		recorder:  mgr.GetEventRecorderFor("invoice-controller"),
+		log:       zap.S(),
 	}
 }
 
@@ -60,6 +79,20 @@ func init() {
 // +kubebuilder:rbac:groups=facturnetes.cnvergence.io,resources=invoices,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=facturnetes.cnvergence.io,resources=invoices/status,verbs=get;update;patch
 // +kubebuilder:rbac:groups=facturnetes.cnvergence.io,resources=invoices/finalizers,verbs=update
+// +kubebuilder:rbac:groups=facturnetes.cnvergence.io,resources=sellerprofiles,verbs=get;list;watch
+// +kubebuilder:rbac:groups=facturnetes.cnvergence.io,resources=customers,verbs=get;list;watch
+// +kubebuilder:rbac:groups=facturnetes.cnvergence.io,resources=invoicesequences,verbs=get;list;watch
+// +kubebuilder:rbac:groups=facturnetes.cnvergence.io,resources=invoicesequences/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=facturnetes.cnvergence.io,resources=creditnotes,verbs=get;list;watch;update;patch
+// +kubebuilder:rbac:groups=facturnetes.cnvergence.io,resources=creditnotes/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=facturnetes.cnvergence.io,resources=recurringinvoices,verbs=get;list;watch
+// +kubebuilder:rbac:groups=facturnetes.cnvergence.io,resources=recurringinvoices/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=facturnetes.cnvergence.io,resources=payments,verbs=get;list;watch
+// +kubebuilder:rbac:groups="",resources=events,verbs=create;patch
+// +kubebuilder:rbac:groups=facturnetes.cnvergence.io,resources=dunningpolicies,verbs=get;list;watch
+// +kubebuilder:rbac:groups=facturnetes.cnvergence.io,resources=interestratetables,verbs=get;list;watch
+// +kubebuilder:rbac:groups=facturnetes.cnvergence.io,resources=interestnotes,verbs=get;list;watch;update;patch
+// +kubebuilder:rbac:groups=facturnetes.cnvergence.io,resources=interestnotes/status,verbs=get;update;patch
 // +kubebuilder:rbac:groups=*,resources=deployments,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=*,resources=services,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=*,resources=secrets,verbs=get;list;watch;create;update;patch;delete
@@ -74,7 +107,7 @@ func init() {
 // For more details, check Reconcile and its Result here:
 // - https://pkg.go.dev/sigs.k8s.io/controller-runtime@v0.11.0/pkg/reconcile
 func (r *InvoiceReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
-	invoice := facturnetesv1.Invoice{}
+	invoice := facturnetesv2.Invoice{}
 	r.log = zap.S().With("Invoice", req.NamespacedName)
 	r.log.Info("Reconciling Invoice")
 
@@ -83,16 +116,152 @@ func (r *InvoiceReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ct
 		return r.SetFailureStatus(ctx, &invoice, err)
 	}
 
-	pdf, err := r.generateInvoice(invoice)
+	if err := validateInvoice(&invoice); err != nil {
+		r.log.Error(err, "invalid invoice data")
+		setCondition(&invoice, facturnetesv2.ConditionValidated, metav1.ConditionFalse, ReasonInvalid, err.Error())
+		return r.SetFailureStatus(ctx, &invoice, err)
+	}
+
+	if needsNumber(&invoice, time.Now()) {
+		if err := r.allocateNumber(ctx, &invoice); err != nil {
+			r.log.Error(err, "unable to allocate invoice number")
+			setCondition(&invoice, facturnetesv2.ConditionValidated, metav1.ConditionFalse, ReasonNumberingFailed, err.Error())
+			return r.SetFailureStatus(ctx, &invoice, err)
+		}
+		// Updating the number changes the generation and triggers the next reconciliation.
+		return ctrl.Result{}, nil
+	}
+
+	if err := r.holdIssuance(ctx, &invoice, time.Now()); err != nil {
+		r.log.Error(err, "invoice breaks EN 16931 business rules")
+		setCondition(&invoice, facturnetesv2.ConditionValidated, metav1.ConditionFalse, ReasonRulesViolated, err.Error())
+		return r.SetFailureStatus(ctx, &invoice, err)
+	}
+
+	parties, err := r.resolveParties(ctx, &invoice)
+	if err != nil {
+		r.log.Error(err, "unable to resolve invoice parties")
+		setCondition(&invoice, facturnetesv2.ConditionValidated, metav1.ConditionFalse, ReasonUnresolvedReference, err.Error())
+		return r.SetFailureStatus(ctx, &invoice, err)
+	}
+
+	if err := r.checkDuplicate(ctx, &invoice); err != nil {
+		r.log.Error(err, "duplicate invoice number")
+		setCondition(&invoice, facturnetesv2.ConditionValidated, metav1.ConditionFalse, ReasonDuplicateNumber, err.Error())
+		return r.SetFailureStatus(ctx, &invoice, err)
+	}
+	setCondition(&invoice, facturnetesv2.ConditionValidated, metav1.ConditionTrue, ReasonValid, "Invoice data is valid")
+
+	totals, err := r.computeTotals(&invoice)
+	if err != nil {
+		setCondition(&invoice, facturnetesv2.ConditionPDFRendered, metav1.ConditionFalse, ReasonRenderFailed, err.Error())
+		return r.SetFailureStatus(ctx, &invoice, err)
+	}
+	advances, err := r.settleAdvances(ctx, &invoice)
 	if err != nil {
-		r.log.Error(err, "unable to generate PDF invoice")
+		r.log.Error(err, "unable to settle advance invoices")
+		setCondition(&invoice, facturnetesv2.ConditionValidated, metav1.ConditionFalse, ReasonUnresolvedReference, err.Error())
+		return r.SetFailureStatus(ctx, &invoice, err)
+	}
+	if err := r.updateBalance(ctx, &invoice); err != nil {
+		r.log.Error(err, "unable to compute invoice balance")
+		return r.SetFailureStatus(ctx, &invoice, err)
+	}
+	// The invoice only leaves Draft once every check above has passed, as an
+	// issued invoice can no longer be changed. Payments received since the
+	// last reconciliation may settle it.
+	if err := r.advanceState(&invoice); err != nil {
+		r.log.Error(err, "unable to change invoice state")
+		setCondition(&invoice, facturnetesv2.ConditionValidated, metav1.ConditionFalse, ReasonInvalid, err.Error())
+		return r.SetFailureStatus(ctx, &invoice, err)
+	}
+	setOverdueCondition(&invoice)
+
+	rendered := invoice
+	rendered.Spec.InvoiceData.Company = parties
+	if _, err := r.checkRules(&invoice, rendered, totals, advances); err != nil {
+		r.log.Error(err, "unable to check EN 16931 business rules")
+		setCondition(&invoice, facturnetesv2.ConditionCompliant, metav1.ConditionFalse, ReasonInvalid, err.Error())
+		return r.SetFailureStatus(ctx, &invoice, err)
+	}
+	pdf, err := r.issuedPDF(ctx, &invoice)
+	if err != nil {
+		r.log.Error(err, "issued PDF invoice is not intact")
+		setCondition(&invoice, facturnetesv2.ConditionPDFRendered, metav1.ConditionFalse, ReasonDigestMismatch, err.Error())
+		return r.SetFailureStatus(ctx, &invoice, err)
+	}
+	if pdf != nil {
+		setCondition(&invoice, facturnetesv2.ConditionPDFRendered, metav1.ConditionTrue, ReasonIssued,
+			fmt.Sprintf("PDF document of the issued invoice kept (sha256 %s)", invoice.Status.PDFSHA256))
+	} else {
+		pdf, err = r.generateInvoice(rendered, totals, advances, "")
+		if err != nil {
+			r.log.Error(err, "unable to generate PDF invoice")
+			setCondition(&invoice, facturnetesv2.ConditionPDFRendered, metav1.ConditionFalse, ReasonRenderFailed, err.Error())
+			return r.SetFailureStatus(ctx, &invoice, err)
+		}
+		setCondition(&invoice, facturnetesv2.ConditionPDFRendered, metav1.ConditionTrue, ReasonRendered,
+			fmt.Sprintf("PDF document rendered (%d bytes)", len(pdf)))
+	}
+
+	viewer, err := r.viewerCopy(rendered, totals, advances)
+	if err != nil {
+		setCondition(&invoice, facturnetesv2.ConditionPDFRendered, metav1.ConditionFalse, ReasonRenderFailed, err.Error())
+		return r.SetFailureStatus(ctx, &invoice, err)
+	}
+
+	ubl, err := r.generateUBL(rendered, totals, advances)
+	if err != nil {
+		r.log.Error(err, "unable to generate UBL invoice")
+		setCondition(&invoice, facturnetesv2.ConditionSecretSynced, metav1.ConditionFalse, ReasonExportFailed, err.Error())
+		return r.SetFailureStatus(ctx, &invoice, err)
+	}
+	cii, err := r.generateCII(rendered, totals, advances)
+	if err != nil {
+		r.log.Error(err, "unable to generate CII invoice")
+		setCondition(&invoice, facturnetesv2.ConditionSecretSynced, metav1.ConditionFalse, ReasonExportFailed, err.Error())
+		return r.SetFailureStatus(ctx, &invoice, err)
+	}
+	ksef, err := r.generateKSeF(rendered, totals, advances)
+	if err != nil {
+		r.log.Error(err, "unable to generate KSeF invoice")
+		setCondition(&invoice, facturnetesv2.ConditionSecretSynced, metav1.ConditionFalse, ReasonExportFailed, err.Error())
+		return r.SetFailureStatus(ctx, &invoice, err)
+	}
+	fatturaPA, err := r.generateFatturaPA(rendered, totals, advances)
+	if err != nil {
+		r.log.Error(err, "unable to generate FatturaPA invoice")
+		setCondition(&invoice, facturnetesv2.ConditionSecretSynced, metav1.ConditionFalse, ReasonExportFailed, err.Error())
 		return r.SetFailureStatus(ctx, &invoice, err)
 	}
 
 	r.log.Debug("Ensuring that Secret exists")
-	if err := r.ensureSecret(&invoice, pdf); err != nil {
+	documents := map[string][]byte{
+		resource.PDFKey:       pdf,
+		resource.ViewerPDFKey: viewer,
+		resource.UBLKey:       ubl,
+		resource.CIIKey:       cii,
+		resource.KSeFKey:      ksef,
+		resource.FatturaPAKey: fatturaPA,
+	}
+	if err := r.ensureSecret(&invoice, documents); err != nil {
 		return r.SetFailureStatus(ctx, &invoice, err)
 	}
+	// The digest is only kept once the PDF it stands for is stored.
+	recordDigest(&invoice, pdf)
+
+	submitted := invoice.Status.Clearance != nil
+	if err := r.submit(ctx, &invoice, parties, documents, time.Now()); err != nil {
+		r.log.Error(err, "unable to submit invoice for clearance")
+		setCondition(&invoice, facturnetesv2.ConditionCleared, metav1.ConditionFalse, ReasonSubmissionFailed, err.Error())
+		return r.SetFailureStatus(ctx, &invoice, err)
+	}
+	if !submitted && invoice.Status.Clearance != nil {
+		if err := r.storeStatus(ctx, &invoice); err != nil {
+			r.log.Error(err, "unable to record the submission for clearance")
+			return ctrl.Result{}, err
+		}
+	}
 
 	r.log.Debug("Ensuring that Deployment exists")
 	if err := r.ensureDeployment(&invoice); err != nil {
@@ -111,24 +280,69 @@ func (r *InvoiceReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ct
 		}
 	}
 
+	now := time.Now()
+	if err := r.accrueInterest(ctx, &invoice, now); err != nil {
+		r.log.Error(err, "unable to compute late-payment interest")
+		setCondition(&invoice, facturnetesv2.ConditionOverdue, metav1.ConditionTrue, ReasonInterestFailed, err.Error())
+		return r.SetFailureStatus(ctx, &invoice, err)
+	}
+	if err := r.dun(ctx, &invoice, now); err != nil {
+		r.log.Error(err, "unable to send dunning reminder")
+		setCondition(&invoice, facturnetesv2.ConditionOverdue, metav1.ConditionTrue, ReasonDunningFailed, err.Error())
+		return r.SetFailureStatus(ctx, &invoice, err)
+	}
+
 	return r.SetSuccessStatus(ctx, &invoice)
 }
 
 // SetupWithManager sets up the controller with the Manager.
 func (r *InvoiceReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	indexer := mgr.GetFieldIndexer()
+	if err := indexer.IndexField(context.Background(), &facturnetesv2.Invoice{}, customerRefField, indexCustomerRef); err != nil {
+		return err
+	}
+	if err := indexer.IndexField(context.Background(), &facturnetesv2.Invoice{}, sellerRefField, indexSellerRef); err != nil {
+		return err
+	}
+	if err := indexer.IndexField(context.Background(), &facturnetesv2.Invoice{}, facturnetesv2.InvoiceNumberField, facturnetesv2.IndexInvoiceNumber); err != nil {
+		return err
+	}
+	if err := indexer.IndexField(context.Background(), &facturnetesv2.CreditNote{}, creditNoteInvoiceField, indexCreditNoteInvoice); err != nil {
+		return err
+	}
+	if err := indexer.IndexField(context.Background(), &facturnetesv2.Payment{}, paymentInvoiceField, indexPaymentInvoice); err != nil {
+		return err
+	}
+
 	return ctrl.NewControllerManagedBy(mgr).
-		WithEventFilter(predicate.GenerationChangedPredicate{}).
-		For(&facturnetesv1.Invoice{}).
+		For(&facturnetesv2.Invoice{}, builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		Watches(&source.Kind{Type: &facturnetesv2.Customer{}},
+			handler.EnqueueRequestsFromMapFunc(r.invoicesReferencing(customerRefField))).
+		Watches(&source.Kind{Type: &facturnetesv2.SellerProfile{}},
+			handler.EnqueueRequestsFromMapFunc(r.invoicesReferencing(sellerRefField))).
+		Watches(&source.Kind{Type: &facturnetesv2.CreditNote{}},
+			handler.EnqueueRequestsFromMapFunc(invoiceOfCreditNote)).
+		Watches(&source.Kind{Type: &facturnetesv2.Payment{}},
+			handler.EnqueueRequestsFromMapFunc(invoiceOfPayment)).
+		Watches(&source.Kind{Type: &facturnetesv2.DunningPolicy{}},
+			handler.EnqueueRequestsFromMapFunc(r.overdueInvoicesOf)).
+		Watches(&source.Kind{Type: &facturnetesv2.InterestRateTable{}},
+			handler.EnqueueRequestsFromMapFunc(r.overdueInvoicesOf)).
 		Owns(&appsv1.Deployment{}).
 		Owns(&corev1.Service{}).
 		Owns(&corev1.Secret{}).
+		Owns(&networkingv1.Ingress{}).
 		Complete(r)
 }
 
-func (r *InvoiceReconciler) SetSuccessStatus(ctx context.Context, invoice *facturnetesv1.Invoice) (ctrl.Result, error) {
+func (r *InvoiceReconciler) SetSuccessStatus(ctx context.Context, invoice *facturnetesv2.Invoice) (ctrl.Result, error) {
+	now := time.Now()
+	processed := invoice.Status.LastProcessedTime
 	invoice.Status.ObservedGeneration = invoice.Generation
-	invoice.Status.LastProcessedTime = &metav1.Time{Time: time.Now()}
-	invoice.Status.Phase = facturnetesv1.Success
+	invoice.Status.LastProcessedTime = &metav1.Time{Time: now}
+	invoice.Status.Phase = facturnetesv2.Success
+	invoice.Status.Message = ""
+	setReadyCondition(invoice)
 
 	if err := r.client.Status().Update(ctx, invoice); err != nil {
 		r.log.Error(err, "Unable to update the status")
@@ -136,17 +350,59 @@ func (r *InvoiceReconciler) SetSuccessStatus(ctx context.Context, invoice *factu
 			RequeueAfter: 15 * time.Second,
 		}, err
 	}
+	r.announceOverdue(invoice, processed)
 
-	return ctrl.Result{}, nil
+	// Come back when an unpaid invoice becomes overdue, reaches the next
+	// dunning level or accrues interest for another day, and to poll a
+	// pending submission.
+	var after time.Duration
+	for _, next := range []time.Duration{
+		untilOverdue(invoice, now),
+		untilNextLevel(invoice, now),
+		untilNextAccrual(invoice, now),
+		untilNextPoll(invoice),
+	} {
+		if next > 0 && (after == 0 || next < after) {
+			after = next
+		}
+	}
+	return ctrl.Result{RequeueAfter: after}, nil
 }
 
-func (r *InvoiceReconciler) SetFailureStatus(ctx context.Context, invoice *facturnetesv1.Invoice, msg error) (ctrl.Result, error) {
+// storeStatus stores the status of the invoice before the end of the
+// reconciliation, once it records something never to be done twice, such as
+// a submission for clearance or a reminder sent. A conflict with a newer
+// version of the invoice is retried over that version.
+func (r *InvoiceReconciler) storeStatus(ctx context.Context, invoice *facturnetesv2.Invoice) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		err := r.client.Status().Update(ctx, invoice)
+		if !apierrors.IsConflict(err) {
+			return err
+		}
+		latest := &facturnetesv2.Invoice{}
+		if err := r.apiReader.Get(ctx, client.ObjectKeyFromObject(invoice), latest); err != nil {
+			return err
+		}
+		invoice.ResourceVersion = latest.ResourceVersion
+		return err
+	})
+}
+
+func (r *InvoiceReconciler) SetFailureStatus(ctx context.Context, invoice *facturnetesv2.Invoice, msg error) (ctrl.Result, error) {
+	processed := invoice.Status.LastProcessedTime
 	invoice.Status.Message = msg.Error()
 	invoice.Status.ObservedGeneration = invoice.Generation
 	invoice.Status.LastProcessedTime = &metav1.Time{Time: time.Now()}
-	invoice.Status.Phase = facturnetesv1.Failure
+	invoice.Status.Phase = facturnetesv2.Failure
+	setCondition(invoice, facturnetesv2.ConditionReady, metav1.ConditionFalse, ReasonReconcileFailed, msg.Error())
 
+	if err := r.client.Status().Update(ctx, invoice); err != nil {
+		return ctrl.Result{
+			RequeueAfter: 15 * time.Second,
+		}, err
+	}
+	r.announceOverdue(invoice, processed)
 	return ctrl.Result{
 		RequeueAfter: 15 * time.Second,
-	}, r.client.Status().Update(ctx, invoice)
+	}, nil
 }
@@ -0,0 +1,240 @@
+package controllers
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	facturnetesv1 "github.com/cnvergence/facturnetes/api/v1"
+	facturnetesv2 "github.com/cnvergence/facturnetes/api/v2"
+)
+
+func newInvoice(name string) *facturnetesv2.Invoice {
+	return &facturnetesv2.Invoice{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+		Spec: facturnetesv2.InvoiceSpec{
+			InvoiceData: facturnetesv2.InvoiceData{
+				Number:    "FV/2022/01/" + name,
+				IssueDate: "01-01-2022",
+				SaleDate:  "31-01-2022",
+				DueDate:   "14-02-2022",
+				Currency:  "EUR",
+				Signature: "Best Company",
+				Bank: facturnetesv2.Bank{
+					AccountNumber: "PL61 1090 1014 0000 0712 1981 2874",
+					Swift:         "BANKPLPW",
+				},
+				Company: facturnetesv2.Company{
+					Buyer:  facturnetesv2.Buyer{Name: "Best Customer", Address: "Office Str Places, World", VAT: "111111111"},
+					Seller: facturnetesv2.Seller{Name: "Best Company", Address: "Best Company Str. Places, World", VAT: "222222222"},
+				},
+				Items: []*facturnetesv2.Item{
+					{Description: "Potatoes", Quantity: "33", UnitPrice: "3.05", VATRate: "23"},
+				},
+			},
+		},
+	}
+}
+
+func expectInvalid(err error, fields ...string) {
+	ExpectWithOffset(1, apierrors.IsInvalid(err)).To(BeTrue(), "expected Invalid error, got %v", err)
+	for _, f := range fields {
+		ExpectWithOffset(1, err.Error()).To(ContainSubstring(f))
+	}
+}
+
+var _ = Describe("Invoice validating webhook", func() {
+	It("accepts a valid invoice", func() {
+		invoice := newInvoice("valid")
+		Expect(k8sClient.Create(ctx, invoice)).To(Succeed())
+		Expect(k8sClient.Delete(ctx, invoice)).To(Succeed())
+	})
+
+	It("rejects bad dates", func() {
+		invoice := newInvoice("bad-dates")
+		invoice.Spec.InvoiceData.IssueDate = "2022-01-01"
+		invoice.Spec.InvoiceData.DueDate = "32-01-2022"
+		expectInvalid(k8sClient.Create(ctx, invoice), "spec.invoiceData.issueDate", "spec.invoiceData.dueDate")
+	})
+
+	It("rejects a due date before the issue date", func() {
+		invoice := newInvoice("early-due")
+		invoice.Spec.InvoiceData.DueDate = "31-12-2021"
+		expectInvalid(k8sClient.Create(ctx, invoice), "spec.invoiceData.dueDate")
+	})
+
+	It("rejects empty items and negative quantities", func() {
+		invoice := newInvoice("no-items")
+		invoice.Spec.InvoiceData.Items = nil
+		expectInvalid(k8sClient.Create(ctx, invoice), "spec.invoiceData.items")
+
+		invoice = newInvoice("negative-quantity")
+		invoice.Spec.InvoiceData.Items[0].Quantity = "-1"
+		expectInvalid(k8sClient.Create(ctx, invoice), "spec.invoiceData.items[0].quantity")
+	})
+
+	It("rejects unknown currencies", func() {
+		invoice := newInvoice("bad-currency")
+		invoice.Spec.InvoiceData.Currency = "XYZ"
+		expectInvalid(k8sClient.Create(ctx, invoice), "spec.invoiceData.currency")
+	})
+
+	It("rejects a malformed IBAN", func() {
+		invoice := newInvoice("bad-iban")
+		invoice.Spec.InvoiceData.Bank.AccountNumber = "PL61 1090 1014 0000 0712 1981 2875"
+		expectInvalid(k8sClient.Create(ctx, invoice), "spec.invoiceData.bank.accountNumber")
+	})
+
+	It("requires the buyer inline or by reference", func() {
+		invoice := newInvoice("no-buyer")
+		invoice.Spec.InvoiceData.Company.Buyer = facturnetesv2.Buyer{}
+		expectInvalid(k8sClient.Create(ctx, invoice), "spec.invoiceData.company.buyer.name")
+
+		invoice = newInvoice("customer-ref")
+		invoice.Spec.InvoiceData.Company.Buyer = facturnetesv2.Buyer{}
+		invoice.Spec.CustomerRef = &corev1.LocalObjectReference{Name: "best-customer"}
+		Expect(k8sClient.Create(ctx, invoice, client.DryRunAll)).To(Succeed())
+	})
+
+	It("requires the fields of the e-invoice profile", func() {
+		invoice := newInvoice("peppol")
+		invoice.Spec.InvoiceData.Options.Profile = facturnetesv2.ProfilePeppolBIS
+		expectInvalid(k8sClient.Create(ctx, invoice),
+			"spec.invoiceData.company.buyer.buyerReference", "spec.invoiceData.company.buyer.endpointID",
+			"spec.invoiceData.company.seller.endpointID")
+
+		invoice = newInvoice("xrechnung")
+		invoice.Spec.InvoiceData.Options.Profile = facturnetesv2.ProfileXRechnungCII
+		invoice.Spec.CustomerRef = &corev1.LocalObjectReference{Name: "best-customer"}
+		expectInvalid(k8sClient.Create(ctx, invoice), "spec.invoiceData.company.seller.contact.name")
+	})
+
+	It("rejects a number already used by the seller", func() {
+		first := newInvoice("first")
+		Expect(k8sClient.Create(ctx, first)).To(Succeed())
+		defer func() { Expect(k8sClient.Delete(ctx, first)).To(Succeed()) }()
+
+		// The webhook lists invoices from the manager cache, so allow it to catch up.
+		Eventually(func() error {
+			second := newInvoice("second")
+			second.Spec.InvoiceData.Number = first.Spec.InvoiceData.Number
+			return k8sClient.Create(ctx, second, client.DryRunAll)
+		}, "10s", "250ms").Should(MatchError(ContainSubstring("spec.invoiceData.number")))
+
+		other := newInvoice("other-seller")
+		other.Spec.InvoiceData.Number = first.Spec.InvoiceData.Number
+		other.Spec.InvoiceData.Company.Seller.VAT = "444444444"
+		Expect(k8sClient.Create(ctx, other, client.DryRunAll)).To(Succeed())
+	})
+
+	It("rejects a bad public URL", func() {
+		invoice := newInvoice("bad-url")
+		invoice.Spec.Exposure.PublicURL = "invoices.example.com/99"
+		expectInvalid(k8sClient.Create(ctx, invoice), "spec.exposure.publicURL")
+	})
+
+	It("rejects invalid state transitions", func() {
+		invoice := newInvoice("transition")
+		Expect(k8sClient.Create(ctx, invoice)).To(Succeed())
+
+		invoice.Spec.State = facturnetesv2.Paid
+		expectInvalid(k8sClient.Update(ctx, invoice), "spec.state")
+		Expect(k8sClient.Delete(ctx, invoice)).To(Succeed())
+	})
+
+	It("rejects changes to an issued invoice", func() {
+		invoice := newInvoice("issued")
+		invoice.Spec.State = facturnetesv2.Issued
+		Expect(k8sClient.Create(ctx, invoice)).To(Succeed())
+
+		// Until the controller issues it, the invoice may still be fixed.
+		invoice.Spec.InvoiceData.Items[0].Quantity = "34"
+		invoice.Spec.State = facturnetesv2.Draft
+		Expect(k8sClient.Update(ctx, invoice)).To(Succeed())
+		invoice.Spec.State = facturnetesv2.Issued
+		Expect(k8sClient.Update(ctx, invoice)).To(Succeed())
+
+		invoice.Status.State = facturnetesv2.Issued
+		Expect(k8sClient.Status().Update(ctx, invoice)).To(Succeed())
+		invoice.Spec.InvoiceData.Items[0].Quantity = "33"
+		expectInvalid(k8sClient.Update(ctx, invoice), "spec.invoiceData")
+		invoice.Spec.State = facturnetesv2.Draft
+		expectInvalid(k8sClient.Update(ctx, invoice), "spec.state")
+
+		invoice.Spec.InvoiceData.Items[0].Quantity = "34"
+		invoice.Spec.State = facturnetesv2.Sent
+		Expect(k8sClient.Update(ctx, invoice)).To(Succeed())
+		Expect(k8sClient.Delete(ctx, invoice)).To(Succeed())
+	})
+
+	It("validates invoices written through v1", func() {
+		invoice := &facturnetesv1.Invoice{
+			ObjectMeta: metav1.ObjectMeta{Name: "v1-invoice", Namespace: "default"},
+		}
+		Expect((invoice).ConvertFrom(newInvoice("v1-invoice"))).To(Succeed())
+		invoice.Annotations = nil
+		invoice.Spec.InvoiceData.Items[0].Quantity = -3
+		expectInvalid(k8sClient.Create(ctx, invoice), "spec.invoiceData.items[0].quantity")
+	})
+
+	It("keeps the v2 status of invoices read through v1", func() {
+		original := newInvoice("v1-status")
+		original.Status.State = facturnetesv2.Issued
+		original.Status.PDFSHA256 = "0123abcd"
+		original.Status.Totals = &facturnetesv2.InvoiceTotals{Currency: "EUR"}
+		original.Status.Parties = &original.Spec.InvoiceData.Company
+		invoice := &facturnetesv1.Invoice{}
+		Expect(invoice.ConvertFrom(original)).To(Succeed())
+
+		converted := &facturnetesv2.Invoice{}
+		Expect(invoice.ConvertTo(converted)).To(Succeed())
+		Expect(converted.Status).To(Equal(original.Status))
+	})
+})
+
+var _ = Describe("Invoice defaulting webhook", func() {
+	It("fills missing invoice data from the default SellerProfile", func() {
+		days := int32(14)
+		profile := &facturnetesv2.SellerProfile{
+			ObjectMeta: metav1.ObjectMeta{Name: "default-profile", Namespace: "default"},
+			Spec: facturnetesv2.SellerProfileSpec{
+				Default:         true,
+				Seller:          facturnetesv2.Seller{Name: "Profile Company", Address: "Profile Str. 1", VAT: "333333333"},
+				Bank:            facturnetesv2.Bank{AccountNumber: "PL61 1090 1014 0000 0712 1981 2874", Swift: "BANKPLPW"},
+				Currency:        "PLN",
+				Signature:       "Profile Company",
+				PaymentTermDays: &days,
+			},
+		}
+		Expect(k8sClient.Create(ctx, profile)).To(Succeed())
+		defer func() { Expect(k8sClient.Delete(ctx, profile)).To(Succeed()) }()
+
+		// The webhook reads profiles from the manager cache, so allow it to catch up.
+		Eventually(func() (facturnetesv2.InvoiceData, error) {
+			invoice := newInvoice("defaulted")
+			invoice.Spec.InvoiceData.DueDate = ""
+			invoice.Spec.InvoiceData.Currency = ""
+			invoice.Spec.InvoiceData.Signature = ""
+			invoice.Spec.InvoiceData.Bank = facturnetesv2.Bank{}
+			invoice.Spec.InvoiceData.Company.Seller = facturnetesv2.Seller{}
+			err := k8sClient.Create(ctx, invoice, client.DryRunAll)
+			return invoice.Spec.InvoiceData, err
+		}, "10s", "250ms").Should(And(
+			HaveField("Company.Seller.Name", "Profile Company"),
+			HaveField("Currency", "PLN"),
+			HaveField("Signature", "Profile Company"),
+			HaveField("Bank.Swift", "BANKPLPW"),
+			HaveField("DueDate", "15-01-2022"),
+		))
+	})
+
+	It("keeps values set on the invoice", func() {
+		invoice := newInvoice("explicit")
+		Expect(k8sClient.Create(ctx, invoice, client.DryRunAll)).To(Succeed())
+		Expect(invoice.Spec.InvoiceData.Company.Seller.Name).To(Equal("Best Company"))
+		Expect(invoice.Spec.InvoiceData.DueDate).To(Equal("14-02-2022"))
+	})
+})
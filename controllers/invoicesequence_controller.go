@@ -0,0 +1,109 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"reflect"
+
+	facturnetesv2 "github.com/cnvergence/facturnetes/api/v2"
+	"github.com/cnvergence/facturnetes/pkg/sequence"
+	"go.uber.org/zap"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+// InvoiceSequenceReconciler reports the gaps of an InvoiceSequence.
+type InvoiceSequenceReconciler struct {
+	client client.Client
+	log    *zap.SugaredLogger
+}
+
+func NewSequenceReconciler(mgr manager.Manager) *InvoiceSequenceReconciler {
+	return &InvoiceSequenceReconciler{
+		client: mgr.GetClient(),
+		log:    zap.S(),
+	}
+}
+
+// +kubebuilder:rbac:groups=facturnetes.cnvergence.io,resources=invoicesequences,verbs=get;list;watch
+// +kubebuilder:rbac:groups=facturnetes.cnvergence.io,resources=invoicesequences/status,verbs=get;update;patch
+// Reconcile lists the numbers missing from the invoices numbered with the
+// format of the sequence.
+func (r *InvoiceSequenceReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	log := r.log.With("InvoiceSequence", req.NamespacedName)
+
+	seq := &facturnetesv2.InvoiceSequence{}
+	if err := r.client.Get(ctx, req.NamespacedName, seq); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	invoices := &facturnetesv2.InvoiceList{}
+	if err := r.client.List(ctx, invoices, client.InNamespace(req.Namespace)); err != nil {
+		return ctrl.Result{}, err
+	}
+	numbers := make([]string, 0, len(invoices.Items))
+	for _, invoice := range invoices.Items {
+		numbers = append(numbers, invoice.Spec.InvoiceData.Number)
+	}
+
+	gaps := sequence.Gaps(seq, numbers)
+	if reflect.DeepEqual(gaps, seq.Status.Gaps) {
+		return ctrl.Result{}, nil
+	}
+	seq.Status.Gaps = gaps
+	// The update fails on a conflict with a number allocation and is retried,
+	// so a stale counter is never written back.
+	if err := r.client.Status().Update(ctx, seq); err != nil {
+		if apierrors.IsConflict(err) {
+			return ctrl.Result{Requeue: true}, nil
+		}
+		return ctrl.Result{}, err
+	}
+	log.Infow("Updated the gap report", "periods", len(gaps))
+
+	return ctrl.Result{}, nil
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *InvoiceSequenceReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&facturnetesv2.InvoiceSequence{}).
+		Watches(&source.Kind{Type: &facturnetesv2.Invoice{}},
+			handler.EnqueueRequestsFromMapFunc(r.sequencesOfNamespace)).
+		Complete(r)
+}
+
+// sequencesOfNamespace enqueues the sequences of the namespace of a changed invoice.
+func (r *InvoiceSequenceReconciler) sequencesOfNamespace(obj client.Object) []reconcile.Request {
+	sequences := &facturnetesv2.InvoiceSequenceList{}
+	if err := r.client.List(context.Background(), sequences, client.InNamespace(obj.GetNamespace())); err != nil {
+		r.log.Errorw("Unable to list invoice sequences", "namespace", obj.GetNamespace(), "error", err)
+		return nil
+	}
+
+	requests := make([]reconcile.Request, 0, len(sequences.Items))
+	for _, seq := range sequences.Items {
+		requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&seq)})
+	}
+	return requests
+}
@@ -0,0 +1,52 @@
+package controllers
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+
+	facturnetesv2 "github.com/cnvergence/facturnetes/api/v2"
+	"github.com/cnvergence/facturnetes/pkg/resource"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func digest(pdf []byte) string {
+	sum := sha256.Sum256(pdf)
+	return hex.EncodeToString(sum[:])
+}
+
+// issuedPDF returns the stored PDF of an issued invoice, checked against the
+// digest recorded in the status. It returns nil when the invoice has no
+// recorded PDF yet and has to be rendered.
+func (r *InvoiceReconciler) issuedPDF(ctx context.Context, invoice *facturnetesv2.Invoice) ([]byte, error) {
+	if isDraft(invoice) || invoice.Status.PDFSHA256 == "" {
+		return nil, nil
+	}
+	return storedPDF(ctx, r.client, client.ObjectKeyFromObject(invoice), invoice.Status.PDFSHA256)
+}
+
+// storedPDF reads the PDF kept in the Secret and checks it against its digest.
+func storedPDF(ctx context.Context, c client.Reader, key client.ObjectKey, sum string) ([]byte, error) {
+	secret := &corev1.Secret{}
+	if err := c.Get(ctx, key, secret); err != nil {
+		return nil, fmt.Errorf("unable to read the issued PDF: %w", err)
+	}
+	pdf := secret.Data[resource.PDFKey]
+	if digest(pdf) != sum {
+		return nil, fmt.Errorf("stored PDF does not match the SHA-256 %s recorded when it was issued", sum)
+	}
+
+	return pdf, nil
+}
+
+// recordDigest keeps the digest of the PDF stored for an issued invoice, so that the
+// PDF is not rendered again.
+func recordDigest(invoice *facturnetesv2.Invoice, pdf []byte) {
+	if isDraft(invoice) {
+		invoice.Status.PDFSHA256 = ""
+		return
+	}
+	invoice.Status.PDFSHA256 = digest(pdf)
+}
@@ -0,0 +1,177 @@
+package controllers
+
+import (
+	"fmt"
+	"time"
+
+	facturnetesv2 "github.com/cnvergence/facturnetes/api/v2"
+	"github.com/cnvergence/facturnetes/pkg/money"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// isDraft tells whether the invoice has not been issued yet.
+func isDraft(invoice *facturnetesv2.Invoice) bool {
+	return invoice.Status.State == "" || invoice.Status.State == facturnetesv2.Draft
+}
+
+// targetState returns the lifecycle state the invoice should be in, based on
+// the requested state, the received payments and the due date.
+func targetState(invoice *facturnetesv2.Invoice, now time.Time) facturnetesv2.State {
+	requested := invoice.Spec.State
+	if requested == "" {
+		requested = facturnetesv2.Draft
+	}
+	if paid := paymentState(invoice); paid != "" {
+		requested = paid
+	}
+
+	switch requested {
+	case facturnetesv2.Issued, facturnetesv2.Sent, facturnetesv2.PartiallyPaid:
+		if at, ok := overdueAt(invoice); ok && !now.Before(at) {
+			return facturnetesv2.Overdue
+		}
+	}
+
+	return requested
+}
+
+// overdueAt returns the time the invoice becomes overdue, the start of the
+// day after its due date in UTC. ok is false without a valid due date.
+func overdueAt(invoice *facturnetesv2.Invoice) (time.Time, bool) {
+	due, err := facturnetesv2.ParseDate(invoice.Spec.InvoiceData.DueDate)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return due.AddDate(0, 0, 1), true
+}
+
+// untilOverdue returns how long an unpaid invoice has until it becomes
+// overdue, or zero when it is not awaiting payment or is overdue already.
+func untilOverdue(invoice *facturnetesv2.Invoice, now time.Time) time.Duration {
+	switch invoice.Status.State {
+	case facturnetesv2.Issued, facturnetesv2.Sent, facturnetesv2.PartiallyPaid:
+	default:
+		return 0
+	}
+	at, ok := overdueAt(invoice)
+	if !ok || !now.Before(at) {
+		return 0
+	}
+	return at.Sub(now)
+}
+
+// setOverdueCondition tells whether the invoice is past its due date.
+func setOverdueCondition(invoice *facturnetesv2.Invoice) {
+	due := invoice.Spec.InvoiceData.DueDate
+	switch state := invoice.Status.State; {
+	case state == facturnetesv2.Overdue:
+		setCondition(invoice, facturnetesv2.ConditionOverdue, metav1.ConditionTrue, ReasonPastDue,
+			fmt.Sprintf("Invoice was due on %s", due))
+	case state.IsOpen():
+		setCondition(invoice, facturnetesv2.ConditionOverdue, metav1.ConditionFalse, ReasonNotDue,
+			fmt.Sprintf("Invoice is due on %s", due))
+	default:
+		if state == "" {
+			state = facturnetesv2.Draft
+		}
+		setCondition(invoice, facturnetesv2.ConditionOverdue, metav1.ConditionFalse, ReasonNotAwaitingPayment,
+			fmt.Sprintf("Invoice in state %s does not await payment", state))
+	}
+}
+
+// paymentState returns PartiallyPaid or Paid when payments were received for
+// an open invoice, based on its balance. An invoice settled by advances or
+// credit notes is Paid without payments, once it is issued. Paid is final, so
+// an invoice stays paid when a payment is withdrawn later.
+func paymentState(invoice *facturnetesv2.Invoice) facturnetesv2.State {
+	switch invoice.Spec.State {
+	case facturnetesv2.Issued, facturnetesv2.Sent, facturnetesv2.PartiallyPaid:
+	default:
+		return ""
+	}
+	if invoice.Status.State == facturnetesv2.Paid {
+		return facturnetesv2.Paid
+	}
+
+	balance := invoice.Status.Balance
+	if balance == nil || balance.Outstanding == "" {
+		return ""
+	}
+	outstanding, err := money.Parse(balance.Outstanding)
+	if err != nil {
+		return ""
+	}
+	if outstanding.Sign() <= 0 {
+		return facturnetesv2.Paid
+	}
+	if balance.Paid == "" {
+		return ""
+	}
+	paid, err := money.Parse(balance.Paid)
+	if err != nil || paid.Sign() <= 0 {
+		return ""
+	}
+	return facturnetesv2.PartiallyPaid
+}
+
+// advanceState moves the invoice to its target lifecycle state and records the
+// transition. An invoice without a state is in Draft. An invoice requested to
+// be issued passes Issued on its way out of Draft, so that it may be Sent, or
+// Paid or Overdue already. Transitions not allowed by the lifecycle are
+// rejected.
+func (r *InvoiceReconciler) advanceState(invoice *facturnetesv2.Invoice) error {
+	now := time.Now()
+	next := targetState(invoice, now)
+	if invoice.Status.State == next {
+		return nil
+	}
+
+	current := invoice.Status.State
+	if current == "" {
+		current = facturnetesv2.Draft
+	}
+	if current == facturnetesv2.Draft && next != facturnetesv2.Issued {
+		switch invoice.Spec.State {
+		case facturnetesv2.Issued, facturnetesv2.Sent, facturnetesv2.PartiallyPaid:
+			r.recordTransition(invoice, facturnetesv2.Issued, now)
+			current = facturnetesv2.Issued
+		}
+	}
+	if !current.CanTransitionTo(next) {
+		return fmt.Errorf("invalid state transition from %s to %s", current, next)
+	}
+	r.recordTransition(invoice, next, now)
+
+	return nil
+}
+
+// recordTransition moves the invoice to the next state and adds the
+// transition to its history.
+func (r *InvoiceReconciler) recordTransition(invoice *facturnetesv2.Invoice, next facturnetesv2.State, now time.Time) {
+	current := invoice.Status.State
+	r.log.Infow("Changing invoice state", "from", current, "to", next)
+	invoice.Status.State = next
+	invoice.Status.StateChangedTime = &metav1.Time{Time: now}
+	invoice.Status.History = append(invoice.Status.History, facturnetesv2.StateTransition{
+		From: current,
+		To:   next,
+		Time: metav1.Time{Time: now},
+	})
+}
+
+// announceOverdue emits the event and counts the invoice once the status
+// recording that it became overdue after the previous reconciliation, which
+// ended at processed, is stored.
+func (r *InvoiceReconciler) announceOverdue(invoice *facturnetesv2.Invoice, processed *metav1.Time) {
+	changed := invoice.Status.StateChangedTime
+	if invoice.Status.State != facturnetesv2.Overdue || changed == nil {
+		return
+	}
+	if processed != nil && !changed.After(processed.Time) {
+		return
+	}
+	r.recorder.Eventf(invoice, corev1.EventTypeWarning, ReasonPastDue,
+		"Invoice %s was due on %s and is not paid", invoice.Spec.InvoiceData.Number, invoice.Spec.InvoiceData.DueDate)
+	overdueInvoices.WithLabelValues(invoice.Namespace, invoice.Spec.InvoiceData.Currency).Inc()
+}
@@ -0,0 +1,61 @@
+package controllers
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"go.uber.org/zap"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	facturnetesv2 "github.com/cnvergence/facturnetes/api/v2"
+)
+
+var _ = Describe("Invoice lifecycle", func() {
+	var (
+		reconciler *InvoiceReconciler
+		invoice    *facturnetesv2.Invoice
+	)
+
+	BeforeEach(func() {
+		reconciler = &InvoiceReconciler{
+			recorder: record.NewFakeRecorder(10),
+			log:      zap.S(),
+		}
+		invoice = newInvoice("lifecycle")
+	})
+
+	It("issues a new invoice before it becomes overdue", func() {
+		invoice.Spec.State = facturnetesv2.Issued
+
+		Expect(reconciler.advanceState(invoice)).To(Succeed())
+		Expect(invoice.Status.State).To(Equal(facturnetesv2.Overdue))
+		Expect(invoice.Status.History).To(HaveLen(2))
+		Expect(invoice.Status.History[0].To).To(Equal(facturnetesv2.Issued))
+		Expect(invoice.Status.History[1].From).To(Equal(facturnetesv2.Issued))
+	})
+
+	It("does not move a new invoice straight to Paid", func() {
+		invoice.Spec.State = facturnetesv2.Paid
+
+		Expect(reconciler.advanceState(invoice)).NotTo(Succeed())
+		Expect(invoice.Status.State).To(BeEmpty())
+	})
+
+	It("announces an overdue invoice once its status is stored", func() {
+		invoice.Spec.State = facturnetesv2.Issued
+		reconciler.client = fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(invoice).Build()
+		events := reconciler.recorder.(*record.FakeRecorder).Events
+
+		Expect(reconciler.advanceState(invoice)).To(Succeed())
+		Expect(events).To(BeEmpty())
+
+		_, err := reconciler.SetSuccessStatus(ctx, invoice)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(events).To(Receive(ContainSubstring(ReasonPastDue)))
+
+		_, err = reconciler.SetSuccessStatus(ctx, invoice)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(events).To(BeEmpty())
+	})
+})
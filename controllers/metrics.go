@@ -0,0 +1,16 @@
+package controllers
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+// overdueInvoices counts the invoices that became overdue.
+var overdueInvoices = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Name: "facturnetes_invoices_overdue_total",
+	Help: "Number of invoices that became overdue",
+}, []string{"namespace", "currency"})
+
+func init() {
+	metrics.Registry.MustRegister(overdueInvoices)
+}
@@ -0,0 +1,153 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	facturnetesv2 "github.com/cnvergence/facturnetes/api/v2"
+	"github.com/cnvergence/facturnetes/pkg/sequence"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// documentType returns the document type of the invoice, Invoice by default.
+func documentType(invoice *facturnetesv2.Invoice) facturnetesv2.DocumentType {
+	if invoice.Spec.DocumentType == "" {
+		return facturnetesv2.DocumentInvoice
+	}
+	return invoice.Spec.DocumentType
+}
+
+// needsNumber tells whether the invoice is leaving Draft without a number.
+func needsNumber(invoice *facturnetesv2.Invoice, now time.Time) bool {
+	return invoice.Spec.InvoiceData.Number == "" && targetState(invoice, now) != facturnetesv2.Draft
+}
+
+// allocateNumber gives the invoice the next number of its InvoiceSequence,
+// unless the API server has it numbered already and the cache is stale.
+func (r *InvoiceReconciler) allocateNumber(ctx context.Context, invoice *facturnetesv2.Invoice) error {
+	latest := &facturnetesv2.Invoice{}
+	if err := r.apiReader.Get(ctx, client.ObjectKeyFromObject(invoice), latest); err != nil {
+		return err
+	}
+	if latest.Spec.InvoiceData.Number != "" {
+		return nil
+	}
+	issued, err := facturnetesv2.ParseDate(invoice.Spec.InvoiceData.IssueDate)
+	if err != nil {
+		return err
+	}
+	number, err := nextNumber(ctx, r.client, r.apiReader, invoice.Namespace, invoice.Spec.SequenceRef, documentType(invoice), issued)
+	if err != nil {
+		return err
+	}
+
+	r.log.Infow("Allocated invoice number", "number", number)
+	return setNumber(ctx, r.client, r.apiReader, invoice, &invoice.Spec.InvoiceData.Number, number)
+}
+
+// setNumber writes the number allocated for a document. The patch is made on
+// the resource version read, so a number stored meanwhile is never written
+// over. On a conflict the document is read again: it keeps the number it got
+// meanwhile, or the patch is retried so the allocated number is not lost to
+// another change.
+func setNumber(ctx context.Context, c client.Client, reader client.Reader, obj client.Object, field *string, number string) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		if *field != "" {
+			return nil
+		}
+		patch := client.MergeFromWithOptions(obj.DeepCopyObject().(client.Object), client.MergeFromWithOptimisticLock{})
+		*field = number
+		err := c.Patch(ctx, obj, patch)
+		if !apierrors.IsConflict(err) {
+			return err
+		}
+		*field = ""
+		if err := reader.Get(ctx, client.ObjectKeyFromObject(obj), obj); err != nil {
+			return err
+		}
+		return err
+	})
+}
+
+// nextNumber allocates the next number of the referenced or default
+// InvoiceSequence of the namespace. The counter lives in the sequence status
+// and is updated with optimistic concurrency, so two documents never get the
+// same number. A number allocated for a document deleted before the number
+// is written is skipped, never reused.
+func nextNumber(ctx context.Context, c client.Client, reader client.Reader, namespace string,
+	ref *corev1.LocalObjectReference, docType facturnetesv2.DocumentType, date time.Time) (string, error) {
+	var number string
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		seq, err := invoiceSequence(ctx, reader, namespace, ref, docType)
+		if err != nil {
+			return err
+		}
+		if number, err = sequence.Allocate(seq, date); err != nil {
+			return err
+		}
+		return c.Status().Update(ctx, seq)
+	})
+	return number, err
+}
+
+// invoiceSequence reads the InvoiceSequence from the API server, bypassing
+// the cache so the counter is never stale. Without a reference, the sequence
+// listing the document type is used, then the default sequence of the namespace.
+func invoiceSequence(ctx context.Context, reader client.Reader, namespace string,
+	ref *corev1.LocalObjectReference, docType facturnetesv2.DocumentType) (*facturnetesv2.InvoiceSequence, error) {
+	if ref != nil {
+		seq := &facturnetesv2.InvoiceSequence{}
+		if err := reader.Get(ctx, client.ObjectKey{Namespace: namespace, Name: ref.Name}, seq); err != nil {
+			return nil, fmt.Errorf("unable to get InvoiceSequence %s: %w", ref.Name, err)
+		}
+		return seq, nil
+	}
+
+	sequences := &facturnetesv2.InvoiceSequenceList{}
+	if err := reader.List(ctx, sequences, client.InNamespace(namespace)); err != nil {
+		return nil, err
+	}
+	var typed, generic, defaults []*facturnetesv2.InvoiceSequence
+	for i := range sequences.Items {
+		seq := &sequences.Items[i]
+		switch {
+		case listsType(seq, docType):
+			typed = append(typed, seq)
+		case len(seq.Spec.DocumentTypes) == 0:
+			generic = append(generic, seq)
+			if seq.Spec.Default {
+				defaults = append(defaults, seq)
+			}
+		}
+	}
+
+	for _, candidates := range [][]*facturnetesv2.InvoiceSequence{typed, defaults} {
+		switch len(candidates) {
+		case 0:
+			continue
+		case 1:
+			return candidates[0], nil
+		default:
+			return nil, fmt.Errorf("namespace %s has more than one InvoiceSequence for %s documents: %s, %s",
+				namespace, docType, candidates[0].Name, candidates[1].Name)
+		}
+	}
+	if len(generic) == 1 {
+		return generic[0], nil
+	}
+
+	return nil, fmt.Errorf("document has no number and namespace %s has no InvoiceSequence for %s documents", namespace, docType)
+}
+
+func listsType(seq *facturnetesv2.InvoiceSequence, docType facturnetesv2.DocumentType) bool {
+	for _, t := range seq.Spec.DocumentTypes {
+		if facturnetesv2.DocumentType(t) == docType {
+			return true
+		}
+	}
+	return false
+}
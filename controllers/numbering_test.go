@@ -0,0 +1,59 @@
+package controllers
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"go.uber.org/zap"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	facturnetesv2 "github.com/cnvergence/facturnetes/api/v2"
+)
+
+var _ = Describe("Invoice numbering", func() {
+	var (
+		invoice    *facturnetesv2.Invoice
+		stale      *facturnetesv2.Invoice
+		key        client.ObjectKey
+		c          client.Client
+		reconciler *InvoiceReconciler
+	)
+
+	BeforeEach(func() {
+		seq := &facturnetesv2.InvoiceSequence{
+			ObjectMeta: metav1.ObjectMeta{Name: "invoices", Namespace: "default"},
+			Spec:       facturnetesv2.InvoiceSequenceSpec{Default: true, Format: "FV/{YYYY}/{seq}"},
+		}
+		invoice = newInvoice("numbered")
+		invoice.Spec.InvoiceData.Number = ""
+		c = fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(seq, invoice).Build()
+		reconciler = &InvoiceReconciler{client: c, apiReader: c, log: zap.S()}
+
+		key = client.ObjectKeyFromObject(invoice)
+		stale = &facturnetesv2.Invoice{}
+		Expect(c.Get(ctx, key, stale)).To(Succeed())
+		Expect(c.Get(ctx, key, invoice)).To(Succeed())
+	})
+
+	It("keeps the allocated number when the invoice changed meanwhile", func() {
+		invoice.Spec.InvoiceData.Notes = "Paid by card"
+		Expect(c.Update(ctx, invoice)).To(Succeed())
+
+		Expect(reconciler.allocateNumber(ctx, stale)).To(Succeed())
+		Expect(c.Get(ctx, key, invoice)).To(Succeed())
+		Expect(invoice.Spec.InvoiceData.Number).To(Equal("FV/2022/1"))
+		Expect(invoice.Spec.InvoiceData.Notes).To(Equal("Paid by card"))
+	})
+
+	It("does not number an invoice numbered meanwhile", func() {
+		invoice.Spec.InvoiceData.Number = "FV/2022/7"
+		Expect(c.Update(ctx, invoice)).To(Succeed())
+
+		Expect(reconciler.allocateNumber(ctx, stale)).To(Succeed())
+		Expect(setNumber(ctx, c, c, stale, &stale.Spec.InvoiceData.Number, "FV/2022/8")).To(Succeed())
+		Expect(c.Get(ctx, key, invoice)).To(Succeed())
+		Expect(invoice.Spec.InvoiceData.Number).To(Equal("FV/2022/7"))
+	})
+})
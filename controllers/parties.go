@@ -0,0 +1,93 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	facturnetesv2 "github.com/cnvergence/facturnetes/api/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// Field indexes of the invoices referencing a Customer or a SellerProfile.
+const (
+	customerRefField = "spec.customerRef.name"
+	sellerRefField   = "spec.sellerRef.name"
+)
+
+func indexCustomerRef(obj client.Object) []string {
+	invoice := obj.(*facturnetesv2.Invoice)
+	if invoice.Spec.CustomerRef == nil {
+		return nil
+	}
+	return []string{invoice.Spec.CustomerRef.Name}
+}
+
+func indexSellerRef(obj client.Object) []string {
+	invoice := obj.(*facturnetesv2.Invoice)
+	if invoice.Spec.SellerRef == nil {
+		return nil
+	}
+	return []string{invoice.Spec.SellerRef.Name}
+}
+
+// invoicesReferencing returns a map function enqueuing the draft invoices
+// which reference the changed object through the given field index. Issued
+// invoices keep their frozen parties, so they are not re-rendered.
+func (r *InvoiceReconciler) invoicesReferencing(indexField string) func(client.Object) []reconcile.Request {
+	return func(obj client.Object) []reconcile.Request {
+		invoices := &facturnetesv2.InvoiceList{}
+		if err := r.client.List(context.Background(), invoices,
+			client.InNamespace(obj.GetNamespace()),
+			client.MatchingFields{indexField: obj.GetName()}); err != nil {
+			r.log.Errorw("Unable to list invoices", "field", indexField, "name", obj.GetName(), "error", err)
+			return nil
+		}
+
+		var requests []reconcile.Request
+		for _, invoice := range invoices.Items {
+			if invoice.Status.Parties != nil {
+				continue
+			}
+			requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&invoice)})
+		}
+		return requests
+	}
+}
+
+// resolveParties returns the seller and buyer printed on the invoice. Draft
+// invoices follow the referenced Customer and SellerProfile. Once the invoice
+// leaves Draft, the resolved parties are frozen in the status and reused.
+func (r *InvoiceReconciler) resolveParties(ctx context.Context, invoice *facturnetesv2.Invoice) (facturnetesv2.Company, error) {
+	draft := isDraft(invoice)
+	if !draft && invoice.Status.Parties != nil {
+		return *invoice.Status.Parties, nil
+	}
+
+	parties := invoice.Spec.InvoiceData.Company
+	if ref := invoice.Spec.CustomerRef; ref != nil {
+		customer := &facturnetesv2.Customer{}
+		key := client.ObjectKey{Namespace: invoice.Namespace, Name: ref.Name}
+		if err := r.client.Get(ctx, key, customer); err != nil {
+			return parties, fmt.Errorf("unable to get Customer %s: %w", ref.Name, err)
+		}
+		parties.Buyer = customer.Spec.Buyer
+	}
+	if ref := invoice.Spec.SellerRef; ref != nil {
+		profile := &facturnetesv2.SellerProfile{}
+		key := client.ObjectKey{Namespace: invoice.Namespace, Name: ref.Name}
+		if err := r.client.Get(ctx, key, profile); err != nil {
+			return parties, fmt.Errorf("unable to get SellerProfile %s: %w", ref.Name, err)
+		}
+		parties.Seller = profile.Spec.Seller
+	}
+
+	if draft {
+		invoice.Status.Parties = nil
+	} else {
+		frozen := parties
+		invoice.Status.Parties = &frozen
+	}
+
+	return parties, nil
+}
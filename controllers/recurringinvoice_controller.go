@@ -0,0 +1,154 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"time"
+
+	facturnetesv2 "github.com/cnvergence/facturnetes/api/v2"
+	"github.com/cnvergence/facturnetes/pkg/resource"
+	"github.com/cnvergence/facturnetes/pkg/schedule"
+	"go.uber.org/zap"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+)
+
+// defaultHistoryLimit is the number of created invoices listed in the status
+// of a RecurringInvoice without a history limit.
+const defaultHistoryLimit = 12
+
+// RecurringInvoiceReconciler creates the invoices of a RecurringInvoice on schedule.
+type RecurringInvoiceReconciler struct {
+	client client.Client
+	log    *zap.SugaredLogger
+	now    func() time.Time
+}
+
+func NewRecurringInvoiceReconciler(mgr manager.Manager) *RecurringInvoiceReconciler {
+	return &RecurringInvoiceReconciler{
+		client: mgr.GetClient(),
+		log:    zap.S(),
+		now:    time.Now,
+	}
+}
+
+// +kubebuilder:rbac:groups=facturnetes.cnvergence.io,resources=recurringinvoices,verbs=get;list;watch
+// +kubebuilder:rbac:groups=facturnetes.cnvergence.io,resources=recurringinvoices/status,verbs=get;update;patch
+// Reconcile creates the invoice of the latest scheduled run, if it was not
+// created yet, and requeues at the next scheduled run.
+func (r *RecurringInvoiceReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	log := r.log.With("RecurringInvoice", req.NamespacedName)
+
+	ri := &facturnetesv2.RecurringInvoice{}
+	if err := r.client.Get(ctx, req.NamespacedName, ri); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	sched, loc, err := schedule.Parse(ri.Spec.Schedule, ri.Spec.TimeZone)
+	if err != nil {
+		r.setCondition(ri, metav1.ConditionFalse, ReasonInvalid, err.Error())
+		ri.Status.NextScheduleTime = nil
+		return ctrl.Result{}, r.updateStatus(ctx, ri, err)
+	}
+
+	now := r.now()
+	last := ri.CreationTimestamp.Time
+	if ri.Status.LastScheduleTime != nil {
+		last = ri.Status.LastScheduleTime.Time
+	}
+	latest, next := schedule.Runs(sched, last, now)
+	ri.Status.NextScheduleTime = &metav1.Time{Time: next}
+	result := ctrl.Result{RequeueAfter: next.Sub(now)}
+
+	suspended := ri.Spec.Suspend != nil && *ri.Spec.Suspend
+	switch {
+	case suspended:
+		log.Debug("RecurringInvoice is suspended")
+		r.setCondition(ri, metav1.ConditionFalse, ReasonSuspended, "Creation of invoices is suspended")
+		// Resuming changes the generation and triggers the next reconciliation.
+		return ctrl.Result{}, r.updateStatus(ctx, ri, nil)
+	case latest.IsZero():
+		r.setCondition(ri, metav1.ConditionTrue, ReasonScheduled, "Waiting for the next scheduled run")
+		return result, r.updateStatus(ctx, ri, nil)
+	}
+
+	deadline := ri.Spec.StartingDeadlineSeconds
+	if deadline != nil && now.Sub(latest) > time.Duration(*deadline)*time.Second {
+		log.Infow("Missed the starting deadline of a run", "scheduled", latest)
+		ri.Status.LastScheduleTime = &metav1.Time{Time: latest}
+		return result, r.updateStatus(ctx, ri, nil)
+	}
+
+	invoice, err := resource.RecurringInvoice(ri, latest.In(loc))
+	if err != nil {
+		r.setCondition(ri, metav1.ConditionFalse, ReasonInvalid, err.Error())
+		return ctrl.Result{}, r.updateStatus(ctx, ri, err)
+	}
+	if err := r.client.Create(ctx, invoice); err != nil && !apierrors.IsAlreadyExists(err) {
+		log.Errorw("Unable to create the scheduled invoice", "invoice", invoice.Name, "error", err)
+		r.setCondition(ri, metav1.ConditionFalse, ReasonCreateFailed, err.Error())
+		return ctrl.Result{RequeueAfter: 15 * time.Second}, r.updateStatus(ctx, ri, err)
+	}
+	log.Infow("Created the scheduled invoice", "invoice", invoice.Name, "scheduled", latest)
+
+	ri.Status.LastScheduleTime = &metav1.Time{Time: latest}
+	ri.Status.History = append([]facturnetesv2.RecurringRun{{
+		ScheduledTime: metav1.Time{Time: latest},
+		Invoice:       invoice.Name,
+	}}, ri.Status.History...)
+	limit := defaultHistoryLimit
+	if ri.Spec.HistoryLimit != nil {
+		limit = int(*ri.Spec.HistoryLimit)
+	}
+	if len(ri.Status.History) > limit {
+		ri.Status.History = ri.Status.History[:limit]
+	}
+	r.setCondition(ri, metav1.ConditionTrue, ReasonScheduled, "Created invoice "+invoice.Name)
+
+	return result, r.updateStatus(ctx, ri, nil)
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *RecurringInvoiceReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&facturnetesv2.RecurringInvoice{}).
+		Complete(r)
+}
+
+func (r *RecurringInvoiceReconciler) setCondition(ri *facturnetesv2.RecurringInvoice, status metav1.ConditionStatus, reason, message string) {
+	meta.SetStatusCondition(&ri.Status.Conditions, metav1.Condition{
+		Type:               facturnetesv2.ConditionReady,
+		Status:             status,
+		ObservedGeneration: ri.Generation,
+		Reason:             reason,
+		Message:            message,
+	})
+}
+
+func (r *RecurringInvoiceReconciler) updateStatus(ctx context.Context, ri *facturnetesv2.RecurringInvoice, msg error) error {
+	ri.Status.ObservedGeneration = ri.Generation
+	ri.Status.Message = ""
+	if msg != nil {
+		ri.Status.Message = msg.Error()
+	}
+	return r.client.Status().Update(ctx, ri)
+}
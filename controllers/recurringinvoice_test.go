@@ -0,0 +1,69 @@
+package controllers
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"go.uber.org/zap"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	facturnetesv2 "github.com/cnvergence/facturnetes/api/v2"
+	"github.com/cnvergence/facturnetes/pkg/resource"
+)
+
+var _ = Describe("RecurringInvoice missed runs", func() {
+	var (
+		ri  *facturnetesv2.RecurringInvoice
+		now time.Time
+	)
+
+	BeforeEach(func() {
+		ri = &facturnetesv2.RecurringInvoice{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:              "monthly",
+				Namespace:         "default",
+				CreationTimestamp: metav1.NewTime(time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)),
+			},
+			Spec: facturnetesv2.RecurringInvoiceSpec{
+				Schedule: "0 6 1 * *",
+				TimeZone: "UTC",
+				Template: facturnetesv2.InvoiceTemplate{Spec: newInvoice("template").Spec},
+			},
+		}
+		now = time.Date(2022, 4, 15, 12, 0, 0, 0, time.UTC)
+	})
+
+	reconcile := func() client.Client {
+		c := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(ri).Build()
+		reconciler := &RecurringInvoiceReconciler{client: c, log: zap.S(), now: func() time.Time { return now }}
+		_, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(ri)})
+		Expect(err).NotTo(HaveOccurred())
+		return c
+	}
+
+	It("creates only the latest missed invoice without a starting deadline", func() {
+		c := reconcile()
+
+		invoices := &facturnetesv2.InvoiceList{}
+		Expect(c.List(ctx, invoices)).To(Succeed())
+		latest := time.Date(2022, 4, 1, 6, 0, 0, 0, time.UTC)
+		Expect(invoices.Items).To(ConsistOf(HaveField("Name", resource.RecurringInvoiceName(ri, latest))))
+		Expect(c.Get(ctx, client.ObjectKeyFromObject(ri), ri)).To(Succeed())
+		Expect(ri.Status.LastScheduleTime.Time).To(BeTemporally("==", latest))
+	})
+
+	It("skips a missed invoice past the starting deadline", func() {
+		deadline := int64(3600)
+		ri.Spec.StartingDeadlineSeconds = &deadline
+		c := reconcile()
+
+		invoices := &facturnetesv2.InvoiceList{}
+		Expect(c.List(ctx, invoices)).To(Succeed())
+		Expect(invoices.Items).To(BeEmpty())
+	})
+})
@@ -0,0 +1,87 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"time"
+
+	facturnetesv2 "github.com/cnvergence/facturnetes/api/v2"
+	"github.com/cnvergence/facturnetes/pkg/document"
+	"github.com/cnvergence/facturnetes/pkg/einvoice"
+	"github.com/cnvergence/facturnetes/pkg/money"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// checkRules checks the rendered invoice against the EN 16931 business rules
+// and records the rules it breaks in the status of the invoice. It returns
+// the number of broken rules.
+func (r *InvoiceReconciler) checkRules(invoice *facturnetesv2.Invoice, rendered facturnetesv2.Invoice, totals *money.Totals, advances []document.Advance) (int, error) {
+	inv, err := einvoice.New(&rendered, totals, advances...)
+	if err != nil {
+		return 0, err
+	}
+	violations := inv.Validate()
+
+	invoice.Status.RuleViolations = nil
+	for _, v := range violations {
+		invoice.Status.RuleViolations = append(invoice.Status.RuleViolations, facturnetesv2.RuleViolation{Rule: v.Rule, Message: v.Message})
+	}
+	if len(violations) == 0 {
+		setCondition(invoice, facturnetesv2.ConditionCompliant, metav1.ConditionTrue, ReasonCompliant, "Invoice keeps the EN 16931 business rules")
+		return 0, nil
+	}
+	message := fmt.Sprintf("Invoice breaks %d EN 16931 business rules, first %s", len(violations), violations[0])
+	if len(violations) == 1 {
+		message = fmt.Sprintf("Invoice breaks the EN 16931 business rule %s", violations[0])
+	}
+	setCondition(invoice, facturnetesv2.ConditionCompliant, metav1.ConditionFalse, ReasonRulesViolated, message)
+	return len(violations), nil
+}
+
+// holdIssuance keeps a Draft invoice in strict rule mode from being issued
+// while it breaks EN 16931 business rules, or while the rules cannot be
+// checked because its parties, totals or advances cannot be resolved. The
+// warning event is only emitted when the broken rules change, not on every
+// retry.
+func (r *InvoiceReconciler) holdIssuance(ctx context.Context, invoice *facturnetesv2.Invoice, now time.Time) error {
+	if invoice.Spec.InvoiceData.Options.Rules != facturnetesv2.RulesStrict ||
+		!isDraft(invoice) || targetState(invoice, now) == facturnetesv2.Draft {
+		return nil
+	}
+	parties, err := r.resolveParties(ctx, invoice)
+	if err != nil {
+		return unchecked(err)
+	}
+	totals, err := money.Compute(&invoice.Spec.InvoiceData)
+	if err != nil {
+		return unchecked(err)
+	}
+	advances, err := r.settleAdvances(ctx, invoice)
+	if err != nil {
+		return unchecked(err)
+	}
+
+	rendered := *invoice
+	rendered.Spec.InvoiceData.Company = parties
+	reported := invoice.Status.RuleViolations
+	broken, err := r.checkRules(invoice, rendered, totals, advances)
+	if err != nil {
+		return unchecked(err)
+	}
+	if broken == 0 {
+		return nil
+	}
+	if !reflect.DeepEqual(reported, invoice.Status.RuleViolations) {
+		r.recorder.Eventf(invoice, corev1.EventTypeWarning, ReasonRulesViolated,
+			"Invoice is kept in Draft, it breaks %d EN 16931 business rules", broken)
+	}
+	return fmt.Errorf("invoice breaks %d EN 16931 business rules and is kept in Draft", broken)
+}
+
+// unchecked is the error keeping a strict invoice in Draft when its rules
+// cannot be checked.
+func unchecked(err error) error {
+	return fmt.Errorf("EN 16931 business rules cannot be checked, the invoice is kept in Draft: %w", err)
+}
@@ -0,0 +1,87 @@
+package controllers
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"go.uber.org/zap"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/client-go/tools/record"
+
+	facturnetesv2 "github.com/cnvergence/facturnetes/api/v2"
+	"github.com/cnvergence/facturnetes/pkg/money"
+)
+
+var _ = Describe("EN 16931 business rules", func() {
+	var (
+		reconciler *InvoiceReconciler
+		invoice    *facturnetesv2.Invoice
+	)
+
+	BeforeEach(func() {
+		reconciler = &InvoiceReconciler{
+			recorder: record.NewFakeRecorder(10),
+			log:      zap.S(),
+		}
+
+		invoice = newInvoice("rules")
+		invoice.Spec.State = facturnetesv2.Issued
+		invoice.Spec.InvoiceData.Options.Rules = facturnetesv2.RulesStrict
+		invoice.Spec.InvoiceData.Company.Buyer.CountryCode = "PL"
+		invoice.Spec.InvoiceData.Company.Buyer.VAT = "PL1111111111"
+		invoice.Spec.InvoiceData.Company.Seller.CountryCode = "PL"
+		invoice.Spec.InvoiceData.Company.Seller.VAT = "PL2222222222"
+	})
+
+	It("issues a strict invoice keeping the rules", func() {
+		Expect(reconciler.holdIssuance(ctx, invoice, time.Now())).To(Succeed())
+		Expect(invoice.Status.RuleViolations).To(BeEmpty())
+		Expect(meta.IsStatusConditionTrue(invoice.Status.Conditions, facturnetesv2.ConditionCompliant)).To(BeTrue())
+	})
+
+	It("keeps a strict invoice breaking the rules in Draft", func() {
+		invoice.Spec.InvoiceData.Company.Seller.VAT = "2222222222"
+		invoice.Spec.InvoiceData.DueDate = ""
+
+		Expect(reconciler.holdIssuance(ctx, invoice, time.Now())).NotTo(Succeed())
+		Expect(invoice.Status.RuleViolations).To(ConsistOf(
+			HaveField("Rule", "BR-CO-09"),
+			HaveField("Rule", "BR-CO-25"),
+		))
+		Expect(meta.IsStatusConditionFalse(invoice.Status.Conditions, facturnetesv2.ConditionCompliant)).To(BeTrue())
+	})
+
+	It("keeps a strict invoice in Draft when its rules cannot be checked", func() {
+		invoice.Spec.InvoiceData.Items[0].Quantity = "many"
+
+		Expect(reconciler.holdIssuance(ctx, invoice, time.Now())).NotTo(Succeed())
+		Expect(isDraft(invoice)).To(BeTrue())
+	})
+
+	It("warns once while a strict invoice keeps breaking the same rules", func() {
+		invoice.Spec.InvoiceData.Company.Seller.VAT = "2222222222"
+		recorder := reconciler.recorder.(*record.FakeRecorder)
+
+		Expect(reconciler.holdIssuance(ctx, invoice, time.Now())).NotTo(Succeed())
+		Expect(reconciler.holdIssuance(ctx, invoice, time.Now())).NotTo(Succeed())
+		Expect(recorder.Events).To(HaveLen(1))
+	})
+
+	It("records the broken rules but issues the invoice in Report mode", func() {
+		invoice.Spec.InvoiceData.Options.Rules = facturnetesv2.RulesReport
+		invoice.Spec.InvoiceData.Company.Seller.VAT = "2222222222"
+
+		Expect(reconciler.holdIssuance(ctx, invoice, time.Now())).To(Succeed())
+		Expect(reconciler.advanceState(invoice)).To(Succeed())
+		Expect(isDraft(invoice)).To(BeFalse())
+
+		totals, err := money.Compute(&invoice.Spec.InvoiceData)
+		Expect(err).NotTo(HaveOccurred())
+		broken, err := reconciler.checkRules(invoice, *invoice, totals, nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(broken).To(Equal(1))
+		Expect(invoice.Status.RuleViolations).To(ConsistOf(HaveField("Rule", "BR-CO-09")))
+		Expect(meta.IsStatusConditionFalse(invoice.Status.Conditions, facturnetesv2.ConditionCompliant)).To(BeTrue())
+	})
+})
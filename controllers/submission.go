@@ -0,0 +1,145 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	facturnetesv2 "github.com/cnvergence/facturnetes/api/v2"
+	"github.com/cnvergence/facturnetes/pkg/ksef"
+	"github.com/cnvergence/facturnetes/pkg/resource"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// clearancePollInterval is how often the status of a pending submission is
+// polled.
+const clearancePollInterval = 10 * time.Second
+
+// Submitter sends issued invoices to an e-invoicing clearance system, such
+// as KSeF, SDI or a Peppol access point.
+type Submitter interface {
+	// System is the name of the clearance system recorded in the status.
+	System() string
+	// DocumentKey is the key of the document submitted in the invoice Secret.
+	// Invoices without this document are not submitted.
+	DocumentKey() string
+	// Submit sends the document of the invoice issued by the parties and
+	// returns the reference of the submission.
+	Submit(ctx context.Context, parties facturnetesv2.Company, document []byte) (string, error)
+	// Poll returns the outcome of the submission with the reference.
+	Poll(ctx context.Context, parties facturnetesv2.Company, reference string) (*Clearance, error)
+}
+
+// Clearance is the outcome of a submission.
+type Clearance struct {
+	// Pending is true while the clearance system processes the invoice.
+	Pending bool
+	// ID is the identifier the clearance system assigned to the accepted invoice.
+	ID string
+	// Receipt is the acknowledgement of receipt of the accepted invoice.
+	Receipt []byte
+	// Rejection is the reason the clearance system rejected the invoice.
+	Rejection string
+}
+
+// ksefSubmitter submits the KSeF FA(2) invoices of the sellers with a NIP.
+type ksefSubmitter struct {
+	client *ksef.Client
+}
+
+// NewKSeFSubmitter returns a Submitter sending invoices to KSeF through the client.
+func NewKSeFSubmitter(client *ksef.Client) Submitter {
+	return &ksefSubmitter{client: client}
+}
+
+func (s *ksefSubmitter) System() string {
+	return "KSeF"
+}
+
+func (s *ksefSubmitter) DocumentKey() string {
+	return resource.KSeFKey
+}
+
+func (s *ksefSubmitter) Submit(ctx context.Context, parties facturnetesv2.Company, document []byte) (string, error) {
+	return s.client.Send(ctx, parties.Seller.NIP, document)
+}
+
+func (s *ksefSubmitter) Poll(ctx context.Context, parties facturnetesv2.Company, reference string) (*Clearance, error) {
+	status, err := s.client.Status(ctx, parties.Seller.NIP, reference)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case status.Pending():
+		return &Clearance{Pending: true}, nil
+	case status.Rejected():
+		return &Clearance{Rejection: fmt.Sprintf("%d %s", status.Code, status.Description)}, nil
+	}
+	return &Clearance{ID: status.KSeFNumber, Receipt: status.UPO}, nil
+}
+
+// submit sends the issued invoice to the clearance system once and polls the
+// submission until it is accepted or rejected. Invoices still in Draft or
+// cancelled before they were submitted are not sent.
+func (r *InvoiceReconciler) submit(ctx context.Context, invoice *facturnetesv2.Invoice, parties facturnetesv2.Company, documents map[string][]byte, now time.Time) error {
+	if r.Submitter == nil {
+		return nil
+	}
+	status := invoice.Status.Clearance
+	if status == nil {
+		document := documents[r.Submitter.DocumentKey()]
+		if document == nil || invoice.Status.State == facturnetesv2.Draft || invoice.Status.State == facturnetesv2.Cancelled {
+			return nil
+		}
+		reference, err := r.Submitter.Submit(ctx, parties, document)
+		if err != nil {
+			return err
+		}
+		r.log.Infow("Submitted invoice", "system", r.Submitter.System(), "reference", reference)
+		invoice.Status.Clearance = &facturnetesv2.ClearanceStatus{
+			System:        r.Submitter.System(),
+			Reference:     reference,
+			Phase:         facturnetesv2.ClearancePending,
+			SubmittedTime: &metav1.Time{Time: now},
+		}
+		setCondition(invoice, facturnetesv2.ConditionCleared, metav1.ConditionFalse, ReasonSubmitted,
+			fmt.Sprintf("Submitted to %s as %s", r.Submitter.System(), reference))
+		return nil
+	}
+	if status.Phase != facturnetesv2.ClearancePending || status.System != r.Submitter.System() {
+		return nil
+	}
+
+	clearance, err := r.Submitter.Poll(ctx, parties, status.Reference)
+	if err != nil {
+		return err
+	}
+	switch {
+	case clearance.Pending:
+		return nil
+	case clearance.Rejection != "":
+		status.Phase = facturnetesv2.ClearanceRejected
+		status.Message = clearance.Rejection
+		r.recorder.Eventf(invoice, corev1.EventTypeWarning, ReasonRejected, "%s rejected the invoice: %s", status.System, clearance.Rejection)
+		setCondition(invoice, facturnetesv2.ConditionCleared, metav1.ConditionFalse, ReasonRejected, clearance.Rejection)
+	default:
+		status.Phase = facturnetesv2.ClearanceAccepted
+		status.ID = clearance.ID
+		status.Receipt = string(clearance.Receipt)
+		status.ClearedTime = &metav1.Time{Time: now}
+		r.recorder.Eventf(invoice, corev1.EventTypeNormal, ReasonCleared, "%s accepted the invoice as %s", status.System, clearance.ID)
+		setCondition(invoice, facturnetesv2.ConditionCleared, metav1.ConditionTrue, ReasonCleared,
+			fmt.Sprintf("Accepted by %s as %s", status.System, clearance.ID))
+	}
+	return nil
+}
+
+// untilNextPoll returns how long a pending submission waits for its next
+// poll, or zero when there is nothing to poll.
+func untilNextPoll(invoice *facturnetesv2.Invoice) time.Duration {
+	if c := invoice.Status.Clearance; c != nil && c.Phase == facturnetesv2.ClearancePending {
+		return clearancePollInterval
+	}
+	return 0
+}
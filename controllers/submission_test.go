@@ -0,0 +1,76 @@
+package controllers
+
+import (
+	"net/http/httptest"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"go.uber.org/zap"
+	"k8s.io/client-go/tools/record"
+
+	facturnetesv2 "github.com/cnvergence/facturnetes/api/v2"
+	"github.com/cnvergence/facturnetes/pkg/einvoice"
+	"github.com/cnvergence/facturnetes/pkg/ksef"
+	"github.com/cnvergence/facturnetes/pkg/money"
+	"github.com/cnvergence/facturnetes/pkg/resource"
+)
+
+var _ = Describe("Invoice submission", func() {
+	var (
+		server     *httptest.Server
+		reconciler *InvoiceReconciler
+		invoice    *facturnetesv2.Invoice
+		documents  map[string][]byte
+	)
+
+	BeforeEach(func() {
+		mock := ksef.NewMock("token")
+		server = httptest.NewServer(mock)
+		reconciler = &InvoiceReconciler{
+			recorder:  record.NewFakeRecorder(10),
+			log:       zap.S(),
+			Submitter: NewKSeFSubmitter(ksef.NewClient(server.URL, "token", mock.PublicKey())),
+		}
+
+		invoice = newInvoice("submitted")
+		invoice.Spec.InvoiceData.Company.Seller.NIP = "2222222222"
+		invoice.Status.State = facturnetesv2.Issued
+		totals, err := money.Compute(&invoice.Spec.InvoiceData)
+		Expect(err).NotTo(HaveOccurred())
+		inv, err := einvoice.New(invoice, totals)
+		Expect(err).NotTo(HaveOccurred())
+		document, err := inv.KSeF(invoice.Spec.DocumentType)
+		Expect(err).NotTo(HaveOccurred())
+		documents = map[string][]byte{resource.KSeFKey: document}
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("records the KSeF number and the UPO of the accepted invoice", func() {
+		parties := invoice.Spec.InvoiceData.Company
+		Expect(reconciler.submit(ctx, invoice, parties, documents, time.Now())).To(Succeed())
+		Expect(invoice.Status.Clearance).NotTo(BeNil())
+		Expect(invoice.Status.Clearance.Phase).To(Equal(facturnetesv2.ClearancePending))
+		Expect(untilNextPoll(invoice)).To(Equal(clearancePollInterval))
+
+		Expect(reconciler.submit(ctx, invoice, parties, documents, time.Now())).To(Succeed())
+		Expect(invoice.Status.Clearance.Phase).To(Equal(facturnetesv2.ClearancePending))
+		Expect(reconciler.submit(ctx, invoice, parties, documents, time.Now())).To(Succeed())
+
+		clearance := invoice.Status.Clearance
+		Expect(clearance.Phase).To(Equal(facturnetesv2.ClearanceAccepted))
+		Expect(clearance.System).To(Equal("KSeF"))
+		Expect(clearance.ID).To(HavePrefix("2222222222-"))
+		Expect(clearance.Receipt).To(ContainSubstring(clearance.ID))
+		Expect(untilNextPoll(invoice)).To(BeZero())
+	})
+
+	It("does not submit a draft", func() {
+		invoice.Status.State = facturnetesv2.Draft
+		Expect(reconciler.submit(ctx, invoice, invoice.Spec.InvoiceData.Company, documents, time.Now())).To(Succeed())
+		Expect(invoice.Status.Clearance).To(BeNil())
+	})
+})
@@ -0,0 +1,30 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	facturnetesv2 "github.com/cnvergence/facturnetes/api/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// validateInvoice checks the invoice with the rules of the validating webhook,
+// in case the webhook is disabled.
+func validateInvoice(invoice *facturnetesv2.Invoice) error {
+	return facturnetesv2.ValidateInvoice(invoice).ToAggregate()
+}
+
+// checkDuplicate refuses an invoice whose number is already used by an older
+// invoice of the same seller.
+func (r *InvoiceReconciler) checkDuplicate(ctx context.Context, invoice *facturnetesv2.Invoice) error {
+	number := invoice.Spec.InvoiceData.Number
+	duplicate, err := facturnetesv2.FindDuplicate(ctx, r.client, invoice,
+		client.MatchingFields{facturnetesv2.InvoiceNumberField: number})
+	if err != nil {
+		return err
+	}
+	if duplicate != nil {
+		return fmt.Errorf("invoice number %s is already used by invoice %s", number, duplicate.Name)
+	}
+	return nil
+}
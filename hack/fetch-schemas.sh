@@ -0,0 +1,38 @@
+#!/usr/bin/env bash
+# Replaces the bundled schemas of the structured invoices with the official
+# schemas, fetched with every schema they import. The imports are rewritten to
+# the local copies so the invoices validate without network access. Commit the
+# result, the build and the tests never fetch.
+set -euo pipefail
+
+dir=${1:-pkg/einvoice/schema}
+tmp=$(mktemp -d)
+trap 'rm -rf "$tmp"' EXIT
+
+# fetch <subdirectory> <url> downloads a schema and, recursively, its imports.
+fetch() {
+	local sub=$1 url=$2
+	local file=$tmp/$sub/${url##*/}
+	if [ -s "$file" ]; then
+		return
+	fi
+	mkdir -p "$tmp/$sub"
+	echo "fetching $url"
+	curl -fsSL -o "$file" "$url"
+
+	local location
+	for location in $(grep -o 'schemaLocation="[^"]*"' "$file" | sed 's/^schemaLocation="//; s/"$//' | sort -u); do
+		case $location in
+		http://* | https://*) fetch "$sub" "$location" ;;
+		*) fetch "$sub" "${url%/*}/$location" ;;
+		esac
+		sed -i "s|schemaLocation=\"$location\"|schemaLocation=\"${location##*/}\"|" "$file"
+	done
+}
+
+fetch fa2 http://crd.gov.pl/wzor/2023/06/29/12648/schemat.xsd
+
+# FatturaPA imports the XML signature schema of the W3C.
+fetch fatturapa https://www.fatturapa.gov.it/export/documenti/fatturapa/v1.2.2/Schema_del_file_xml_FatturaPA_v1.2.2.xsd
+
+cp -R "$tmp"/. "$dir"/
@@ -17,14 +17,21 @@ limitations under the License.
 package main
 
 import (
+	// Embed the time zone database for the schedules of recurring invoices.
+	_ "time/tzdata"
+
 	"flag"
+	"os"
 
 	// Import all Kubernetes client auth plugins (e.g. Azure, GCP, OIDC, etc.)
 	// to ensure that exec-entrypoint and run can make use of them.
 	_ "k8s.io/client-go/plugin/pkg/client/auth"
 
 	facturnetesv1 "github.com/cnvergence/facturnetes/api/v1"
+	facturnetesv2 "github.com/cnvergence/facturnetes/api/v2"
 	"github.com/cnvergence/facturnetes/controllers"
+	"github.com/cnvergence/facturnetes/pkg/einvoice"
+	"github.com/cnvergence/facturnetes/pkg/ksef"
 	"k8s.io/apimachinery/pkg/runtime"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
@@ -42,6 +49,7 @@ func init() {
 	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
 
 	utilruntime.Must(facturnetesv1.AddToScheme(scheme))
+	utilruntime.Must(facturnetesv2.AddToScheme(scheme))
 	//+kubebuilder:scaffold:scheme
 }
 
@@ -50,11 +58,21 @@ func main() {
 	var metricsAddr string
 	var enableLeaderElection bool
 	var probeAddr string
+	var ksefURL string
+	var ksefPublicKey string
+	var validateSchemas bool
 	flag.StringVar(&metricsAddr, "metrics-bind-address", ":8080", "The address the metric endpoint binds to.")
 	flag.StringVar(&probeAddr, "health-probe-bind-address", ":8081", "The address the probe endpoint binds to.")
 	flag.BoolVar(&enableLeaderElection, "leader-elect", false,
 		"Enable leader election for controller manager. "+
 			"Enabling this will ensure there is only one active controller manager.")
+	flag.StringVar(&ksefURL, "ksef-url", "",
+		"The URL of the KSeF environment the issued invoices are submitted to, with the token in KSEF_TOKEN. "+
+			"Invoices are not submitted when empty.")
+	flag.StringVar(&ksefPublicKey, "ksef-public-key", "",
+		"The PEM file with the public key of the Ministry of Finance for the KSeF environment, encrypting the token.")
+	flag.BoolVar(&validateSchemas, "validate-schemas", false,
+		"Validate the structured invoices against the bundled schemas before they are stored. Requires xmllint.")
 	opts := zap.Options{
 		Development: true,
 	}
@@ -75,9 +93,45 @@ func main() {
 		setupLog.Sugar().Fatalf("unable to start manager: &v", err)
 	}
 
-	if err = controllers.NewReconciler(mgr).SetupWithManager(mgr); err != nil {
+	reconciler := controllers.NewReconciler(mgr)
+	ksefToken := os.Getenv("KSEF_TOKEN")
+	if ksefURL != "" {
+		data, err := os.ReadFile(ksefPublicKey)
+		if err != nil {
+			setupLog.Sugar().Fatalf("unable to read the KSeF public key: %v", err)
+		}
+		ksefKey, err := ksef.ParsePublicKey(data)
+		if err != nil {
+			setupLog.Sugar().Fatalf("unable to parse the KSeF public key: %v", err)
+		}
+		reconciler.Submitter = controllers.NewKSeFSubmitter(ksef.NewClient(ksefURL, ksefToken, ksefKey))
+	}
+	if validateSchemas {
+		reconciler.Schemas, err = einvoice.NewSchemaValidator()
+		if err != nil {
+			setupLog.Sugar().Fatalf("unable to validate the structured invoices: %v", err)
+		}
+	}
+	if err = reconciler.SetupWithManager(mgr); err != nil {
 		setupLog.Sugar().Fatalf("unable to create Invoice controller: %v", err)
 	}
+	if err = controllers.NewSequenceReconciler(mgr).SetupWithManager(mgr); err != nil {
+		setupLog.Sugar().Fatalf("unable to create InvoiceSequence controller: %v", err)
+	}
+	if err = controllers.NewCreditNoteReconciler(mgr).SetupWithManager(mgr); err != nil {
+		setupLog.Sugar().Fatalf("unable to create CreditNote controller: %v", err)
+	}
+	if err = controllers.NewRecurringInvoiceReconciler(mgr).SetupWithManager(mgr); err != nil {
+		setupLog.Sugar().Fatalf("unable to create RecurringInvoice controller: %v", err)
+	}
+	if err = controllers.NewInterestNoteReconciler(mgr).SetupWithManager(mgr); err != nil {
+		setupLog.Sugar().Fatalf("unable to create InterestNote controller: %v", err)
+	}
+	if os.Getenv("ENABLE_WEBHOOKS") != "false" {
+		if err = (&facturnetesv2.Invoice{}).SetupWebhookWithManager(mgr); err != nil {
+			setupLog.Sugar().Fatalf("unable to create Invoice webhook: %v", err)
+		}
+	}
 	//+kubebuilder:scaffold:builder
 
 	if err := mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {
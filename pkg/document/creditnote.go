@@ -0,0 +1,80 @@
+package document
+
+import (
+	"fmt"
+	"strconv"
+
+	facturnetesv2 "github.com/cnvergence/facturnetes/api/v2"
+	"github.com/cnvergence/facturnetes/pkg/money"
+)
+
+// CreditNote prepares the printable document of a credit note. Every
+// corrected line is printed as it was invoiced and as it was corrected,
+// followed by the difference to the invoice totals.
+func CreditNote(note *facturnetesv2.CreditNote, invoice *facturnetesv2.Invoice, before, after *money.Totals) *Document {
+	data := invoice.Spec.InvoiceData
+	corrected := note.Spec.Apply(&data)
+	doc := &Document{
+		Title:  facturnetesv2.DocumentCreditNote.Title(),
+		Number: note.Spec.Number,
+		Dates: []Field{
+			{"Date of issue", note.Spec.IssueDate},
+			{"Corrected invoice", data.Number},
+			{"Invoice date of issue", data.IssueDate},
+		},
+		Seller:    sellerParty(data.Company.Seller),
+		Buyer:     buyerParty(data.Company.Buyer),
+		Bank:      Bank(data.Bank),
+		Notes:     "Reason of correction: " + note.Spec.Reason,
+		Signature: data.Signature,
+		Font:      data.Options.FontFamily,
+		Created:   issued(note.Spec.IssueDate),
+	}
+
+	doc.Table = Table{
+		Header:    []string{"No", "Description", "Quantity", "Unit net price", "VAT rate", "VAT amount", "Total gross price"},
+		GridSizes: invoiceGridSizes,
+	}
+	row := func(no, label string, item *facturnetesv2.Item, line money.LineTotal) []string {
+		return []string{
+			no,
+			fmt.Sprintf("%s (%s)", item.Description, label),
+			string(item.Quantity),
+			string(item.UnitPrice),
+			string(item.VATRate) + "%",
+			money.Format(line.VAT, before.Scale),
+			money.Format(line.Gross, before.Scale),
+		}
+	}
+	var invoiced []*facturnetesv2.Item
+	for _, item := range data.Items {
+		if item != nil {
+			invoiced = append(invoiced, item)
+		}
+	}
+	for i, item := range corrected.Items {
+		no := strconv.Itoa(i + 1)
+		if i >= len(invoiced) {
+			doc.Table.Rows = append(doc.Table.Rows, row(no, "added", item, after.Lines[i]))
+			continue
+		}
+		if *invoiced[i] == *item {
+			continue
+		}
+		doc.Table.Rows = append(doc.Table.Rows,
+			row(no, "before", invoiced[i], before.Lines[i]),
+			row("", "after", item, after.Lines[i]))
+	}
+
+	diff := money.Subtract(after, before)
+	doc.Summary = append(doc.Summary,
+		Field{"Net total before", amount(before.Net, before.Scale, data.Currency)},
+		Field{"Net total after", amount(after.Net, after.Scale, data.Currency)},
+	)
+	for _, rate := range diff.VAT {
+		doc.Summary = append(doc.Summary, Field{fmt.Sprintf("VAT %s%% difference", rate.Rate), amount(rate.VAT, diff.Scale, data.Currency)})
+	}
+	doc.Summary = append(doc.Summary, Field{"Total difference", amount(diff.Gross, diff.Scale, data.Currency)})
+
+	return doc
+}
@@ -0,0 +1,437 @@
+// Package document renders invoices and related business documents as PDF.
+package document
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	facturnetesv2 "github.com/cnvergence/facturnetes/api/v2"
+	"github.com/flopp/go-findfont"
+	"github.com/johnfercher/maroto/pkg/color"
+	"github.com/johnfercher/maroto/pkg/consts"
+	"github.com/johnfercher/maroto/pkg/pdf"
+	"github.com/johnfercher/maroto/pkg/props"
+	"github.com/jung-kurt/gofpdf"
+)
+
+// Party is the seller or the buyer printed on a document.
+type Party struct {
+	Name    string
+	Address string
+	VAT     string
+}
+
+// Bank details printed on a document.
+type Bank struct {
+	AccountNumber string
+	Swift         string
+}
+
+// Field is a labelled value.
+type Field struct {
+	Label string
+	Value string
+}
+
+// Table is a list of document lines.
+type Table struct {
+	Header []string
+	Rows   [][]string
+	// GridSizes of the columns, summing up to 12.
+	GridSizes []uint
+}
+
+// Document is a printable business document.
+type Document struct {
+	Title     string
+	Number    string
+	Dates     []Field
+	Seller    Party
+	Buyer     Party
+	Bank      Bank
+	Table     Table
+	Summary   []Field
+	Notes     string
+	Signature string
+	Font      string
+	// Stamp is printed across the header, e.g. PAID.
+	Stamp string
+	// Created is the creation date recorded in the PDF, so a document
+	// rendered again from the same data has the same bytes.
+	Created time.Time
+
+	pdf pdf.Maroto
+}
+
+// Render returns the document as PDF bytes.
+func (d *Document) Render() ([]byte, error) {
+	d.pdf = newMaroto(d.Created)
+	d.pdf.SetFirstPageNb(1)
+	d.pdf.SetPageMargins(10, 15, 10)
+	if err := d.setFonts(); err != nil {
+		return nil, fmt.Errorf("could not configure fonts: %s", err)
+	}
+
+	d.buildHeader()
+	d.buildFooter()
+	d.buildCompanyDetails()
+	d.buildBankDetails()
+	d.buildTable()
+	d.buildSummary()
+	d.buildSignature()
+
+	_, height := d.pdf.GetPageSize()
+	current := d.pdf.GetCurrentOffset()
+	if filler := height - current - 60; filler > 0 {
+		d.pdf.Row(filler, func() {})
+	}
+
+	bytes, err := d.pdf.Output()
+	if err != nil {
+		return nil, fmt.Errorf("could not save document to bytes: %s", err)
+	}
+	return bytes.Bytes(), nil
+}
+
+// gofpdfDefaults guards the package defaults of gofpdf newMaroto sets.
+var gofpdfDefaults sync.Mutex
+
+// newMaroto returns an A4 PDF with the given creation and modification date
+// and sorted resource catalogs, which gofpdf only takes from its package
+// defaults when the PDF is created.
+func newMaroto(created time.Time) pdf.Maroto {
+	gofpdfDefaults.Lock()
+	defer gofpdfDefaults.Unlock()
+	gofpdf.SetDefaultCatalogSort(true)
+	gofpdf.SetDefaultCreationDate(created)
+	gofpdf.SetDefaultModificationDate(created)
+	return pdf.NewMaroto(consts.Portrait, consts.A4)
+}
+
+// issued returns the date of issue of a document as its creation date, the
+// zero time when it cannot be parsed.
+func issued(date string) time.Time {
+	t, _ := time.Parse(facturnetesv2.DateLayout, date)
+	return t
+}
+
+func tealColor() color.Color {
+	return color.Color{Red: 3, Green: 166, Blue: 166}
+}
+
+func stampColor() color.Color {
+	return color.Color{Red: 200, Green: 30, Blue: 30}
+}
+
+func grayColor() color.Color {
+	return color.Color{Red: 200, Green: 200, Blue: 200}
+}
+
+func (d *Document) setFonts() error {
+	if d.Font == "" {
+		return nil
+	}
+	fontPath, err := findfont.Find(d.Font)
+	if err != nil {
+		return fmt.Errorf("could not find font %s installed: %s", d.Font, err)
+	}
+	d.pdf.SetFontLocation(filepath.Dir(fontPath))
+	for _, font := range findfont.List() {
+		if !strings.Contains(font, d.Font) {
+			continue
+		}
+		name := filepath.Base(font)
+		switch {
+		case strings.Contains(font, "BoldItalic") || strings.Contains(font, "Bold Italic"):
+			d.pdf.AddUTF8Font(d.Font, consts.BoldItalic, name)
+		case strings.Contains(font, "Bold"):
+			d.pdf.AddUTF8Font(d.Font, consts.Bold, name)
+		case strings.Contains(font, "Italic"):
+			d.pdf.AddUTF8Font(d.Font, consts.Italic, name)
+		case strings.Contains(font, "Regular") || strings.EqualFold(font, d.Font):
+			d.pdf.AddUTF8Font(d.Font, consts.Normal, name)
+		}
+	}
+	d.pdf.SetDefaultFontFamily(d.Font)
+	// Maroto starts with a standard font, which would be selected on every
+	// page and is never embedded.
+	if m, ok := d.pdf.(*pdf.PdfMaroto); ok {
+		m.Font.SetFont(d.Font, consts.Normal, m.Font.GetSize())
+	}
+	return nil
+}
+
+// buildHeader prepares the title, the number and the dates of the document.
+func (d *Document) buildHeader() {
+	d.pdf.RegisterHeader(func() {
+		d.pdf.Row(float64(10+10*len(d.Dates)), func() {
+			d.pdf.Col(5, func() {
+				d.pdf.Text(d.Title, props.Text{
+					Size:  24,
+					Style: consts.Bold,
+					Align: consts.Left,
+				})
+				d.pdf.Text(d.Number, props.Text{
+					Top:   12,
+					Size:  18,
+					Style: consts.Bold,
+				})
+			})
+			d.pdf.Col(3, func() {
+				if d.Stamp == "" {
+					return
+				}
+				d.pdf.Text(d.Stamp, props.Text{
+					Top:   4,
+					Size:  28,
+					Style: consts.Bold,
+					Align: consts.Center,
+					Color: stampColor(),
+				})
+			})
+			d.pdf.Col(4, func() {
+				for i, date := range d.Dates {
+					top := float64(12 * i)
+					d.pdf.Text(date.Label+":", props.Text{
+						Top:   top,
+						Size:  8,
+						Style: consts.Bold,
+						Align: consts.Left,
+						Color: tealColor(),
+					})
+					d.pdf.Text(date.Value, props.Text{
+						Top:   top,
+						Size:  8,
+						Style: consts.Bold,
+						Align: consts.Center,
+					})
+				}
+			})
+		})
+	})
+}
+
+// buildFooter prepares the page numbers.
+func (d *Document) buildFooter() {
+	d.pdf.RegisterFooter(func() {
+		d.pdf.SetAliasNbPages("{nbs}")
+		currentPage := strconv.Itoa(d.pdf.GetCurrentPage())
+		d.pdf.Row(6, func() {
+			d.pdf.Col(12, func() {
+				d.pdf.Text(fmt.Sprintf("Page %s of {nbs}", currentPage), props.Text{
+					Top:   1,
+					Style: consts.BoldItalic,
+					Size:  8,
+					Align: consts.Left,
+					Color: tealColor(),
+				})
+			})
+		})
+	})
+}
+
+// buildCompanyDetails prepares rows with seller and buyer details.
+func (d *Document) buildCompanyDetails() {
+	d.pdf.Row(7, func() {
+		d.pdf.SetBackgroundColor(tealColor())
+		d.pdf.Col(5, func() {
+			d.pdf.Text("Seller", props.Text{
+				Top:   1.5,
+				Size:  9,
+				Style: consts.Bold,
+				Align: consts.Center,
+				Color: color.NewWhite(),
+			})
+		})
+		d.pdf.ColSpace(2)
+		d.pdf.Col(5, func() {
+			d.pdf.Text("Buyer", props.Text{
+				Top:   1.5,
+				Size:  9,
+				Style: consts.Bold,
+				Align: consts.Center,
+				Color: color.NewWhite(),
+			})
+		})
+	})
+	d.pdf.SetBackgroundColor(color.NewWhite())
+
+	d.partyRow("Name", d.Seller.Name, d.Buyer.Name)
+	d.partyRow("Address", d.Seller.Address, d.Buyer.Address)
+	d.partyRow("VAT Number", d.Seller.VAT, d.Buyer.VAT)
+	d.pdf.Row(2, func() {})
+}
+
+func (d *Document) partyRow(label, seller, buyer string) {
+	d.pdf.Row(10, func() {
+		for i, value := range []string{seller, buyer} {
+			if i > 0 {
+				d.pdf.ColSpace(2)
+			}
+			d.pdf.Col(2, func() {
+				d.pdf.Text(label+":", props.Text{
+					Top:   3,
+					Style: consts.Bold,
+					Align: consts.Left,
+					Color: tealColor(),
+				})
+			})
+			d.pdf.Col(3, func() {
+				d.pdf.Text(value, props.Text{
+					Top:   3,
+					Style: consts.Bold,
+					Align: consts.Left,
+				})
+			})
+		}
+	})
+}
+
+// buildBankDetails prepares rows with bank details.
+func (d *Document) buildBankDetails() {
+	if d.Bank.AccountNumber == "" && d.Bank.Swift == "" {
+		return
+	}
+	d.pdf.SetBackgroundColor(tealColor())
+	d.pdf.Line(0.5)
+	d.pdf.SetBackgroundColor(color.NewWhite())
+
+	d.pdf.Row(20, func() {
+		for i, f := range []Field{{"Account no", d.Bank.AccountNumber}, {"Bank/SWIFT", d.Bank.Swift}} {
+			size := uint(3)
+			if i > 0 {
+				size = 2
+			}
+			d.pdf.Col(size, func() {
+				d.pdf.Text(f.Label+":", props.Text{
+					Style: consts.Bold,
+					Size:  8,
+					Align: consts.Left,
+					Color: tealColor(),
+				})
+				d.pdf.Text(f.Value, props.Text{
+					Top:   3,
+					Style: consts.Bold,
+					Size:  8,
+					Align: consts.Left,
+				})
+			})
+		}
+	})
+}
+
+// buildTable prepares the list of document lines.
+func (d *Document) buildTable() {
+	if len(d.Table.Header) == 0 {
+		return
+	}
+	backgroundColor := grayColor()
+	d.pdf.SetBackgroundColor(tealColor())
+	d.pdf.Row(2, func() {
+		d.pdf.Col(12, func() {})
+	})
+	d.pdf.SetBackgroundColor(color.NewWhite())
+	d.pdf.TableList(d.Table.Header, d.Table.Rows, props.TableList{
+		HeaderProp: props.TableListContent{
+			Style:     consts.Normal,
+			Size:      8,
+			GridSizes: d.Table.GridSizes,
+			Color:     tealColor(),
+		},
+		ContentProp: props.TableListContent{
+			Style:     consts.Normal,
+			Size:      9,
+			GridSizes: d.Table.GridSizes,
+		},
+		Align:                consts.Center,
+		AlternatedBackground: &backgroundColor,
+		HeaderContentSpace:   1,
+		Line:                 false,
+	})
+}
+
+// buildSummary prepares the totals below the table, the last one highlighted.
+func (d *Document) buildSummary() {
+	for i, f := range d.Summary {
+		last := i == len(d.Summary)-1
+		textColor := color.NewBlack()
+		if last {
+			d.pdf.SetBackgroundColor(tealColor())
+			textColor = color.NewWhite()
+		}
+		d.pdf.Row(8, func() {
+			d.pdf.ColSpace(6)
+			d.pdf.Col(3, func() {
+				d.pdf.Text(f.Label+":", props.Text{
+					Top:   2,
+					Style: consts.Bold,
+					Size:  8,
+					Align: consts.Right,
+					Color: textColor,
+				})
+			})
+			d.pdf.Col(3, func() {
+				d.pdf.Text(f.Value, props.Text{
+					Top:   2,
+					Style: consts.Bold,
+					Size:  8,
+					Align: consts.Center,
+					Color: textColor,
+				})
+			})
+		})
+		d.pdf.SetBackgroundColor(color.NewWhite())
+	}
+}
+
+// buildSignature prepares the notes and the signatures of the receiver and issuer.
+func (d *Document) buildSignature() {
+	d.pdf.SetBackgroundColor(tealColor())
+	d.pdf.Line(0.5)
+	d.pdf.SetBackgroundColor(color.NewWhite())
+
+	d.pdf.Row(15, func() {
+		d.pdf.Col(1, func() {
+			d.pdf.Text("Notes:", props.Text{
+				Top:   1,
+				Style: consts.Bold,
+				Size:  8,
+				Align: consts.Left,
+				Color: tealColor(),
+			})
+		})
+		d.pdf.Col(11, func() {
+			d.pdf.Text(d.Notes, props.Text{
+				Top:   1,
+				Style: consts.Bold,
+				Size:  8,
+				Align: consts.Left,
+			})
+		})
+	})
+
+	signatureFont := props.Font{
+		Size:  12.0,
+		Style: consts.BoldItalic,
+		Color: color.Color{Red: 10, Green: 20, Blue: 30},
+	}
+	d.pdf.Row(15, func() {
+		d.pdf.Col(6, func() {
+			d.pdf.Signature("Signature of the receiver", signatureFont)
+		})
+		d.pdf.ColSpace(3)
+		d.pdf.Col(3, func() {
+			d.pdf.Text(d.Signature, props.Text{
+				Top:   5,
+				Style: consts.Bold,
+				Size:  8,
+				Align: consts.Center,
+			})
+			d.pdf.Signature("Signature of the issuer", signatureFont)
+		})
+	})
+}
@@ -0,0 +1,33 @@
+package document
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestRenderIsStable(t *testing.T) {
+	doc := &Document{
+		Title:   "Invoice",
+		Number:  "FV/1/2022",
+		Dates:   []Field{{"Date of issue", "01-05-2022"}},
+		Table:   Table{Header: []string{"No", "Description"}, Rows: [][]string{{"1", "Consulting"}}, GridSizes: []uint{2, 10}},
+		Summary: []Field{{"Total", "100.00 PLN"}},
+		Created: time.Date(2022, time.May, 1, 0, 0, 0, 0, time.UTC),
+	}
+
+	first, err := doc.Render()
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := doc.Render()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(first, second) {
+		t.Errorf("the same document rendered to different bytes")
+	}
+	if !bytes.Contains(first, []byte("/CreationDate (D:20220501000000)")) {
+		t.Errorf("the PDF does not record the date of issue as its creation date")
+	}
+}
@@ -0,0 +1,60 @@
+package document
+
+import (
+	"fmt"
+	"strconv"
+
+	facturnetesv2 "github.com/cnvergence/facturnetes/api/v2"
+)
+
+// InterestNote prepares the printable document of an interest note. Every
+// line is an amount of a source invoice paid late, with the days late and the
+// interest charged on it. The parties and the bank are taken from the first
+// source invoice, as it was issued.
+func InterestNote(note *facturnetesv2.InterestNote, first *facturnetesv2.Invoice) *Document {
+	data := first.Spec.InvoiceData
+	parties := data.Company
+	if first.Status.Parties != nil {
+		parties = *first.Status.Parties
+	}
+	currency := note.Status.Currency
+
+	dates := []Field{
+		{"Date of issue", note.Spec.IssueDate},
+		{"Period", note.Spec.From + " - " + note.Spec.To},
+	}
+	if note.Spec.DueDate != "" {
+		dates = append(dates, Field{"Due date", note.Spec.DueDate})
+	}
+	doc := &Document{
+		Title:     facturnetesv2.DocumentInterestNote.Title(),
+		Number:    note.Spec.Number,
+		Dates:     dates,
+		Seller:    sellerParty(parties.Seller),
+		Buyer:     buyerParty(parties.Buyer),
+		Bank:      Bank(data.Bank),
+		Notes:     "Statutory interest for late payment of the invoices listed above.",
+		Signature: data.Signature,
+		Font:      data.Options.FontFamily,
+		Created:   issued(note.Spec.IssueDate),
+	}
+
+	doc.Table = Table{
+		Header:    []string{"No", "Invoice", "Amount paid", "Due date", "Payment date", "Days late", "Interest"},
+		GridSizes: []uint{1, 3, 2, 2, 2, 1, 1},
+	}
+	for i, line := range note.Status.Lines {
+		doc.Table.Rows = append(doc.Table.Rows, []string{
+			strconv.Itoa(i + 1),
+			line.Number,
+			fmt.Sprintf("%s %s", line.Amount, currency),
+			line.DueDate,
+			line.PaymentDate,
+			strconv.Itoa(int(line.DaysLate)),
+			string(line.Interest),
+		})
+	}
+	doc.Summary = append(doc.Summary, Field{"Total interest", fmt.Sprintf("%s %s", note.Status.Total, currency)})
+
+	return doc
+}
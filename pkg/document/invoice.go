@@ -0,0 +1,90 @@
+package document
+
+import (
+	"fmt"
+	"strconv"
+
+	facturnetesv2 "github.com/cnvergence/facturnetes/api/v2"
+	"github.com/cnvergence/facturnetes/pkg/money"
+	"gopkg.in/inf.v0"
+)
+
+var invoiceGridSizes = []uint{1, 3, 1, 2, 1, 2, 2}
+
+// Advance is an advance invoice settled by a final invoice.
+type Advance struct {
+	Number string
+	Totals *money.Totals
+}
+
+// Invoice prepares the printable document of an invoice with its computed
+// totals. The advances settled by a final invoice are deducted from its total.
+func Invoice(invoice *facturnetesv2.Invoice, totals *money.Totals, advances ...Advance) *Document {
+	data := invoice.Spec.InvoiceData
+	doc := &Document{
+		Title:  invoice.Spec.DocumentType.Title(),
+		Number: data.Number,
+		Dates: []Field{
+			{"Date of issue", data.IssueDate},
+			{"Date of sale", data.SaleDate},
+			{"Due date", data.DueDate},
+		},
+		Seller:    sellerParty(data.Company.Seller),
+		Buyer:     buyerParty(data.Company.Buyer),
+		Bank:      Bank(data.Bank),
+		Notes:     data.Notes,
+		Signature: data.Signature,
+		Font:      data.Options.FontFamily,
+		Created:   issued(data.IssueDate),
+	}
+
+	doc.Table = Table{
+		Header:    []string{"No", "Description", "Quantity", "Unit net price", "VAT rate", "VAT amount", "Total gross price"},
+		GridSizes: invoiceGridSizes,
+	}
+	for _, item := range data.Items {
+		if item == nil {
+			continue
+		}
+		n := len(doc.Table.Rows)
+		line := totals.Lines[n]
+		doc.Table.Rows = append(doc.Table.Rows, []string{
+			strconv.Itoa(n + 1),
+			item.Description,
+			string(item.Quantity),
+			string(item.UnitPrice),
+			string(item.VATRate) + "%",
+			money.Format(line.VAT, totals.Scale),
+			money.Format(line.Gross, totals.Scale),
+		})
+	}
+
+	doc.Summary = append(doc.Summary, Field{"Net total", amount(totals.Net, totals.Scale, data.Currency)})
+	for _, rate := range totals.VAT {
+		doc.Summary = append(doc.Summary, Field{fmt.Sprintf("VAT %s%%", rate.Rate), amount(rate.VAT, totals.Scale, data.Currency)})
+	}
+	doc.Summary = append(doc.Summary, Field{"Total", amount(totals.Gross, totals.Scale, data.Currency)})
+	if len(advances) > 0 {
+		due := new(inf.Dec).Set(totals.Gross)
+		for _, advance := range advances {
+			due.Sub(due, advance.Totals.Gross)
+			doc.Summary = append(doc.Summary, Field{"Advance " + advance.Number,
+				amount(new(inf.Dec).Neg(advance.Totals.Gross), totals.Scale, data.Currency)})
+		}
+		doc.Summary = append(doc.Summary, Field{"Amount due", amount(due, totals.Scale, data.Currency)})
+	}
+
+	return doc
+}
+
+func sellerParty(seller facturnetesv2.Seller) Party {
+	return Party{Name: seller.Name, Address: seller.Address, VAT: seller.VAT}
+}
+
+func buyerParty(buyer facturnetesv2.Buyer) Party {
+	return Party{Name: buyer.Name, Address: buyer.Address, VAT: buyer.VAT}
+}
+
+func amount(value *inf.Dec, scale inf.Scale, currency string) string {
+	return fmt.Sprintf("%s %s", money.Format(value, scale), currency)
+}
@@ -0,0 +1,78 @@
+package document
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"text/template"
+
+	facturnetesv2 "github.com/cnvergence/facturnetes/api/v2"
+	"github.com/cnvergence/facturnetes/pkg/dunning"
+	"gopkg.in/inf.v0"
+)
+
+// Reminder prepares the payment reminder of a dunning level for an overdue
+// invoice. The text of the level is expanded with the invoice number, its
+// due date, the days late and the outstanding amount.
+func Reminder(invoice *facturnetesv2.Invoice, level *facturnetesv2.DunningLevel, issueDate string,
+	daysLate int32, claim *dunning.Claim, scale inf.Scale) (*Document, error) {
+	data := invoice.Spec.InvoiceData
+	parties := data.Company
+	if invoice.Status.Parties != nil {
+		parties = *invoice.Status.Parties
+	}
+
+	var notes strings.Builder
+	tmpl, err := template.New(level.Name).Option("missingkey=error").Parse(level.Text)
+	if err != nil {
+		return nil, fmt.Errorf("text of level %s: %w", level.Name, err)
+	}
+	if err := tmpl.Execute(&notes, map[string]interface{}{
+		"Number":      data.Number,
+		"DueDate":     data.DueDate,
+		"DaysLate":    daysLate,
+		"Outstanding": amount(claim.Outstanding, scale, data.Currency),
+	}); err != nil {
+		return nil, fmt.Errorf("text of level %s: %w", level.Name, err)
+	}
+
+	doc := &Document{
+		Title:  level.Title,
+		Number: data.Number,
+		Dates: []Field{
+			{"Date of reminder", issueDate},
+			{"Invoice date of issue", data.IssueDate},
+			{"Due date", data.DueDate},
+		},
+		Seller:    sellerParty(parties.Seller),
+		Buyer:     buyerParty(parties.Buyer),
+		Bank:      Bank(data.Bank),
+		Notes:     notes.String(),
+		Signature: data.Signature,
+		Font:      data.Options.FontFamily,
+		Created:   issued(issueDate),
+	}
+
+	doc.Table = Table{
+		Header:    []string{"Invoice", "Date of issue", "Due date", "Days overdue", "Outstanding"},
+		GridSizes: []uint{3, 2, 2, 2, 3},
+		Rows: [][]string{{
+			data.Number,
+			data.IssueDate,
+			data.DueDate,
+			strconv.Itoa(int(daysLate)),
+			amount(claim.Outstanding, scale, data.Currency),
+		}},
+	}
+
+	doc.Summary = append(doc.Summary, Field{"Outstanding", amount(claim.Outstanding, scale, data.Currency)})
+	if claim.LateFee.Sign() != 0 {
+		doc.Summary = append(doc.Summary, Field{"Late fee", amount(claim.LateFee, scale, data.Currency)})
+	}
+	if claim.Interest.Sign() != 0 {
+		doc.Summary = append(doc.Summary, Field{fmt.Sprintf("Interest (%d days)", daysLate), amount(claim.Interest, scale, data.Currency)})
+	}
+	doc.Summary = append(doc.Summary, Field{"Total due", amount(claim.Total, scale, data.Currency)})
+
+	return doc, nil
+}
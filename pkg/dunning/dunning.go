@@ -0,0 +1,94 @@
+// Package dunning decides which reminder of a DunningPolicy an overdue
+// invoice reached and computes the amount claimed by it.
+package dunning
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	facturnetesv2 "github.com/cnvergence/facturnetes/api/v2"
+	"github.com/cnvergence/facturnetes/pkg/interest"
+	"github.com/cnvergence/facturnetes/pkg/money"
+	"gopkg.in/inf.v0"
+)
+
+const day = 24 * time.Hour
+
+// Levels returns the levels of the policy ordered by their days.
+func Levels(policy *facturnetesv2.DunningPolicy) []facturnetesv2.DunningLevel {
+	levels := append([]facturnetesv2.DunningLevel(nil), policy.Spec.Levels...)
+	sort.SliceStable(levels, func(i, j int) bool { return levels[i].Days < levels[j].Days })
+	return levels
+}
+
+// Reached returns the last level reached on now by an invoice due on the due
+// date, and the time the next level is reached. A level of N days is reached
+// at the start of the N-th day after the due date. reached is nil before the
+// first level, next is zero after the last one.
+func Reached(policy *facturnetesv2.DunningPolicy, due, now time.Time) (reached *facturnetesv2.DunningLevel, next time.Time) {
+	for _, level := range Levels(policy) {
+		level := level
+		at := due.AddDate(0, 0, int(level.Days))
+		if now.Before(at) {
+			return reached, at
+		}
+		reached = &level
+	}
+	return reached, time.Time{}
+}
+
+// DaysLate returns the number of whole days from the due date to now.
+func DaysLate(due, now time.Time) int32 {
+	if !now.After(due) {
+		return 0
+	}
+	return int32(now.Sub(due) / day)
+}
+
+// Claim is the amount claimed by a reminder.
+type Claim struct {
+	Outstanding *inf.Dec
+	LateFee     *inf.Dec
+	Interest    *inf.Dec
+	Total       *inf.Dec
+}
+
+// Compute returns the claim of the level for the outstanding amount of an
+// invoice due on the due date, on the given day, rounded to the scale. A
+// level charging interest claims the accrued interest of the invoice, a level
+// with a fixed rate claims the simple interest on the outstanding amount.
+func Compute(level *facturnetesv2.DunningLevel, outstanding *inf.Dec, due, on time.Time, accrued *inf.Dec, scale inf.Scale) (*Claim, error) {
+	claim := &Claim{
+		Outstanding: outstanding,
+		LateFee:     new(inf.Dec),
+		Interest:    new(inf.Dec),
+	}
+	if level.LateFee != "" {
+		fee, err := money.Parse(level.LateFee)
+		if err != nil {
+			return nil, fmt.Errorf("late fee of level %s: %w", level.Name, err)
+		}
+		claim.LateFee.Round(fee, scale, inf.RoundHalfUp)
+	}
+	switch {
+	case level.ChargeInterest:
+		if accrued != nil {
+			claim.Interest.Round(accrued, scale, inf.RoundHalfUp)
+		}
+	case level.InterestRate != "" && outstanding.Sign() > 0:
+		rate, err := money.Parse(level.InterestRate)
+		if err != nil {
+			return nil, fmt.Errorf("interest rate of level %s: %w", level.Name, err)
+		}
+		periods, err := interest.Fixed(rate).Simple(outstanding, due, on)
+		if err != nil {
+			return nil, err
+		}
+		claim.Interest = interest.Sum(periods, scale)
+	}
+
+	claim.Total = new(inf.Dec).Add(outstanding, claim.LateFee)
+	claim.Total.Add(claim.Total, claim.Interest)
+	return claim, nil
+}
@@ -0,0 +1,86 @@
+package dunning
+
+import (
+	"testing"
+	"time"
+
+	facturnetesv2 "github.com/cnvergence/facturnetes/api/v2"
+	"github.com/cnvergence/facturnetes/pkg/money"
+)
+
+func TestReached(t *testing.T) {
+	policy := &facturnetesv2.DunningPolicy{Spec: facturnetesv2.DunningPolicySpec{
+		Levels: []facturnetesv2.DunningLevel{
+			{Name: "final", Days: 30},
+			{Name: "reminder", Days: 3},
+			{Name: "notice", Days: 14},
+		},
+	}}
+	due := time.Date(2022, 1, 31, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		now     time.Time
+		reached string
+		next    time.Time
+	}{
+		{due.Add(time.Hour), "", due.AddDate(0, 0, 3)},
+		{due.AddDate(0, 0, 3), "reminder", due.AddDate(0, 0, 14)},
+		{due.AddDate(0, 0, 20), "notice", due.AddDate(0, 0, 30)},
+		{due.AddDate(0, 0, 45), "final", time.Time{}},
+	}
+	for _, tt := range tests {
+		reached, next := Reached(policy, due, tt.now)
+		name := ""
+		if reached != nil {
+			name = reached.Name
+		}
+		if name != tt.reached || !next.Equal(tt.next) {
+			t.Errorf("Reached(%s) = %q, %s, want %q, %s", tt.now, name, next, tt.reached, tt.next)
+		}
+	}
+}
+
+func TestCompute(t *testing.T) {
+	tests := []struct {
+		name     string
+		level    facturnetesv2.DunningLevel
+		days     int
+		interest string
+		total    string
+	}{{
+		name:     "reminder",
+		level:    facturnetesv2.DunningLevel{Name: "reminder"},
+		days:     3,
+		interest: "0.00",
+		total:    "1000.00",
+	}, {
+		name:     "fee and interest",
+		level:    facturnetesv2.DunningLevel{Name: "notice", LateFee: "40", InterestRate: "11.25"},
+		days:     14,
+		interest: "4.32",
+		total:    "1044.32",
+	}, {
+		name:     "accrued interest",
+		level:    facturnetesv2.DunningLevel{Name: "final", ChargeInterest: true, InterestRate: "99"},
+		days:     30,
+		interest: "12.35",
+		total:    "1012.35",
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			outstanding, _ := money.Parse("1000.00")
+			due := time.Date(2022, 1, 31, 0, 0, 0, 0, time.UTC)
+			accrued, _ := money.Parse("12.345")
+			claim, err := Compute(&tt.level, outstanding, due, due.AddDate(0, 0, tt.days), accrued, 2)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := money.Format(claim.Interest, 2); got != tt.interest {
+				t.Errorf("interest = %s, want %s", got, tt.interest)
+			}
+			if got := money.Format(claim.Total, 2); got != tt.total {
+				t.Errorf("total = %s, want %s", got, tt.total)
+			}
+		})
+	}
+}
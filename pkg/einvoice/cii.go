@@ -0,0 +1,280 @@
+package einvoice
+
+import (
+	"encoding/xml"
+	"time"
+)
+
+// CII namespaces.
+const (
+	ciiRSMNS = "urn:un:unece:uncefact:data:standard:CrossIndustryInvoice:100"
+	ciiRAMNS = "urn:un:unece:uncefact:data:standard:ReusableAggregateBusinessInformationEntity:100"
+	ciiQDTNS = "urn:un:unece:uncefact:data:standard:QualifiedDataType:100"
+	ciiUDTNS = "urn:un:unece:uncefact:data:standard:UnqualifiedDataType:100"
+)
+
+// CII date format, code 102 of UNTDID 2379.
+const (
+	ciiDateLayout = "20060102"
+	ciiDateFormat = "102"
+)
+
+type ciiInvoice struct {
+	XMLName     xml.Name       `xml:"rsm:CrossIndustryInvoice"`
+	RSM         string         `xml:"xmlns:rsm,attr"`
+	RAM         string         `xml:"xmlns:ram,attr"`
+	QDT         string         `xml:"xmlns:qdt,attr"`
+	UDT         string         `xml:"xmlns:udt,attr"`
+	Context     ciiContext     `xml:"rsm:ExchangedDocumentContext"`
+	Document    ciiDocument    `xml:"rsm:ExchangedDocument"`
+	Transaction ciiTransaction `xml:"rsm:SupplyChainTradeTransaction"`
+}
+
+type ciiContext struct {
+	Process   *ciiParameter `xml:"ram:BusinessProcessSpecifiedDocumentContextParameter"`
+	Guideline ciiParameter  `xml:"ram:GuidelineSpecifiedDocumentContextParameter"`
+}
+
+type ciiParameter struct {
+	ID string `xml:"ram:ID"`
+}
+
+type ciiDocument struct {
+	ID        string      `xml:"ram:ID"`
+	TypeCode  string      `xml:"ram:TypeCode"`
+	IssueDate ciiDateTime `xml:"ram:IssueDateTime"`
+	Note      *ciiNote    `xml:"ram:IncludedNote"`
+}
+
+type ciiNote struct {
+	Content string `xml:"ram:Content"`
+}
+
+type ciiDateTime struct {
+	Value ciiDateString `xml:"udt:DateTimeString"`
+}
+
+type ciiDateString struct {
+	Format string `xml:"format,attr"`
+	Value  string `xml:",chardata"`
+}
+
+type ciiTransaction struct {
+	Lines      []ciiLine     `xml:"ram:IncludedSupplyChainTradeLineItem"`
+	Agreement  ciiAgreement  `xml:"ram:ApplicableHeaderTradeAgreement"`
+	Delivery   ciiDelivery   `xml:"ram:ApplicableHeaderTradeDelivery"`
+	Settlement ciiSettlement `xml:"ram:ApplicableHeaderTradeSettlement"`
+}
+
+type ciiLine struct {
+	ID         string            `xml:"ram:AssociatedDocumentLineDocument>ram:LineID"`
+	Name       string            `xml:"ram:SpecifiedTradeProduct>ram:Name"`
+	Price      string            `xml:"ram:SpecifiedLineTradeAgreement>ram:NetPriceProductTradePrice>ram:ChargeAmount"`
+	Quantity   ublQuantity       `xml:"ram:SpecifiedLineTradeDelivery>ram:BilledQuantity"`
+	Settlement ciiLineSettlement `xml:"ram:SpecifiedLineTradeSettlement"`
+}
+
+type ciiLineSettlement struct {
+	Tax   ciiTax `xml:"ram:ApplicableTradeTax"`
+	Total string `xml:"ram:SpecifiedTradeSettlementLineMonetarySummation>ram:LineTotalAmount"`
+}
+
+type ciiTax struct {
+	Calculated string `xml:"ram:CalculatedAmount,omitempty"`
+	TypeCode   string `xml:"ram:TypeCode"`
+	Basis      string `xml:"ram:BasisAmount,omitempty"`
+	Category   string `xml:"ram:CategoryCode"`
+	Rate       string `xml:"ram:RateApplicablePercent"`
+}
+
+type ciiAgreement struct {
+	BuyerReference string   `xml:"ram:BuyerReference,omitempty"`
+	Seller         ciiParty `xml:"ram:SellerTradeParty"`
+	Buyer          ciiParty `xml:"ram:BuyerTradeParty"`
+}
+
+type ciiParty struct {
+	Name     string              `xml:"ram:Name"`
+	Contact  *ciiContact         `xml:"ram:DefinedTradeContact"`
+	Address  *ciiAddress         `xml:"ram:PostalTradeAddress"`
+	Endpoint *ciiID              `xml:"ram:URIUniversalCommunication>ram:URIID"`
+	Tax      *ciiTaxRegistration `xml:"ram:SpecifiedTaxRegistration"`
+}
+
+type ciiContact struct {
+	Name  string `xml:"ram:PersonName,omitempty"`
+	Phone string `xml:"ram:TelephoneUniversalCommunication>ram:CompleteNumber,omitempty"`
+	Email string `xml:"ram:EmailURIUniversalCommunication>ram:URIID,omitempty"`
+}
+
+type ciiAddress struct {
+	PostalCode string `xml:"ram:PostcodeCode,omitempty"`
+	LineOne    string `xml:"ram:LineOne,omitempty"`
+	LineTwo    string `xml:"ram:LineTwo,omitempty"`
+	City       string `xml:"ram:CityName,omitempty"`
+	Country    string `xml:"ram:CountryID,omitempty"`
+}
+
+type ciiTaxRegistration struct {
+	ID ciiID `xml:"ram:ID"`
+}
+
+type ciiID struct {
+	Scheme string `xml:"schemeID,attr,omitempty"`
+	Value  string `xml:",chardata"`
+}
+
+type ciiDelivery struct {
+	Date *ciiDateTime `xml:"ram:ActualDeliverySupplyChainEvent>ram:OccurrenceDateTime"`
+}
+
+type ciiSettlement struct {
+	PaymentReference string            `xml:"ram:PaymentReference,omitempty"`
+	Currency         string            `xml:"ram:InvoiceCurrencyCode"`
+	PaymentMeans     *ciiPaymentMeans  `xml:"ram:SpecifiedTradeSettlementPaymentMeans"`
+	Taxes            []ciiTax          `xml:"ram:ApplicableTradeTax"`
+	DueDate          *ciiDateTime      `xml:"ram:SpecifiedTradePaymentTerms>ram:DueDateDateTime"`
+	Summation        ciiSummation      `xml:"ram:SpecifiedTradeSettlementHeaderMonetarySummation"`
+	Preceding        []ciiReferencedID `xml:"ram:InvoiceReferencedDocument"`
+}
+
+type ciiPaymentMeans struct {
+	TypeCode string `xml:"ram:TypeCode"`
+	IBAN     string `xml:"ram:PayeePartyCreditorFinancialAccount>ram:IBANID,omitempty"`
+	Account  string `xml:"ram:PayeePartyCreditorFinancialAccount>ram:ProprietaryID,omitempty"`
+	BIC      string `xml:"ram:PayeeSpecifiedCreditorFinancialInstitution>ram:BICID,omitempty"`
+}
+
+type ciiSummation struct {
+	LineTotal  string    `xml:"ram:LineTotalAmount"`
+	TaxBasis   string    `xml:"ram:TaxBasisTotalAmount"`
+	TaxTotal   ublAmount `xml:"ram:TaxTotalAmount"`
+	GrandTotal string    `xml:"ram:GrandTotalAmount"`
+	Prepaid    string    `xml:"ram:TotalPrepaidAmount,omitempty"`
+	DuePayable string    `xml:"ram:DuePayableAmount"`
+}
+
+type ciiReferencedID struct {
+	ID string `xml:"ram:IssuerAssignedID"`
+}
+
+// CII serializes the invoice as an UN/CEFACT Cross-Industry Invoice (D16B)
+// document following the profile.
+func (inv *Invoice) CII(profile Profile) ([]byte, error) {
+	if err := profile.check(inv); err != nil {
+		return nil, err
+	}
+	doc := ciiInvoice{
+		RSM:     ciiRSMNS,
+		RAM:     ciiRAMNS,
+		QDT:     ciiQDTNS,
+		UDT:     ciiUDTNS,
+		Context: ciiContext{Guideline: ciiParameter{ID: profile.Customization}},
+		Document: ciiDocument{
+			ID:        inv.Number,
+			TypeCode:  inv.TypeCode,
+			IssueDate: ciiDateOf(inv.IssueDate),
+		},
+	}
+	if profile.BusinessProcess != "" {
+		doc.Context.Process = &ciiParameter{ID: profile.BusinessProcess}
+	}
+	if inv.Note != "" {
+		doc.Document.Note = &ciiNote{Content: inv.Note}
+	}
+
+	for _, line := range inv.Lines {
+		doc.Transaction.Lines = append(doc.Transaction.Lines, ciiLine{
+			ID:       line.ID,
+			Name:     line.Name,
+			Price:    line.Price.String(),
+			Quantity: ublQuantity{Unit: "C62", Value: line.Quantity.String()},
+			Settlement: ciiLineSettlement{
+				Tax:   ciiTax{TypeCode: "VAT", Category: line.VATCategory, Rate: line.VATRate.String()},
+				Total: inv.Amount(line.Net),
+			},
+		})
+	}
+
+	doc.Transaction.Agreement = ciiAgreement{
+		BuyerReference: inv.BuyerReference,
+		Seller:         ciiPartyOf(inv.Seller),
+		Buyer:          ciiPartyOf(inv.Buyer),
+	}
+	if !inv.DeliveryDate.IsZero() {
+		date := ciiDateOf(inv.DeliveryDate)
+		doc.Transaction.Delivery.Date = &date
+	}
+
+	settlement := &doc.Transaction.Settlement
+	settlement.Currency = inv.Currency
+	if inv.Payment != nil {
+		settlement.PaymentReference = inv.Number
+		means := &ciiPaymentMeans{TypeCode: inv.Payment.MeansCode, BIC: inv.Payment.Bank}
+		if inv.Payment.MeansCode == MeansSEPACreditTransfer {
+			means.IBAN = inv.Payment.Account
+		} else {
+			means.Account = inv.Payment.Account
+		}
+		settlement.PaymentMeans = means
+	}
+	for _, vat := range inv.VAT {
+		settlement.Taxes = append(settlement.Taxes, ciiTax{
+			Calculated: inv.Amount(vat.Tax),
+			TypeCode:   "VAT",
+			Basis:      inv.Amount(vat.Taxable),
+			Category:   vat.Category,
+			Rate:       vat.Rate.String(),
+		})
+	}
+	if !inv.DueDate.IsZero() {
+		date := ciiDateOf(inv.DueDate)
+		settlement.DueDate = &date
+	}
+	settlement.Summation = ciiSummation{
+		LineTotal:  inv.Amount(inv.LineTotal),
+		TaxBasis:   inv.Amount(inv.TaxExclusive),
+		TaxTotal:   inv.ublAmount(inv.Tax),
+		GrandTotal: inv.Amount(inv.TaxInclusive),
+		DuePayable: inv.Amount(inv.Payable),
+	}
+	if inv.Prepaid.Sign() != 0 {
+		settlement.Summation.Prepaid = inv.Amount(inv.Prepaid)
+	}
+	for _, number := range inv.Preceding {
+		settlement.Preceding = append(settlement.Preceding, ciiReferencedID{ID: number})
+	}
+
+	out, err := xml.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), append(out, '\n')...), nil
+}
+
+func ciiPartyOf(p Party) ciiParty {
+	party := ciiParty{Name: p.Name}
+	if c := p.Contact; c != nil {
+		party.Contact = &ciiContact{Name: c.Name, Phone: c.Phone, Email: c.Email}
+	}
+	if a := p.Address; len(a.Lines) > 0 || a.City != "" || a.PostalCode != "" || a.CountryCode != "" {
+		party.Address = &ciiAddress{PostalCode: a.PostalCode, City: a.City, Country: a.CountryCode}
+	}
+	if len(p.Address.Lines) > 0 {
+		party.Address.LineOne = p.Address.Lines[0]
+	}
+	if len(p.Address.Lines) > 1 {
+		party.Address.LineTwo = p.Address.Lines[1]
+	}
+	if p.EndpointID != "" {
+		party.Endpoint = &ciiID{Scheme: p.EndpointScheme, Value: p.EndpointID}
+	}
+	if p.VAT != "" {
+		party.Tax = &ciiTaxRegistration{ID: ciiID{Scheme: "VA", Value: p.VAT}}
+	}
+	return party
+}
+
+func ciiDateOf(t time.Time) ciiDateTime {
+	return ciiDateTime{Value: ciiDateString{Format: ciiDateFormat, Value: t.Format(ciiDateLayout)}}
+}
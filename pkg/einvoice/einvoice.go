@@ -0,0 +1,320 @@
+// Package einvoice maps invoices to the EN 16931 semantic model and
+// serializes it as structured electronic invoices.
+package einvoice
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	facturnetesv2 "github.com/cnvergence/facturnetes/api/v2"
+	"github.com/cnvergence/facturnetes/pkg/document"
+	"github.com/cnvergence/facturnetes/pkg/money"
+	"gopkg.in/inf.v0"
+)
+
+// Invoice type codes of UNTDID 1001.
+const (
+	TypeCommercialInvoice = "380"
+	TypePrepaymentInvoice = "386"
+	TypeProformaInvoice   = "325"
+)
+
+// VAT category codes of UNTDID 5305.
+const (
+	CategoryStandard = "S"
+	CategoryZero     = "Z"
+)
+
+// Payment means codes of UNTDID 4461.
+const (
+	MeansCreditTransfer     = "30"
+	MeansSEPACreditTransfer = "58"
+)
+
+// Invoice is an invoice in terms of the EN 16931 semantic model.
+type Invoice struct {
+	Number    string
+	TypeCode  string
+	IssueDate time.Time
+	// DeliveryDate is the date of sale, zero when not known.
+	DeliveryDate time.Time
+	// DueDate is zero when the invoice has no payment term.
+	DueDate  time.Time
+	Currency string
+	Note     string
+	// Preceding lists the numbers of the settled advance invoices.
+	Preceding []string
+	// BuyerReference is the reference given by the buyer, e.g. a Leitweg-ID.
+	BuyerReference string
+	// ExemptionNature is the FatturaPA Natura code of the zero-rated amounts.
+	ExemptionNature string
+
+	Seller  Party
+	Buyer   Party
+	Payment *Payment
+
+	Lines []Line
+	VAT   []VATBreakdown
+
+	Scale        inf.Scale
+	LineTotal    *inf.Dec
+	TaxExclusive *inf.Dec
+	Tax          *inf.Dec
+	TaxInclusive *inf.Dec
+	Prepaid      *inf.Dec
+	Payable      *inf.Dec
+}
+
+// Party is the seller or the buyer of an invoice.
+type Party struct {
+	Name string
+	VAT  string
+	// NIP is the Polish tax identification number.
+	NIP     string
+	Address Address
+	// EndpointID is the electronic address of the party in the scheme of the
+	// EAS code EndpointScheme.
+	EndpointID     string
+	EndpointScheme string
+	Contact        *Contact
+	// TaxCode is the Italian codice fiscale.
+	TaxCode string
+	// TaxRegime is the FatturaPA RegimeFiscale of the seller.
+	TaxRegime string
+	// RecipientCode and PEC address FatturaPA invoices to the buyer.
+	RecipientCode string
+	PEC           string
+}
+
+// Address of a party.
+type Address struct {
+	Lines      []string
+	City       string
+	PostalCode string
+	// Province is the Italian provincia.
+	Province    string
+	CountryCode string
+}
+
+// Contact is the contact point of a party.
+type Contact struct {
+	Name  string
+	Phone string
+	Email string
+}
+
+// Payment holds the payment instructions of an invoice.
+type Payment struct {
+	MeansCode string
+	Account   string
+	Bank      string
+}
+
+// Line is an invoice line.
+type Line struct {
+	ID          string
+	Name        string
+	Quantity    *inf.Dec
+	Price       *inf.Dec
+	Net         *inf.Dec
+	VATCategory string
+	VATRate     *inf.Dec
+}
+
+// VATBreakdown holds the amounts of the lines sharing a VAT category and rate.
+type VATBreakdown struct {
+	Category string
+	Rate     *inf.Dec
+	Taxable  *inf.Dec
+	Tax      *inf.Dec
+}
+
+// New maps the invoice with its computed totals to the semantic model. The
+// parties must already be resolved. The advances settled by a final invoice
+// are reported as the prepaid amount.
+func New(invoice *facturnetesv2.Invoice, totals *money.Totals, advances ...document.Advance) (*Invoice, error) {
+	data := invoice.Spec.InvoiceData
+	inv := &Invoice{
+		Number:         data.Number,
+		TypeCode:       typeCode(invoice.Spec.DocumentType),
+		Currency:       data.Currency,
+		Note:           data.Notes,
+		BuyerReference: data.Company.Buyer.BuyerReference,
+		Seller:         sellerParty(data.Company.Seller),
+		Buyer:          buyerParty(data.Company.Buyer),
+		Payment:        payment(data.Bank),
+
+		Scale:        totals.Scale,
+		LineTotal:    totals.Net,
+		TaxExclusive: totals.Net,
+		Tax:          totals.Tax,
+		TaxInclusive: totals.Gross,
+		Prepaid:      new(inf.Dec),
+	}
+
+	if it := data.Company.Buyer.Italy; it != nil {
+		inv.ExemptionNature = it.Natura
+	}
+
+	var err error
+	if inv.IssueDate, err = date(data.IssueDate); err != nil {
+		return nil, fmt.Errorf("issue date: %w", err)
+	}
+	if inv.DeliveryDate, err = date(data.SaleDate); err != nil {
+		return nil, fmt.Errorf("sale date: %w", err)
+	}
+	if inv.DueDate, err = date(data.DueDate); err != nil {
+		return nil, fmt.Errorf("due date: %w", err)
+	}
+
+	for i, item := range data.Items {
+		if item == nil {
+			continue
+		}
+		quantity, err := money.Parse(item.Quantity)
+		if err != nil {
+			return nil, fmt.Errorf("item %d quantity: %w", i+1, err)
+		}
+		price, err := money.Parse(item.UnitPrice)
+		if err != nil {
+			return nil, fmt.Errorf("item %d unit price: %w", i+1, err)
+		}
+		rate, err := money.Parse(item.VATRate)
+		if err != nil {
+			return nil, fmt.Errorf("item %d VAT rate: %w", i+1, err)
+		}
+		inv.Lines = append(inv.Lines, Line{
+			ID:          fmt.Sprint(len(inv.Lines) + 1),
+			Name:        item.Description,
+			Quantity:    quantity,
+			Price:       price,
+			Net:         totals.Lines[len(inv.Lines)].Net,
+			VATCategory: category(rate),
+			VATRate:     rate,
+		})
+	}
+
+	for _, rate := range totals.VAT {
+		inv.VAT = append(inv.VAT, VATBreakdown{
+			Category: category(rate.Rate),
+			Rate:     rate.Rate,
+			Taxable:  rate.Net,
+			Tax:      rate.VAT,
+		})
+	}
+
+	for _, advance := range advances {
+		inv.Preceding = append(inv.Preceding, advance.Number)
+		inv.Prepaid.Add(inv.Prepaid, advance.Totals.Gross)
+	}
+	inv.Payable = new(inf.Dec).Sub(inv.TaxInclusive, inv.Prepaid)
+
+	return inv, nil
+}
+
+// Amount formats an amount with the precision of the invoice currency.
+func (inv *Invoice) Amount(d *inf.Dec) string {
+	return money.Format(d, inv.Scale)
+}
+
+func typeCode(t facturnetesv2.DocumentType) string {
+	switch t {
+	case facturnetesv2.DocumentAdvance:
+		return TypePrepaymentInvoice
+	case facturnetesv2.DocumentProforma:
+		return TypeProformaInvoice
+	default:
+		return TypeCommercialInvoice
+	}
+}
+
+func category(rate *inf.Dec) string {
+	if rate.Sign() == 0 {
+		return CategoryZero
+	}
+	return CategoryStandard
+}
+
+func sellerParty(seller facturnetesv2.Seller) Party {
+	p := Party{
+		Name:           seller.Name,
+		VAT:            seller.VAT,
+		NIP:            seller.NIP,
+		Address:        address(seller.Address, seller.AddressLines),
+		EndpointID:     seller.EndpointID,
+		EndpointScheme: seller.EndpointScheme,
+	}
+	p.Address.City, p.Address.PostalCode, p.Address.CountryCode = seller.City, seller.PostalCode, seller.CountryCode
+	if c := seller.Contact; c != nil {
+		p.Contact = &Contact{Name: c.Name, Phone: c.Phone, Email: c.Email}
+	}
+	if it := seller.Italy; it != nil {
+		p.TaxCode, p.TaxRegime, p.Address.Province = it.CodiceFiscale, it.RegimeFiscale, it.Provincia
+	}
+	return p
+}
+
+func buyerParty(buyer facturnetesv2.Buyer) Party {
+	p := Party{
+		Name:           buyer.Name,
+		VAT:            buyer.VAT,
+		NIP:            buyer.NIP,
+		Address:        address(buyer.Address, buyer.AddressLines),
+		EndpointID:     buyer.EndpointID,
+		EndpointScheme: buyer.EndpointScheme,
+	}
+	p.Address.City, p.Address.PostalCode, p.Address.CountryCode = buyer.City, buyer.PostalCode, buyer.CountryCode
+	if it := buyer.Italy; it != nil {
+		p.TaxCode, p.RecipientCode, p.PEC, p.Address.Province = it.CodiceFiscale, it.CodiceDestinatario, it.PEC, it.Provincia
+	}
+	return p
+}
+
+// address returns the address lines, or the address as a single line.
+func address(single string, lines []string) Address {
+	a := Address{Lines: lines}
+	if len(lines) == 0 && single != "" {
+		a.Lines = []string{single}
+	}
+	return a
+}
+
+// payment returns the payment instructions of the bank details, a SEPA
+// credit transfer when the account number is an IBAN.
+func payment(bank facturnetesv2.Bank) *Payment {
+	account := strings.ReplaceAll(bank.AccountNumber, " ", "")
+	if account == "" {
+		return nil
+	}
+	p := &Payment{MeansCode: MeansCreditTransfer, Account: account, Bank: strings.TrimSpace(bank.Swift)}
+	if isIBAN(account) {
+		p.MeansCode = MeansSEPACreditTransfer
+	}
+	return p
+}
+
+// isIBAN tells whether the account number has the shape of an IBAN.
+func isIBAN(account string) bool {
+	if len(account) < 15 || len(account) > 34 {
+		return false
+	}
+	for i, c := range account {
+		switch {
+		case i < 2 && (c < 'A' || c > 'Z'):
+			return false
+		case i >= 2 && i < 4 && (c < '0' || c > '9'):
+			return false
+		case (c < 'A' || c > 'Z') && (c < '0' || c > '9'):
+			return false
+		}
+	}
+	return true
+}
+
+func date(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	return facturnetesv2.ParseDate(value)
+}
@@ -0,0 +1,450 @@
+package einvoice
+
+import (
+	"bytes"
+	"compress/zlib"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	facturnetesv2 "github.com/cnvergence/facturnetes/api/v2"
+	"github.com/cnvergence/facturnetes/pkg/document"
+	"github.com/cnvergence/facturnetes/pkg/money"
+	"gopkg.in/inf.v0"
+	"sigs.k8s.io/yaml"
+)
+
+var update = flag.Bool("update", false, "update the golden files")
+
+// sampleInvoice returns an Invoice from config/samples with its totals.
+func sampleInvoice(t *testing.T, name string) (*facturnetesv2.Invoice, *money.Totals) {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("..", "..", "config", "samples", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	invoice := &facturnetesv2.Invoice{}
+	if err := yaml.Unmarshal(data, invoice); err != nil {
+		t.Fatal(err)
+	}
+	totals, err := money.Compute(&invoice.Spec.InvoiceData)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return invoice, totals
+}
+
+// sample returns the semantic model of an Invoice from config/samples.
+func sample(t *testing.T, name string) *Invoice {
+	t.Helper()
+	inv, err := New(sampleInvoice(t, name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return inv
+}
+
+// golden compares the document with the golden file in testdata.
+func golden(t *testing.T, name string, got []byte) {
+	t.Helper()
+	path := filepath.Join("testdata", name)
+	if *update {
+		if err := os.WriteFile(path, got, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("%s differs from the golden file, got:\n%s", name, got)
+	}
+}
+
+// validate validates the document against a bundled schema.
+func validate(t *testing.T, schema string, doc []byte) {
+	t.Helper()
+	v, err := NewSchemaValidator()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer v.Close()
+	if err := v.Validate(schema, doc); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestUBL(t *testing.T) {
+	got, err := sample(t, "facturnetes_v2_invoice.yaml").UBL(EN16931)
+	if err != nil {
+		t.Fatal(err)
+	}
+	golden(t, "invoice-sample.ubl.xml", got)
+}
+
+func TestPayment(t *testing.T) {
+	tests := []struct {
+		name  string
+		bank  facturnetesv2.Bank
+		means string
+	}{{
+		name:  "IBAN",
+		bank:  facturnetesv2.Bank{AccountNumber: "PL61 1090 1014 0000 0712 1981 2874", Swift: "WBKPPLPP"},
+		means: MeansSEPACreditTransfer,
+	}, {
+		name:  "domestic account",
+		bank:  facturnetesv2.Bank{AccountNumber: "021000021-123456789"},
+		means: MeansCreditTransfer,
+	}, {
+		name: "no account",
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := payment(tt.bank)
+			if tt.means == "" {
+				if got != nil {
+					t.Errorf("payment() = %+v, want nil", got)
+				}
+				return
+			}
+			if got == nil || got.MeansCode != tt.means {
+				t.Errorf("payment() = %+v, want means code %s", got, tt.means)
+			}
+		})
+	}
+}
+
+func TestCII(t *testing.T) {
+	got, err := sample(t, "facturnetes_v2_invoice.yaml").CII(EN16931)
+	if err != nil {
+		t.Fatal(err)
+	}
+	golden(t, "invoice-sample.cii.xml", got)
+}
+
+func TestProfiles(t *testing.T) {
+	tests := []struct {
+		profile facturnetesv2.EInvoiceProfile
+		golden  string
+	}{
+		{facturnetesv2.ProfileXRechnungUBL, "invoice-sample-xrechnung.ubl.xml"},
+		{facturnetesv2.ProfileXRechnungCII, "invoice-sample-xrechnung.cii.xml"},
+		{facturnetesv2.ProfilePeppolBIS, "invoice-sample-peppol.ubl.xml"},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.profile), func(t *testing.T) {
+			inv := sample(t, "facturnetes_v2_invoice_xrechnung.yaml")
+			profile, cii := ProfileOf(tt.profile)
+			write := inv.UBL
+			if cii {
+				write = inv.CII
+			}
+			got, err := write(profile)
+			if err != nil {
+				t.Fatal(err)
+			}
+			golden(t, tt.golden, got)
+		})
+	}
+}
+
+func TestProfileRequiredFields(t *testing.T) {
+	inv := sample(t, "facturnetes_v2_invoice.yaml")
+	_, err := inv.UBL(PeppolBIS)
+	want := "Peppol BIS Billing 3.0 invoice requires the buyer reference, seller electronic address, buyer electronic address"
+	if err == nil || err.Error() != want {
+		t.Errorf("UBL(PeppolBIS) error = %v, want %q", err, want)
+	}
+
+	inv = sample(t, "facturnetes_v2_invoice_xrechnung.yaml")
+	inv.Seller.Contact = nil
+	inv.Buyer.Address.City = ""
+	_, err = inv.CII(XRechnung)
+	want = "XRechnung invoice requires the buyer city, seller contact name, seller contact phone, seller contact email"
+	if err == nil || err.Error() != want {
+		t.Errorf("CII(XRechnung) error = %v, want %q", err, want)
+	}
+}
+
+func TestFacturX(t *testing.T) {
+	invoice, totals := sampleInvoice(t, "facturnetes_v2_invoice.yaml")
+	inv, err := New(invoice, totals)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pdf, err := document.Invoice(invoice, totals).Render()
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := inv.FacturX(pdf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.HasPrefix(got, []byte("%PDF-1.7\n%\xe2\xe3\xcf\xd3\n")) {
+		t.Errorf("missing PDF/A header, got %q", got[:16])
+	}
+	m := regexp.MustCompile(`startxref\n(\d+)\n%%EOF\n$`).FindSubmatch(got)
+	if m == nil {
+		t.Fatal("missing startxref")
+	}
+	start, _ := strconv.Atoi(string(m[1]))
+	lines := strings.Split(string(got[start:]), "\n")
+	size, _ := strconv.Atoi(strings.Fields(lines[1])[1])
+	for i := 1; i < size; i++ {
+		offset, _ := strconv.Atoi(lines[2+i][:10])
+		if !bytes.HasPrefix(got[offset:], []byte(fmt.Sprintf("%d 0 obj\n", i))) {
+			t.Errorf("cross-reference entry %d does not point to its object", i)
+		}
+	}
+
+	for _, want := range []string{
+		"/AFRelationship /Alternative",
+		"/OutputIntents [",
+		"<pdfaid:part>3</pdfaid:part>",
+		"<fx:ConformanceLevel>EN 16931</fx:ConformanceLevel>",
+		"/Title (Invoice 99) /Author (Best Company)",
+	} {
+		if !bytes.Contains(got, []byte(want)) {
+			t.Errorf("Factur-X document does not contain %q", want)
+		}
+	}
+
+	cii, err := inv.CII(EN16931)
+	if err != nil {
+		t.Fatal(err)
+	}
+	embedded := regexp.MustCompile(`(?s)/Type /EmbeddedFile.*?>>\nstream\n(.*?)\nendstream`).FindSubmatch(got)
+	if embedded == nil {
+		t.Fatal("missing embedded file")
+	}
+	r, err := zlib.NewReader(bytes.NewReader(embedded[1]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	attached, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(attached, cii) {
+		t.Errorf("attached XML differs from the CII of the invoice")
+	}
+}
+
+func TestKSeF(t *testing.T) {
+	invoice, totals := sampleInvoice(t, "facturnetes_v2_invoice.yaml")
+	inv, err := New(invoice, totals)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := inv.KSeF(invoice.Spec.DocumentType)
+	if err != nil {
+		t.Fatal(err)
+	}
+	golden(t, "invoice-sample.ksef.xml", got)
+	validate(t, SchemaFA2, got)
+}
+
+func TestFatturaPA(t *testing.T) {
+	invoice, totals := sampleInvoice(t, "facturnetes_v2_invoice_fatturapa.yaml")
+	inv, err := New(invoice, totals)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := inv.FatturaPA(invoice.Spec.DocumentType)
+	if err != nil {
+		t.Fatal(err)
+	}
+	golden(t, "invoice-sample-fatturapa.xml", got)
+	validate(t, SchemaFatturaPA, got)
+}
+
+func TestFatturaPARequiredFields(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(inv *Invoice)
+		want   string
+	}{{
+		name:   "regime",
+		modify: func(inv *Invoice) { inv.Seller.TaxRegime = "" },
+		want:   "FatturaPA invoices require the RegimeFiscale of the seller",
+	}, {
+		name:   "buyer identifier",
+		modify: func(inv *Invoice) { inv.Buyer.VAT = "" },
+		want:   "FatturaPA invoices require the VAT number or the codice fiscale of Miglior Cliente S.r.l.",
+	}, {
+		name:   "CAP",
+		modify: func(inv *Invoice) { inv.Buyer.Address.PostalCode = "" },
+		want:   "FatturaPA invoices require the five-digit CAP of Miglior Cliente S.r.l.",
+	}, {
+		name:   "natura",
+		modify: func(inv *Invoice) { inv.ExemptionNature = "" },
+		want:   "FatturaPA zero-rated amounts require the Natura of the buyer",
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inv := sample(t, "facturnetes_v2_invoice_fatturapa.yaml")
+			tt.modify(inv)
+			_, err := inv.FatturaPA(facturnetesv2.DocumentInvoice)
+			if err == nil || err.Error() != tt.want {
+				t.Errorf("FatturaPA() error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestFatturaPAForeignBuyer(t *testing.T) {
+	inv := sample(t, "facturnetes_v2_invoice_fatturapa.yaml")
+	inv.Buyer.VAT = "DE123456789"
+	inv.Buyer.RecipientCode = ""
+	inv.Buyer.Address = Address{Lines: []string{"Beispielstraße 5"}, City: "Berlin", PostalCode: "10117", CountryCode: "DE"}
+	got, err := inv.FatturaPA(facturnetesv2.DocumentInvoice)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"<CodiceDestinatario>XXXXXXX</CodiceDestinatario>",
+		"<CAP>00000</CAP>",
+		"<IdPaese>DE</IdPaese>",
+	} {
+		if !strings.Contains(string(got), want) {
+			t.Errorf("FatturaPA() does not contain %s", want)
+		}
+	}
+	validate(t, SchemaFatturaPA, got)
+}
+
+func TestKSeFBuyerID(t *testing.T) {
+	tests := []struct {
+		name  string
+		buyer Party
+		want  ksefBuyerID
+	}{{
+		name:  "NIP",
+		buyer: Party{Name: "Klient", NIP: "1111111111", VAT: "PL1111111111"},
+		want:  ksefBuyerID{Name: "Klient", NIP: "1111111111"},
+	}, {
+		name:  "EU VAT number",
+		buyer: Party{Name: "Kunde", VAT: "DE 123456789", Address: Address{CountryCode: "DE"}},
+		want:  ksefBuyerID{Name: "Kunde", EUCode: "DE", EUVAT: "123456789"},
+	}, {
+		name:  "Greek VAT number",
+		buyer: Party{Name: "Pelatis", VAT: "EL123456789", Address: Address{CountryCode: "GR"}},
+		want:  ksefBuyerID{Name: "Pelatis", EUCode: "EL", EUVAT: "123456789"},
+	}, {
+		name:  "foreign tax number",
+		buyer: Party{Name: "Customer", VAT: "GB123456789", Address: Address{CountryCode: "GB"}},
+		want:  ksefBuyerID{Name: "Customer", Country: "GB", ID: "GB123456789"},
+	}, {
+		name:  "no identifier",
+		buyer: Party{Name: "Consumer"},
+		want:  ksefBuyerID{Name: "Consumer", NoID: "1"},
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ksefBuyerIDOf(tt.buyer); got != tt.want {
+				t.Errorf("ksefBuyerIDOf() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestKSeFRate(t *testing.T) {
+	for _, rate := range []string{"23", "8", "5", "0"} {
+		d, _ := new(inf.Dec).SetString(rate)
+		if _, err := ksefRateOf(d); err != nil {
+			t.Errorf("ksefRateOf(%s) failed: %s", rate, err)
+		}
+	}
+	for _, rate := range []string{"19", "7.5"} {
+		d, _ := new(inf.Dec).SetString(rate)
+		if _, err := ksefRateOf(d); err == nil {
+			t.Errorf("ksefRateOf(%s) succeeded, want an error", rate)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		sample string
+		modify func(inv *Invoice)
+		want   []string
+	}{{
+		name:   "compliant",
+		sample: "facturnetes_v2_invoice_xrechnung.yaml",
+	}, {
+		name:   "VAT identifiers without country code",
+		sample: "facturnetes_v2_invoice.yaml",
+		want:   []string{"BR-CO-09", "BR-CO-09"},
+	}, {
+		name:   "line total",
+		sample: "facturnetes_v2_invoice_xrechnung.yaml",
+		modify: func(inv *Invoice) { inv.Lines[0].Net = inf.NewDec(95000, 2) },
+		want:   []string{"BR-CO-10", "BR-S-08"},
+	}, {
+		name:   "VAT amount",
+		sample: "facturnetes_v2_invoice_xrechnung.yaml",
+		modify: func(inv *Invoice) { inv.VAT[0].Tax = inf.NewDec(18241, 2) },
+		want:   []string{"BR-CO-14", "BR-CO-17", "BR-S-09"},
+	}, {
+		name:   "amount due",
+		sample: "facturnetes_v2_invoice_xrechnung.yaml",
+		modify: func(inv *Invoice) { inv.Payable = inf.NewDec(0, 0) },
+		want:   []string{"BR-CO-16"},
+	}, {
+		name:   "no due date",
+		sample: "facturnetes_v2_invoice_xrechnung.yaml",
+		modify: func(inv *Invoice) { inv.DueDate = time.Time{} },
+		want:   []string{"BR-CO-25"},
+	}, {
+		name:   "no seller VAT",
+		sample: "facturnetes_v2_invoice_xrechnung.yaml",
+		modify: func(inv *Invoice) { inv.Seller.VAT = "" },
+		want:   []string{"BR-CO-26", "BR-S-02"},
+	}, {
+		name:   "negative price and no name",
+		sample: "facturnetes_v2_invoice_xrechnung.yaml",
+		modify: func(inv *Invoice) {
+			inv.Lines[0].Price = inf.NewDec(-120, 0)
+			inv.Lines[0].Name = ""
+		},
+		want: []string{"BR-25", "BR-27"},
+	}, {
+		name:   "zero rated",
+		sample: "facturnetes_v2_invoice.yaml",
+		modify: func(inv *Invoice) {
+			inv.Seller.VAT, inv.Buyer.VAT = "PL2222222222", "PL1111111111"
+			inv.VAT[0].Tax = inf.NewDec(1, 2)
+		},
+		want: []string{"BR-CO-14", "BR-CO-17", "BR-Z-09"},
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inv := sample(t, tt.sample)
+			if tt.modify != nil {
+				tt.modify(inv)
+			}
+			var got []string
+			for _, v := range inv.Validate() {
+				got = append(got, v.Rule)
+			}
+			if strings.Join(got, " ") != strings.Join(tt.want, " ") {
+				t.Errorf("Validate() = %v, want %v", inv.Validate(), tt.want)
+			}
+		})
+	}
+}
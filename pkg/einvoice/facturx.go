@@ -0,0 +1,256 @@
+package einvoice
+
+import (
+	"bytes"
+	"compress/zlib"
+	"crypto/md5"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// FacturXFileName is the name of the invoice XML attached to a Factur-X PDF.
+const FacturXFileName = "factur-x.xml"
+
+// FacturXEN16931 is the conformance level of the EN 16931 (COMFORT) profile.
+const FacturXEN16931 = "EN 16931"
+
+// producer is recorded in the metadata of the documents.
+const producer = "facturnetes"
+
+var (
+	startXRef   = regexp.MustCompile(`startxref\s+(\d+)\s+%%EOF\s*$`)
+	xrefSection = regexp.MustCompile(`^xref\s+0 (\d+)\s+`)
+	trailerRef  = regexp.MustCompile(`/(Root|Info) (\d+) 0 R`)
+)
+
+// FacturX turns the PDF rendering of the invoice into a Factur-X PDF/A-3b
+// document of the EN 16931 profile, with the Cross-Industry Invoice XML
+// attached and described in the XMP metadata. The PDF must use embedded
+// fonts only.
+func (inv *Invoice) FacturX(pdf []byte) ([]byte, error) {
+	cii, err := inv.CII(EN16931)
+	if err != nil {
+		return nil, err
+	}
+	return pdfA3(pdf, pdfMetadata{
+		Title:       "Invoice " + inv.Number,
+		Author:      inv.Seller.Name,
+		Created:     inv.IssueDate,
+		Attachment:  cii,
+		Conformance: FacturXEN16931,
+	})
+}
+
+type pdfMetadata struct {
+	Title       string
+	Author      string
+	Created     time.Time
+	Attachment  []byte
+	Conformance string
+}
+
+// pdfA3 rewrites a PDF written by gofpdf as a PDF/A-3b document with the
+// invoice XML attached. gofpdf writes the Info and Catalog dictionaries as
+// its last two objects, they are replaced by ones carrying the metadata,
+// the output intent and the associated file.
+func pdfA3(pdf []byte, meta pdfMetadata) ([]byte, error) {
+	header := bytes.IndexByte(pdf, '\n')
+	if !bytes.HasPrefix(pdf, []byte("%PDF-1.")) || header < 0 {
+		return nil, errors.New("not a PDF document")
+	}
+	m := startXRef.FindSubmatch(pdf)
+	if m == nil {
+		return nil, errors.New("PDF document has no cross-reference table")
+	}
+	xref, _ := strconv.Atoi(string(m[1]))
+	if xref <= header || xref >= len(pdf) {
+		return nil, errors.New("PDF cross-reference table is out of range")
+	}
+	section := xrefSection.FindSubmatch(pdf[xref:])
+	if section == nil {
+		return nil, errors.New("PDF document has an unsupported cross-reference table")
+	}
+	size, _ := strconv.Atoi(string(section[1]))
+	entries := pdf[xref+len(section[0]):]
+	offsets := make([]int, size)
+	for i := 1; i < size; i++ {
+		if len(entries) < 20*(i+1) {
+			return nil, errors.New("PDF cross-reference table is truncated")
+		}
+		offsets[i], _ = strconv.Atoi(string(entries[20*i : 20*i+10]))
+	}
+	refs := map[string]int{}
+	for _, ref := range trailerRef.FindAllSubmatch(pdf[xref:], -1) {
+		refs[string(ref[1])], _ = strconv.Atoi(string(ref[2]))
+	}
+	info, root := refs["Info"], refs["Root"]
+	if info != size-2 || root != size-1 {
+		return nil, errors.New("PDF document has an unsupported layout")
+	}
+
+	w := &pdfWriter{offsets: offsets[:info]}
+	// PDF/A requires a comment with binary characters after the header.
+	w.WriteString("%PDF-1.7\n%\xe2\xe3\xcf\xd3\n")
+	shift := w.Len() - header - 1
+	for i := 1; i < info; i++ {
+		w.offsets[i] += shift
+	}
+	w.Write(pdf[header+1 : offsets[info]])
+
+	created := pdfDate(meta.Created)
+	infoObj := w.object(fmt.Sprintf("<< /Title %s /Author %s /Producer %s /Creator %s /CreationDate %s /ModDate %s >>",
+		pdfString(meta.Title), pdfString(meta.Author), pdfString(producer), pdfString(producer), created, created))
+
+	file := w.stream(fmt.Sprintf("/Type /EmbeddedFile /Subtype /text#2Fxml /Params << /ModDate %s /Size %d /CheckSum <%x> >>",
+		created, len(meta.Attachment), md5.Sum(meta.Attachment)), meta.Attachment, true)
+	spec := w.object(fmt.Sprintf("<< /Type /Filespec /F %s /UF %s /Desc %s /AFRelationship /Alternative /EF << /F %d 0 R /UF %d 0 R >> >>",
+		pdfString(FacturXFileName), pdfString(FacturXFileName), pdfString("Factur-X invoice"), file, file))
+	xmp := w.stream("/Type /Metadata /Subtype /XML", xmpPacket(meta), false)
+	icc := w.stream("/N 3", srgbProfile, true)
+	intent := w.object(fmt.Sprintf("<< /Type /OutputIntent /S /GTS_PDFA1 /OutputConditionIdentifier %s /Info %s /DestOutputProfile %d 0 R >>",
+		pdfString("sRGB"), pdfString("sRGB IEC61966-2.1"), icc))
+	catalog := w.object(fmt.Sprintf("<< /Type /Catalog /Pages 1 0 R /Metadata %d 0 R /OutputIntents [%d 0 R] /AF [%d 0 R] "+
+		"/Names << /EmbeddedFiles << /Names [%s %d 0 R] >> >> >>", xmp, intent, spec, pdfString(FacturXFileName), spec))
+
+	id := md5.Sum(w.Bytes())
+	start := w.Len()
+	fmt.Fprintf(w, "xref\n0 %d\n0000000000 65535 f \n", len(w.offsets))
+	for _, offset := range w.offsets[1:] {
+		fmt.Fprintf(w, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(w, "trailer\n<< /Size %d /Root %d 0 R /Info %d 0 R /ID [<%x> <%x>] >>\nstartxref\n%d\n%%%%EOF\n",
+		len(w.offsets), catalog, infoObj, id, id, start)
+
+	return w.Bytes(), nil
+}
+
+// pdfWriter writes PDF objects, keeping their offsets by object number.
+type pdfWriter struct {
+	bytes.Buffer
+	offsets []int
+}
+
+func (w *pdfWriter) object(dict string) int {
+	n := w.begin()
+	fmt.Fprintf(w, "%s\nendobj\n", dict)
+	return n
+}
+
+func (w *pdfWriter) stream(dict string, data []byte, compress bool) int {
+	if compress {
+		var b bytes.Buffer
+		z := zlib.NewWriter(&b)
+		z.Write(data)
+		z.Close()
+		data = b.Bytes()
+		dict += " /Filter /FlateDecode"
+	}
+	n := w.begin()
+	fmt.Fprintf(w, "<< %s /Length %d >>\nstream\n", dict, len(data))
+	w.Write(data)
+	w.WriteString("\nendstream\nendobj\n")
+	return n
+}
+
+func (w *pdfWriter) begin() int {
+	n := len(w.offsets)
+	w.offsets = append(w.offsets, w.Len())
+	fmt.Fprintf(w, "%d 0 obj\n", n)
+	return n
+}
+
+// pdfString returns the text as a PDF text string, in UTF-16 when it is
+// not plain ASCII.
+func pdfString(text string) string {
+	ascii := true
+	for _, r := range text {
+		if r > 0x7e || r < 0x20 {
+			ascii = false
+			break
+		}
+	}
+	if ascii {
+		return "(" + strings.NewReplacer(`\`, `\\`, "(", `\(`, ")", `\)`).Replace(text) + ")"
+	}
+	var b strings.Builder
+	b.WriteString("<FEFF")
+	for _, r := range text {
+		if r > 0xffff {
+			r1, r2 := (r-0x10000)>>10+0xd800, (r-0x10000)&0x3ff+0xdc00
+			fmt.Fprintf(&b, "%04X%04X", r1, r2)
+			continue
+		}
+		fmt.Fprintf(&b, "%04X", r)
+	}
+	b.WriteString(">")
+	return b.String()
+}
+
+func pdfDate(t time.Time) string {
+	return "(D:" + t.UTC().Format("20060102150405") + "Z)"
+}
+
+// xmpPacket returns the XMP metadata of a PDF/A-3b Factur-X document,
+// matching the Info dictionary and declaring the Factur-X extension schema.
+func xmpPacket(meta pdfMetadata) []byte {
+	created := meta.Created.UTC().Format(time.RFC3339)
+	var b bytes.Buffer
+	b.WriteString("<?xpacket begin=\"\ufeff\" id=\"W5M0MpCehiHzreSzNTczkc9d\"?>\n")
+	b.WriteString(`<x:xmpmeta xmlns:x="adobe:ns:meta/">
+<rdf:RDF xmlns:rdf="http://www.w3.org/1999/02/22-rdf-syntax-ns#">
+<rdf:Description rdf:about="" xmlns:pdfaid="http://www.aiim.org/pdfa/ns/id/">
+<pdfaid:part>3</pdfaid:part>
+<pdfaid:conformance>B</pdfaid:conformance>
+</rdf:Description>
+<rdf:Description rdf:about="" xmlns:dc="http://purl.org/dc/elements/1.1/">
+<dc:title><rdf:Alt><rdf:li xml:lang="x-default">`)
+	xml.EscapeText(&b, []byte(meta.Title))
+	b.WriteString(`</rdf:li></rdf:Alt></dc:title>
+<dc:creator><rdf:Seq><rdf:li>`)
+	xml.EscapeText(&b, []byte(meta.Author))
+	b.WriteString(`</rdf:li></rdf:Seq></dc:creator>
+</rdf:Description>
+<rdf:Description rdf:about="" xmlns:pdf="http://ns.adobe.com/pdf/1.3/">
+<pdf:Producer>` + producer + `</pdf:Producer>
+</rdf:Description>
+<rdf:Description rdf:about="" xmlns:xmp="http://ns.adobe.com/xap/1.0/">
+<xmp:CreatorTool>` + producer + `</xmp:CreatorTool>
+<xmp:CreateDate>` + created + `</xmp:CreateDate>
+<xmp:ModifyDate>` + created + `</xmp:ModifyDate>
+</rdf:Description>
+<rdf:Description rdf:about="" xmlns:fx="urn:factur-x:pdfa:CrossIndustryDocument:invoice:1p0#">
+<fx:DocumentType>INVOICE</fx:DocumentType>
+<fx:DocumentFileName>` + FacturXFileName + `</fx:DocumentFileName>
+<fx:Version>1.0</fx:Version>
+<fx:ConformanceLevel>` + meta.Conformance + `</fx:ConformanceLevel>
+</rdf:Description>
+<rdf:Description rdf:about="" xmlns:pdfaExtension="http://www.aiim.org/pdfa/ns/extension/" xmlns:pdfaSchema="http://www.aiim.org/pdfa/ns/schema#" xmlns:pdfaProperty="http://www.aiim.org/pdfa/ns/property#">
+<pdfaExtension:schemas><rdf:Bag><rdf:li rdf:parseType="Resource">
+<pdfaSchema:schema>Factur-X PDFA Extension Schema</pdfaSchema:schema>
+<pdfaSchema:namespaceURI>urn:factur-x:pdfa:CrossIndustryDocument:invoice:1p0#</pdfaSchema:namespaceURI>
+<pdfaSchema:prefix>fx</pdfaSchema:prefix>
+<pdfaSchema:property><rdf:Seq>
+`)
+	for _, p := range [][2]string{
+		{"DocumentFileName", "The name of the embedded XML document"},
+		{"DocumentType", "The type of the hybrid document in capital letters, e.g. INVOICE or ORDER"},
+		{"Version", "The actual version of the standard applying to the embedded XML document"},
+		{"ConformanceLevel", "The conformance level of the embedded XML document"},
+	} {
+		b.WriteString(`<rdf:li rdf:parseType="Resource"><pdfaProperty:name>` + p[0] +
+			`</pdfaProperty:name><pdfaProperty:valueType>Text</pdfaProperty:valueType><pdfaProperty:category>external</pdfaProperty:category><pdfaProperty:description>` +
+			p[1] + "</pdfaProperty:description></rdf:li>\n")
+	}
+	b.WriteString(`</rdf:Seq></pdfaSchema:property>
+</rdf:li></rdf:Bag></pdfaExtension:schemas>
+</rdf:Description>
+</rdf:RDF>
+</x:xmpmeta>
+<?xpacket end="w"?>`)
+	return b.Bytes()
+}
@@ -0,0 +1,382 @@
+package einvoice
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+
+	facturnetesv2 "github.com/cnvergence/facturnetes/api/v2"
+	"gopkg.in/inf.v0"
+)
+
+// FatturaPANamespace is the namespace of the FatturaPA 1.2 invoice.
+const FatturaPANamespace = "http://ivaservizi.agenziaentrate.gov.it/docs/xsd/fatture/v1.2"
+
+// FatturaPA codes.
+const (
+	// fpaPrivate is the transmission format of invoices to private parties.
+	fpaPrivate = "FPR12"
+	// fpaNoCode addresses buyers without a channel, which receive the invoice
+	// at their PEC address or in their tax drawer.
+	fpaNoCode = "0000000"
+	// fpaForeign addresses buyers outside of Italy.
+	fpaForeign = "XXXXXXX"
+	// fpaForeignCAP is the CAP of addresses outside of Italy.
+	fpaForeignCAP = "00000"
+
+	fpaInvoice    = "TD01"
+	fpaAdvance    = "TD02"
+	fpaCreditNote = "TD04"
+
+	// fpaImmediate is the EsigibilitaIVA of VAT due at once.
+	fpaImmediate = "I"
+	// fpaFullPayment is the CondizioniPagamento of a payment in one go.
+	fpaFullPayment = "TP02"
+	// fpaTransfer is the ModalitaPagamento of a bank transfer.
+	fpaTransfer = "MP05"
+)
+
+// fpaCausaleLength is the length of a Causale, longer notes are split.
+const fpaCausaleLength = 200
+
+var (
+	fpaCAP = regexp.MustCompile(`^[0-9]{5}$`)
+	fpaBIC = regexp.MustCompile(`^[A-Z]{6}[A-Z2-9][A-NP-Z0-9]([A-Z0-9]{3})?$`)
+)
+
+type fpaInvoiceXML struct {
+	XMLName xml.Name  `xml:"p:FatturaElettronica"`
+	XMLNS   string    `xml:"xmlns:p,attr"`
+	Version string    `xml:"versione,attr"`
+	Header  fpaHeader `xml:"FatturaElettronicaHeader"`
+	Body    fpaBody   `xml:"FatturaElettronicaBody"`
+}
+
+type fpaHeader struct {
+	Transmission fpaTransmission `xml:"DatiTrasmissione"`
+	Seller       fpaSeller       `xml:"CedentePrestatore"`
+	Buyer        fpaBuyer        `xml:"CessionarioCommittente"`
+}
+
+type fpaTransmission struct {
+	Transmitter fpaTaxID `xml:"IdTrasmittente"`
+	Progressive string   `xml:"ProgressivoInvio"`
+	Format      string   `xml:"FormatoTrasmissione"`
+	Recipient   string   `xml:"CodiceDestinatario"`
+	PEC         string   `xml:"PECDestinatario,omitempty"`
+}
+
+type fpaTaxID struct {
+	Country string `xml:"IdPaese"`
+	Code    string `xml:"IdCodice"`
+}
+
+type fpaSeller struct {
+	VAT     fpaTaxID   `xml:"DatiAnagrafici>IdFiscaleIVA"`
+	TaxCode string     `xml:"DatiAnagrafici>CodiceFiscale,omitempty"`
+	Name    string     `xml:"DatiAnagrafici>Anagrafica>Denominazione"`
+	Regime  string     `xml:"DatiAnagrafici>RegimeFiscale"`
+	Address fpaAddress `xml:"Sede"`
+}
+
+type fpaBuyer struct {
+	VAT     *fpaTaxID  `xml:"DatiAnagrafici>IdFiscaleIVA"`
+	TaxCode string     `xml:"DatiAnagrafici>CodiceFiscale,omitempty"`
+	Name    string     `xml:"DatiAnagrafici>Anagrafica>Denominazione"`
+	Address fpaAddress `xml:"Sede"`
+}
+
+type fpaAddress struct {
+	Street   string `xml:"Indirizzo"`
+	CAP      string `xml:"CAP"`
+	City     string `xml:"Comune"`
+	Province string `xml:"Provincia,omitempty"`
+	Country  string `xml:"Nazione"`
+}
+
+type fpaBody struct {
+	Document fpaDocument  `xml:"DatiGenerali>DatiGeneraliDocumento"`
+	Linked   []fpaLinked  `xml:"DatiGenerali>DatiFattureCollegate"`
+	Lines    []fpaLine    `xml:"DatiBeniServizi>DettaglioLinee"`
+	Summary  []fpaSummary `xml:"DatiBeniServizi>DatiRiepilogo"`
+	Payment  *fpaPayment  `xml:"DatiPagamento"`
+}
+
+type fpaDocument struct {
+	Type     string   `xml:"TipoDocumento"`
+	Currency string   `xml:"Divisa"`
+	Date     string   `xml:"Data"`
+	Number   string   `xml:"Numero"`
+	Total    string   `xml:"ImportoTotaleDocumento"`
+	Causale  []string `xml:"Causale"`
+}
+
+type fpaLinked struct {
+	Number string `xml:"IdDocumento"`
+}
+
+type fpaLine struct {
+	Number   int    `xml:"NumeroLinea"`
+	Name     string `xml:"Descrizione"`
+	Quantity string `xml:"Quantita"`
+	Price    string `xml:"PrezzoUnitario"`
+	Total    string `xml:"PrezzoTotale"`
+	Rate     string `xml:"AliquotaIVA"`
+	Nature   string `xml:"Natura,omitempty"`
+}
+
+type fpaSummary struct {
+	Rate          string `xml:"AliquotaIVA"`
+	Nature        string `xml:"Natura,omitempty"`
+	Taxable       string `xml:"ImponibileImporto"`
+	Tax           string `xml:"Imposta"`
+	Chargeability string `xml:"EsigibilitaIVA,omitempty"`
+}
+
+type fpaPayment struct {
+	Terms  string           `xml:"CondizioniPagamento"`
+	Detail fpaPaymentDetail `xml:"DettaglioPagamento"`
+}
+
+type fpaPaymentDetail struct {
+	Method  string `xml:"ModalitaPagamento"`
+	DueDate string `xml:"DataScadenzaPagamento,omitempty"`
+	Amount  string `xml:"ImportoPagamento"`
+	IBAN    string `xml:"IBAN,omitempty"`
+	BIC     string `xml:"BIC,omitempty"`
+}
+
+// FatturaPA serializes the invoice as an Italian FatturaPA invoice to private
+// parties, FPR12. The seller must have a VAT number and a RegimeFiscale.
+func (inv *Invoice) FatturaPA(documentType facturnetesv2.DocumentType) ([]byte, error) {
+	if inv.Seller.TaxRegime == "" {
+		return nil, errors.New("FatturaPA invoices require the RegimeFiscale of the seller")
+	}
+	sellerVAT := fpaTaxIDOf(inv.Seller)
+	if sellerVAT == nil {
+		return nil, errors.New("FatturaPA invoices require the VAT number of the seller")
+	}
+	sellerAddress, err := fpaAddressOf(inv.Seller)
+	if err != nil {
+		return nil, err
+	}
+	buyerVAT := fpaTaxIDOf(inv.Buyer)
+	if buyerVAT == nil && inv.Buyer.TaxCode == "" {
+		return nil, fmt.Errorf("FatturaPA invoices require the VAT number or the codice fiscale of %s", inv.Buyer.Name)
+	}
+	buyerAddress, err := fpaAddressOf(inv.Buyer)
+	if err != nil {
+		return nil, err
+	}
+
+	doc := fpaInvoiceXML{
+		XMLNS:   FatturaPANamespace,
+		Version: fpaPrivate,
+		Header: fpaHeader{
+			Transmission: fpaTransmission{
+				Transmitter: *sellerVAT,
+				Progressive: fpaProgressive(inv.Number),
+				Format:      fpaPrivate,
+				Recipient:   fpaRecipientOf(inv.Buyer),
+				PEC:         inv.Buyer.PEC,
+			},
+			Seller: fpaSeller{
+				VAT:     *sellerVAT,
+				TaxCode: inv.Seller.TaxCode,
+				Name:    inv.Seller.Name,
+				Regime:  inv.Seller.TaxRegime,
+				Address: *sellerAddress,
+			},
+			Buyer: fpaBuyer{
+				VAT:     buyerVAT,
+				TaxCode: inv.Buyer.TaxCode,
+				Name:    inv.Buyer.Name,
+				Address: *buyerAddress,
+			},
+		},
+		Body: fpaBody{
+			Document: fpaDocument{
+				Type:     fpaTypeOf(documentType),
+				Currency: inv.Currency,
+				Date:     inv.IssueDate.Format(ublDate),
+				Number:   inv.Number,
+				Total:    fpaDecimal(inv.TaxInclusive, 2, 2),
+				Causale:  fpaCausale(inv.Note),
+			},
+		},
+	}
+	for _, number := range inv.Preceding {
+		doc.Body.Linked = append(doc.Body.Linked, fpaLinked{Number: number})
+	}
+	for i, line := range inv.Lines {
+		nature, err := inv.fpaNatureOf(line.VATRate)
+		if err != nil {
+			return nil, err
+		}
+		doc.Body.Lines = append(doc.Body.Lines, fpaLine{
+			Number:   i + 1,
+			Name:     line.Name,
+			Quantity: fpaDecimal(line.Quantity, 2, 8),
+			Price:    fpaDecimal(line.Price, 2, 8),
+			Total:    fpaDecimal(line.Net, 2, 8),
+			Rate:     fpaDecimal(line.VATRate, 2, 2),
+			Nature:   nature,
+		})
+	}
+	for _, vat := range inv.VAT {
+		nature, err := inv.fpaNatureOf(vat.Rate)
+		if err != nil {
+			return nil, err
+		}
+		summary := fpaSummary{
+			Rate:    fpaDecimal(vat.Rate, 2, 2),
+			Nature:  nature,
+			Taxable: fpaDecimal(vat.Taxable, 2, 2),
+			Tax:     fpaDecimal(vat.Tax, 2, 2),
+		}
+		if nature == "" {
+			summary.Chargeability = fpaImmediate
+		}
+		doc.Body.Summary = append(doc.Body.Summary, summary)
+	}
+
+	if inv.Payment != nil || !inv.DueDate.IsZero() {
+		detail := fpaPaymentDetail{Method: fpaTransfer, Amount: fpaDecimal(inv.Payable, 2, 2)}
+		if !inv.DueDate.IsZero() {
+			detail.DueDate = inv.DueDate.Format(ublDate)
+		}
+		if p := inv.Payment; p != nil {
+			if p.MeansCode == MeansSEPACreditTransfer {
+				detail.IBAN = p.Account
+			}
+			if fpaBIC.MatchString(p.Bank) {
+				detail.BIC = p.Bank
+			}
+		}
+		doc.Body.Payment = &fpaPayment{Terms: fpaFullPayment, Detail: detail}
+	}
+
+	out, err := xml.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), append(out, '\n')...), nil
+}
+
+func fpaTypeOf(documentType facturnetesv2.DocumentType) string {
+	switch documentType {
+	case facturnetesv2.DocumentAdvance:
+		return fpaAdvance
+	case facturnetesv2.DocumentCreditNote:
+		return fpaCreditNote
+	default:
+		return fpaInvoice
+	}
+}
+
+// fpaNatureOf returns the Natura of the amounts at the VAT rate, which only
+// zero-rated amounts have.
+func (inv *Invoice) fpaNatureOf(rate *inf.Dec) (string, error) {
+	if rate.Sign() != 0 {
+		return "", nil
+	}
+	if inv.ExemptionNature == "" {
+		return "", errors.New("FatturaPA zero-rated amounts require the Natura of the buyer")
+	}
+	return inv.ExemptionNature, nil
+}
+
+// fpaTaxIDOf splits the VAT number of the party into its country and code,
+// taking the country of the address when the number has no prefix. It is
+// nil when the party has no VAT number.
+func fpaTaxIDOf(p Party) *fpaTaxID {
+	vat := strings.ToUpper(strings.ReplaceAll(p.VAT, " ", ""))
+	if vat == "" {
+		return nil
+	}
+	if len(vat) > 2 && unicode.IsLetter(rune(vat[0])) && unicode.IsLetter(rune(vat[1])) {
+		return &fpaTaxID{Country: vat[:2], Code: vat[2:]}
+	}
+	return &fpaTaxID{Country: p.Address.CountryCode, Code: vat}
+}
+
+// fpaAddressOf returns the Sede of the party. Addresses outside of Italy
+// carry the CAP 00000.
+func fpaAddressOf(p Party) (*fpaAddress, error) {
+	a := p.Address
+	if len(a.Lines) == 0 || a.City == "" || a.CountryCode == "" {
+		return nil, fmt.Errorf("FatturaPA invoices require the street, city and country code of %s", p.Name)
+	}
+	address := &fpaAddress{
+		Street:   strings.Join(a.Lines, ", "),
+		CAP:      a.PostalCode,
+		City:     a.City,
+		Province: a.Province,
+		Country:  a.CountryCode,
+	}
+	if a.CountryCode != "IT" {
+		address.CAP, address.Province = fpaForeignCAP, ""
+	} else if !fpaCAP.MatchString(a.PostalCode) {
+		return nil, fmt.Errorf("FatturaPA invoices require the five-digit CAP of %s", p.Name)
+	}
+	return address, nil
+}
+
+// fpaRecipientOf returns the CodiceDestinatario of the buyer.
+func fpaRecipientOf(p Party) string {
+	switch {
+	case p.RecipientCode != "":
+		return p.RecipientCode
+	case p.Address.CountryCode != "IT":
+		return fpaForeign
+	default:
+		return fpaNoCode
+	}
+}
+
+// fpaProgressive returns the ProgressivoInvio of the invoice, the last ten
+// letters and digits of its number.
+func fpaProgressive(number string) string {
+	p := strings.Map(func(r rune) rune {
+		if r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			return r
+		}
+		return -1
+	}, number)
+	if len(p) > 10 {
+		p = p[len(p)-10:]
+	}
+	if p == "" {
+		p = "1"
+	}
+	return p
+}
+
+// fpaCausale splits the note into the Causale of at most 200 characters.
+func fpaCausale(note string) []string {
+	var causale []string
+	runes := []rune(strings.TrimSpace(note))
+	for len(runes) > 0 {
+		n := len(runes)
+		if n > fpaCausaleLength {
+			n = fpaCausaleLength
+		}
+		causale = append(causale, string(runes[:n]))
+		runes = runes[n:]
+	}
+	return causale
+}
+
+// fpaDecimal formats the number with at least min and at most max decimals.
+func fpaDecimal(d *inf.Dec, min, max inf.Scale) string {
+	scale := d.Scale()
+	if scale < min {
+		scale = min
+	} else if scale > max {
+		scale = max
+	}
+	return new(inf.Dec).Round(d, scale, inf.RoundHalfUp).String()
+}
@@ -0,0 +1,94 @@
+package einvoice
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+)
+
+// srgbProfile is the ICC profile of the sRGB output intent of PDF/A
+// documents, an ICC v2 display profile with the sRGB primaries adapted to
+// D50 and a 2.2 gamma.
+var srgbProfile = iccProfile("sRGB IEC61966-2.1 (gamma 2.2)", [3][3]float64{
+	{0.4361, 0.2225, 0.0139},
+	{0.3851, 0.7169, 0.0971},
+	{0.1431, 0.0606, 0.7141},
+}, 2.2)
+
+// iccProfile builds an RGB display profile from the D50 adapted XYZ of its
+// red, green and blue primaries and the gamma of its tone curves.
+func iccProfile(description string, primaries [3][3]float64, gamma float64) []byte {
+	type tag struct {
+		signature string
+		data      []byte
+	}
+	trc := iccCurve(gamma)
+	tags := []tag{
+		{"desc", iccDescription(description)},
+		{"cprt", iccText("No copyright, use freely")},
+		{"wtpt", iccXYZ(0.9642, 1.0, 0.8249)},
+		{"rXYZ", iccXYZ(primaries[0][0], primaries[0][1], primaries[0][2])},
+		{"gXYZ", iccXYZ(primaries[1][0], primaries[1][1], primaries[1][2])},
+		{"bXYZ", iccXYZ(primaries[2][0], primaries[2][1], primaries[2][2])},
+		{"rTRC", trc},
+		{"gTRC", trc},
+		{"bTRC", trc},
+	}
+
+	var table, data bytes.Buffer
+	offset := 128 + 4 + 12*len(tags)
+	binary.Write(&table, binary.BigEndian, uint32(len(tags)))
+	for _, t := range tags {
+		table.WriteString(t.signature)
+		binary.Write(&table, binary.BigEndian, uint32(offset+data.Len()))
+		binary.Write(&table, binary.BigEndian, uint32(len(t.data)))
+		data.Write(t.data)
+		for data.Len()%4 != 0 {
+			data.WriteByte(0)
+		}
+	}
+
+	header := make([]byte, 128)
+	binary.BigEndian.PutUint32(header[0:], uint32(128+table.Len()+data.Len()))
+	binary.BigEndian.PutUint32(header[8:], 0x02100000)
+	copy(header[12:], "mntr")
+	copy(header[16:], "RGB ")
+	copy(header[20:], "XYZ ")
+	for i, v := range []uint16{2022, 1, 1} {
+		binary.BigEndian.PutUint16(header[24+2*i:], v)
+	}
+	copy(header[36:], "acsp")
+	copy(header[68:], iccXYZ(0.9642, 1.0, 0.8249)[8:])
+
+	return append(append(header, table.Bytes()...), data.Bytes()...)
+}
+
+func iccXYZ(x, y, z float64) []byte {
+	b := make([]byte, 20)
+	copy(b, "XYZ ")
+	for i, v := range []float64{x, y, z} {
+		binary.BigEndian.PutUint32(b[8+4*i:], uint32(int32(math.Round(v*65536))))
+	}
+	return b
+}
+
+func iccCurve(gamma float64) []byte {
+	b := make([]byte, 14)
+	copy(b, "curv")
+	binary.BigEndian.PutUint32(b[8:], 1)
+	binary.BigEndian.PutUint16(b[12:], uint16(math.Round(gamma*256)))
+	return b
+}
+
+func iccText(text string) []byte {
+	return append(append([]byte("text\x00\x00\x00\x00"), text...), 0)
+}
+
+func iccDescription(text string) []byte {
+	b := []byte("desc\x00\x00\x00\x00")
+	b = binary.BigEndian.AppendUint32(b, uint32(len(text)+1))
+	b = append(append(b, text...), 0)
+	// No Unicode and no ScriptCode description.
+	b = append(b, make([]byte, 4+4+2+1+67)...)
+	return b
+}
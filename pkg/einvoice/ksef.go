@@ -0,0 +1,326 @@
+package einvoice
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	facturnetesv2 "github.com/cnvergence/facturnetes/api/v2"
+	"gopkg.in/inf.v0"
+)
+
+// KSeFNamespace is the namespace of the FA(2) structured invoice.
+const KSeFNamespace = "http://crd.gov.pl/wzor/2023/06/29/12648/"
+
+// KSeF invoice kinds.
+const (
+	ksefInvoice    = "VAT"
+	ksefAdvance    = "ZAL"
+	ksefSettlement = "ROZ"
+)
+
+// ksefPaymentTransfer is the payment form of a bank transfer.
+const ksefPaymentTransfer = "6"
+
+// euCountries maps the EU member states to their KodUE, which uses EL for Greece.
+var euCountries = map[string]string{
+	"AT": "AT", "BE": "BE", "BG": "BG", "CY": "CY", "CZ": "CZ", "DE": "DE", "DK": "DK",
+	"EE": "EE", "ES": "ES", "FI": "FI", "FR": "FR", "GR": "EL", "EL": "EL", "HR": "HR",
+	"HU": "HU", "IE": "IE", "IT": "IT", "LT": "LT", "LU": "LU", "LV": "LV", "MT": "MT",
+	"NL": "NL", "PT": "PT", "RO": "RO", "SE": "SE", "SI": "SI", "SK": "SK", "XI": "XI",
+}
+
+type ksefInvoiceXML struct {
+	XMLName xml.Name   `xml:"Faktura"`
+	XMLNS   string     `xml:"xmlns,attr"`
+	Header  ksefHeader `xml:"Naglowek"`
+	Seller  ksefSeller `xml:"Podmiot1"`
+	Buyer   ksefBuyer  `xml:"Podmiot2"`
+	Fa      ksefFa     `xml:"Fa"`
+}
+
+type ksefHeader struct {
+	FormCode   ksefFormCode `xml:"KodFormularza"`
+	Variant    int          `xml:"WariantFormularza"`
+	Created    string       `xml:"DataWytworzeniaFa"`
+	SystemInfo string       `xml:"SystemInfo"`
+}
+
+type ksefFormCode struct {
+	SystemCode    string `xml:"kodSystemowy,attr"`
+	SchemaVersion string `xml:"wersjaSchemy,attr"`
+	Value         string `xml:",chardata"`
+}
+
+type ksefSeller struct {
+	NIP     string      `xml:"DaneIdentyfikacyjne>NIP"`
+	Name    string      `xml:"DaneIdentyfikacyjne>Nazwa"`
+	Address ksefAddress `xml:"Adres"`
+}
+
+type ksefBuyer struct {
+	ID      ksefBuyerID  `xml:"DaneIdentyfikacyjne"`
+	Address *ksefAddress `xml:"Adres"`
+}
+
+type ksefBuyerID struct {
+	NIP     string `xml:"NIP,omitempty"`
+	EUCode  string `xml:"KodUE,omitempty"`
+	EUVAT   string `xml:"NrVatUE,omitempty"`
+	Country string `xml:"KodKraju,omitempty"`
+	ID      string `xml:"NrID,omitempty"`
+	NoID    string `xml:"BrakID,omitempty"`
+	Name    string `xml:"Nazwa,omitempty"`
+}
+
+type ksefAddress struct {
+	Country string `xml:"KodKraju"`
+	Line1   string `xml:"AdresL1"`
+	Line2   string `xml:"AdresL2,omitempty"`
+}
+
+type ksefFa struct {
+	Currency    string           `xml:"KodWaluty"`
+	IssueDate   string           `xml:"P_1"`
+	Number      string           `xml:"P_2"`
+	SaleDate    string           `xml:"P_6,omitempty"`
+	Amounts     []ksefAmount     // P_13_x and P_14_x in schema order.
+	Gross       string           `xml:"P_15"`
+	Annotations ksefAnnotations  `xml:"Adnotacje"`
+	Kind        string           `xml:"RodzajFaktury"`
+	Advances    []ksefAdvanceRef `xml:"FakturaZaliczkowa"`
+	Lines       []ksefLine       `xml:"FaWiersz"`
+	Payment     *ksefPayment     `xml:"Platnosc"`
+}
+
+type ksefAmount struct {
+	XMLName xml.Name
+	Value   string `xml:",chardata"`
+}
+
+type ksefAnnotations struct {
+	CashAccounting  int `xml:"P_16"`
+	SelfBilling     int `xml:"P_17"`
+	ReverseCharge   int `xml:"P_18"`
+	SplitPayment    int `xml:"P_18A"`
+	NoExemption     int `xml:"Zwolnienie>P_19N"`
+	NoNewTransport  int `xml:"NoweSrodkiTransportu>P_22N"`
+	SimplifiedChain int `xml:"P_23"`
+	NoMargin        int `xml:"PMarzy>P_PMarzyN"`
+}
+
+type ksefAdvanceRef struct {
+	NotInKSeF int    `xml:"NrKSeFZN"`
+	Number    string `xml:"NrFaZaliczkowej"`
+}
+
+type ksefLine struct {
+	Number   int    `xml:"NrWierszaFa"`
+	Name     string `xml:"P_7"`
+	Unit     string `xml:"P_8A"`
+	Quantity string `xml:"P_8B"`
+	Price    string `xml:"P_9A"`
+	Net      string `xml:"P_11"`
+	Rate     string `xml:"P_12"`
+}
+
+type ksefPayment struct {
+	DueDate string       `xml:"TerminPlatnosci>Termin,omitempty"`
+	Form    string       `xml:"FormaPlatnosci"`
+	Account *ksefAccount `xml:"RachunekBankowy"`
+}
+
+type ksefAccount struct {
+	Number string `xml:"NrRB"`
+	SWIFT  string `xml:"SWIFT,omitempty"`
+}
+
+// ksefRate is the FA(2) field of a VAT rate: the suffix of the P_13 and P_14
+// fields its amounts go to and its P_12 code.
+type ksefRate struct {
+	field string
+	code  string
+	// tax tells whether the rate has a P_14 VAT amount.
+	tax bool
+}
+
+// ksefFieldOrder lists the P_13 fields in the order of the schema.
+var ksefFieldOrder = []string{"1", "2", "3", "6_1"}
+
+// KSeF serializes the invoice as a Polish KSeF structured invoice of the
+// FA(2) schema. The seller must have a NIP.
+func (inv *Invoice) KSeF(documentType facturnetesv2.DocumentType) ([]byte, error) {
+	if inv.Seller.NIP == "" {
+		return nil, errors.New("KSeF invoices require the NIP of the seller")
+	}
+	seller := ksefAddressOf(inv.Seller)
+	if seller == nil {
+		return nil, errors.New("KSeF invoices require the address of the seller")
+	}
+
+	doc := ksefInvoiceXML{
+		XMLNS: KSeFNamespace,
+		Header: ksefHeader{
+			FormCode:   ksefFormCode{SystemCode: "FA (2)", SchemaVersion: "1-0E", Value: "FA"},
+			Variant:    2,
+			Created:    inv.IssueDate.UTC().Format(time.RFC3339),
+			SystemInfo: producer,
+		},
+		Seller: ksefSeller{NIP: inv.Seller.NIP, Name: inv.Seller.Name, Address: *seller},
+		Buyer:  ksefBuyer{ID: ksefBuyerIDOf(inv.Buyer), Address: ksefAddressOf(inv.Buyer)},
+		Fa: ksefFa{
+			Currency:  inv.Currency,
+			IssueDate: inv.IssueDate.Format(ublDate),
+			Number:    inv.Number,
+			Gross:     inv.Amount(inv.TaxInclusive),
+			Annotations: ksefAnnotations{
+				CashAccounting:  2,
+				SelfBilling:     2,
+				ReverseCharge:   2,
+				SplitPayment:    2,
+				NoExemption:     1,
+				NoNewTransport:  1,
+				SimplifiedChain: 2,
+				NoMargin:        1,
+			},
+			Kind: ksefKind(documentType),
+		},
+	}
+	if !inv.DeliveryDate.IsZero() {
+		doc.Fa.SaleDate = inv.DeliveryDate.Format(ublDate)
+	}
+
+	net, tax := map[string]*inf.Dec{}, map[string]*inf.Dec{}
+	taxed := map[string]bool{}
+	for _, vat := range inv.VAT {
+		rate, err := ksefRateOf(vat.Rate)
+		if err != nil {
+			return nil, err
+		}
+		if net[rate.field] == nil {
+			net[rate.field], tax[rate.field] = new(inf.Dec), new(inf.Dec)
+		}
+		net[rate.field].Add(net[rate.field], vat.Taxable)
+		tax[rate.field].Add(tax[rate.field], vat.Tax)
+		taxed[rate.field] = rate.tax
+	}
+	for _, field := range ksefFieldOrder {
+		if net[field] == nil {
+			continue
+		}
+		doc.Fa.Amounts = append(doc.Fa.Amounts, ksefAmount{XMLName: xml.Name{Local: "P_13_" + field}, Value: inv.Amount(net[field])})
+		if taxed[field] {
+			doc.Fa.Amounts = append(doc.Fa.Amounts, ksefAmount{XMLName: xml.Name{Local: "P_14_" + field}, Value: inv.Amount(tax[field])})
+		}
+	}
+
+	for _, number := range inv.Preceding {
+		doc.Fa.Advances = append(doc.Fa.Advances, ksefAdvanceRef{NotInKSeF: 1, Number: number})
+	}
+	for i, line := range inv.Lines {
+		rate, err := ksefRateOf(line.VATRate)
+		if err != nil {
+			return nil, err
+		}
+		doc.Fa.Lines = append(doc.Fa.Lines, ksefLine{
+			Number:   i + 1,
+			Name:     line.Name,
+			Unit:     "szt.",
+			Quantity: line.Quantity.String(),
+			Price:    line.Price.String(),
+			Net:      inv.Amount(line.Net),
+			Rate:     rate.code,
+		})
+	}
+
+	if inv.Payment != nil || !inv.DueDate.IsZero() {
+		payment := &ksefPayment{Form: ksefPaymentTransfer}
+		if !inv.DueDate.IsZero() {
+			payment.DueDate = inv.DueDate.Format(ublDate)
+		}
+		if inv.Payment != nil {
+			payment.Account = &ksefAccount{Number: inv.Payment.Account, SWIFT: inv.Payment.Bank}
+		}
+		doc.Fa.Payment = payment
+	}
+
+	out, err := xml.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), append(out, '\n')...), nil
+}
+
+func ksefKind(documentType facturnetesv2.DocumentType) string {
+	switch documentType {
+	case facturnetesv2.DocumentAdvance:
+		return ksefAdvance
+	case facturnetesv2.DocumentFinal:
+		return ksefSettlement
+	default:
+		return ksefInvoice
+	}
+}
+
+// ksefRateOf returns the FA(2) field of a VAT rate.
+func ksefRateOf(rate *inf.Dec) (ksefRate, error) {
+	code := new(inf.Dec).Round(rate, 0, inf.RoundHalfUp)
+	if code.Cmp(rate) != 0 {
+		return ksefRate{}, fmt.Errorf("VAT rate %s%% is not a KSeF rate", rate)
+	}
+	switch s := code.String(); s {
+	case "23", "22":
+		return ksefRate{field: "1", code: s, tax: true}, nil
+	case "8", "7":
+		return ksefRate{field: "2", code: s, tax: true}, nil
+	case "5":
+		return ksefRate{field: "3", code: s, tax: true}, nil
+	case "0":
+		return ksefRate{field: "6_1", code: s}, nil
+	default:
+		return ksefRate{}, fmt.Errorf("VAT rate %s%% is not a KSeF rate", rate)
+	}
+}
+
+// ksefAddressOf returns the address of the party, Polish when the party has
+// a NIP and no country. It is nil when the party has no address or its
+// country is not known.
+func ksefAddressOf(p Party) *ksefAddress {
+	country := p.Address.CountryCode
+	if country == "" && p.NIP != "" {
+		country = "PL"
+	}
+	if len(p.Address.Lines) == 0 || country == "" {
+		return nil
+	}
+	a := &ksefAddress{Country: country, Line1: p.Address.Lines[0]}
+	if len(p.Address.Lines) > 1 {
+		a.Line2 = p.Address.Lines[1]
+	} else if city := strings.TrimSpace(p.Address.PostalCode + " " + p.Address.City); city != "" {
+		a.Line2 = city
+	}
+	return a
+}
+
+// ksefBuyerIDOf identifies the buyer by NIP, by EU VAT number, by a foreign
+// tax number or as a buyer without identifier.
+func ksefBuyerIDOf(p Party) ksefBuyerID {
+	id := ksefBuyerID{Name: p.Name}
+	country := p.Address.CountryCode
+	vat := strings.ReplaceAll(p.VAT, " ", "")
+	switch eu, ok := euCountries[country]; {
+	case p.NIP != "":
+		id.NIP = p.NIP
+	case vat != "" && ok:
+		id.EUCode = eu
+		id.EUVAT = strings.TrimPrefix(strings.TrimPrefix(vat, country), eu)
+	case vat != "":
+		id.Country = country
+		id.ID = vat
+	default:
+		id.NoID = "1"
+	}
+	return id
+}
@@ -0,0 +1,114 @@
+package einvoice
+
+import (
+	"fmt"
+	"strings"
+
+	facturnetesv2 "github.com/cnvergence/facturnetes/api/v2"
+)
+
+// Specification identifiers of UBL CustomizationID and CII guideline, BT-24.
+const (
+	// CustomizationEN16931 identifies invoices following the core EN 16931 rules.
+	CustomizationEN16931 = "urn:cen.eu:en16931:2017"
+	// CustomizationXRechnung identifies the German XRechnung 3.0.
+	CustomizationXRechnung = "urn:cen.eu:en16931:2017#compliant#urn:xeinkauf.de:kosit:xrechnung_3.0"
+	// CustomizationPeppolBIS identifies the Peppol BIS Billing 3.0.
+	CustomizationPeppolBIS = "urn:cen.eu:en16931:2017#compliant#urn:fdc:peppol.eu:2017:poacc:billing:3.0"
+)
+
+// ProcessPeppolBilling is the Peppol billing business process, BT-23.
+const ProcessPeppolBilling = "urn:fdc:peppol.eu:2017:poacc:billing:01:1.0"
+
+// Profile is a specification of EN 16931, such as a CIUS, and the fields it
+// makes mandatory.
+type Profile struct {
+	Name            string
+	Customization   string
+	BusinessProcess string
+
+	buyerReference bool
+	endpoints      bool
+	countries      bool
+	cities         bool
+	sellerContact  bool
+	payment        bool
+}
+
+var (
+	// EN16931 is the core EN 16931 invoice.
+	EN16931 = Profile{Name: "EN 16931", Customization: CustomizationEN16931}
+	// XRechnung is the German CIUS for invoices to the public sector.
+	XRechnung = Profile{
+		Name:            "XRechnung",
+		Customization:   CustomizationXRechnung,
+		BusinessProcess: ProcessPeppolBilling,
+		buyerReference:  true,
+		endpoints:       true,
+		countries:       true,
+		cities:          true,
+		sellerContact:   true,
+		payment:         true,
+	}
+	// PeppolBIS is the Peppol BIS Billing 3.0 invoice.
+	PeppolBIS = Profile{
+		Name:            "Peppol BIS Billing 3.0",
+		Customization:   CustomizationPeppolBIS,
+		BusinessProcess: ProcessPeppolBilling,
+		buyerReference:  true,
+		endpoints:       true,
+		countries:       true,
+	}
+)
+
+// ProfileOf returns the profile selected for the invoice and whether the
+// invoice is written in CII rather than UBL.
+func ProfileOf(profile facturnetesv2.EInvoiceProfile) (p Profile, cii bool) {
+	switch profile {
+	case facturnetesv2.ProfileXRechnungUBL:
+		return XRechnung, false
+	case facturnetesv2.ProfileXRechnungCII:
+		return XRechnung, true
+	case facturnetesv2.ProfilePeppolBIS:
+		return PeppolBIS, false
+	default:
+		return EN16931, false
+	}
+}
+
+// check returns an error listing the fields the profile requires and the
+// invoice misses.
+func (p Profile) check(inv *Invoice) error {
+	var missing []string
+	require := func(required bool, value, name string) {
+		if required && value == "" {
+			missing = append(missing, name)
+		}
+	}
+	require(p.buyerReference, inv.BuyerReference, "buyer reference")
+	for _, party := range []struct {
+		role string
+		Party
+	}{{"seller", inv.Seller}, {"buyer", inv.Buyer}} {
+		require(p.endpoints, party.EndpointID, party.role+" electronic address")
+		require(p.countries, party.Address.CountryCode, party.role+" country code")
+		require(p.cities, party.Address.City, party.role+" city")
+		require(p.cities, party.Address.PostalCode, party.role+" postal code")
+	}
+	if p.sellerContact {
+		contact := inv.Seller.Contact
+		if contact == nil {
+			contact = &Contact{}
+		}
+		require(true, contact.Name, "seller contact name")
+		require(true, contact.Phone, "seller contact phone")
+		require(true, contact.Email, "seller contact email")
+	}
+	if p.payment && inv.Payment == nil {
+		missing = append(missing, "payment instructions")
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("%s invoice requires the %s", p.Name, strings.Join(missing, ", "))
+	}
+	return nil
+}
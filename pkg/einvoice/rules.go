@@ -0,0 +1,315 @@
+package einvoice
+
+import (
+	"fmt"
+	"strings"
+
+	"gopkg.in/inf.v0"
+)
+
+// Violation is a breach of an EN 16931 business rule.
+type Violation struct {
+	// Rule is the identifier of the rule, e.g. BR-CO-10.
+	Rule    string
+	Message string
+}
+
+func (v Violation) String() string {
+	return fmt.Sprintf("[%s] %s", v.Rule, v.Message)
+}
+
+// rule is an EN 16931 business rule, its check returns a message for every
+// place of the invoice breaking it.
+type rule struct {
+	id    string
+	check func(inv *Invoice) []string
+}
+
+// rules are the EN 16931 business rules the semantic model can break. Rules
+// on elements the model does not have, such as allowances and charges, are
+// left out.
+var rules = []rule{
+	{"BR-02", func(inv *Invoice) []string {
+		return require(inv.Number != "", "An Invoice shall have an Invoice number (BT-1).")
+	}},
+	{"BR-03", func(inv *Invoice) []string {
+		return require(!inv.IssueDate.IsZero(), "An Invoice shall have an Invoice issue date (BT-2).")
+	}},
+	{"BR-04", func(inv *Invoice) []string {
+		return require(inv.TypeCode != "", "An Invoice shall have an Invoice type code (BT-3).")
+	}},
+	{"BR-05", func(inv *Invoice) []string {
+		return require(inv.Currency != "", "An Invoice shall have an Invoice currency code (BT-5).")
+	}},
+	{"BR-06", func(inv *Invoice) []string {
+		return require(inv.Seller.Name != "", "An Invoice shall contain the Seller name (BT-27).")
+	}},
+	{"BR-07", func(inv *Invoice) []string {
+		return require(inv.Buyer.Name != "", "An Invoice shall contain the Buyer name (BT-44).")
+	}},
+	{"BR-09", func(inv *Invoice) []string {
+		return require(inv.Seller.Address.CountryCode != "", "The Seller postal address (BG-5) shall contain a Seller country code (BT-40).")
+	}},
+	{"BR-11", func(inv *Invoice) []string {
+		return require(inv.Buyer.Address.CountryCode != "", "The Buyer postal address (BG-8) shall contain a Buyer country code (BT-55).")
+	}},
+	{"BR-16", func(inv *Invoice) []string {
+		return require(len(inv.Lines) > 0, "An Invoice shall have at least one Invoice line (BG-25).")
+	}},
+	{"BR-21", forLines(func(inv *Invoice, i int, line Line) string {
+		if line.ID == "" {
+			return fmt.Sprintf("Invoice line %d shall have an Invoice line identifier (BT-126).", i+1)
+		}
+		return ""
+	})},
+	{"BR-22", forLines(func(inv *Invoice, i int, line Line) string {
+		if line.Quantity == nil {
+			return fmt.Sprintf("Invoice line %d shall have an Invoiced quantity (BT-129).", i+1)
+		}
+		return ""
+	})},
+	{"BR-24", forLines(func(inv *Invoice, i int, line Line) string {
+		if line.Net == nil {
+			return fmt.Sprintf("Invoice line %d shall have an Invoice line net amount (BT-131).", i+1)
+		}
+		return ""
+	})},
+	{"BR-25", forLines(func(inv *Invoice, i int, line Line) string {
+		if strings.TrimSpace(line.Name) == "" {
+			return fmt.Sprintf("Invoice line %d shall contain the Item name (BT-153).", i+1)
+		}
+		return ""
+	})},
+	{"BR-26", forLines(func(inv *Invoice, i int, line Line) string {
+		if line.Price == nil {
+			return fmt.Sprintf("Invoice line %d shall contain the Item net price (BT-146).", i+1)
+		}
+		return ""
+	})},
+	{"BR-27", forLines(func(inv *Invoice, i int, line Line) string {
+		if line.Price != nil && line.Price.Sign() < 0 {
+			return fmt.Sprintf("The Item net price (BT-146) %s of invoice line %d shall not be negative.", line.Price, i+1)
+		}
+		return ""
+	})},
+	{"BR-CO-09", func(inv *Invoice) []string {
+		var messages []string
+		for _, p := range []struct{ role, vat string }{{"Seller", inv.Seller.VAT}, {"Buyer", inv.Buyer.VAT}} {
+			if vat := strings.ReplaceAll(p.vat, " ", ""); vat != "" && !vatPrefixed(vat) {
+				messages = append(messages, fmt.Sprintf("The %s VAT identifier %s shall have a prefix of the ISO 3166-1 alpha-2 country code.", p.role, p.vat))
+			}
+		}
+		return messages
+	}},
+	{"BR-CO-10", func(inv *Invoice) []string {
+		return inv.requireEqual(inv.LineTotal, inv.sumLines(func(Line) bool { return true }),
+			"Sum of Invoice line net amount (BT-106) %s shall equal the sum of the Invoice line net amounts %s.")
+	}},
+	{"BR-CO-13", func(inv *Invoice) []string {
+		return inv.requireEqual(inv.TaxExclusive, inv.LineTotal,
+			"Invoice total amount without VAT (BT-109) %s shall equal the Sum of Invoice line net amount %s.")
+	}},
+	{"BR-CO-14", func(inv *Invoice) []string {
+		sum := new(inf.Dec)
+		for _, vat := range inv.VAT {
+			sum.Add(sum, inv.round(vat.Tax))
+		}
+		return inv.requireEqual(inv.Tax, sum,
+			"Invoice total VAT amount (BT-110) %s shall equal the sum of the VAT category tax amounts (BT-117) %s.")
+	}},
+	{"BR-CO-15", func(inv *Invoice) []string {
+		return inv.requireEqual(inv.TaxInclusive, new(inf.Dec).Add(inv.round(inv.TaxExclusive), inv.round(inv.Tax)),
+			"Invoice total amount with VAT (BT-112) %s shall equal the Invoice total amount without VAT plus the Invoice total VAT amount %s.")
+	}},
+	{"BR-CO-16", func(inv *Invoice) []string {
+		return inv.requireEqual(inv.Payable, new(inf.Dec).Sub(inv.round(inv.TaxInclusive), inv.round(inv.Prepaid)),
+			"Amount due for payment (BT-115) %s shall equal the Invoice total amount with VAT minus the Paid amount %s.")
+	}},
+	{"BR-CO-17", taxAmount("")},
+	{"BR-CO-18", func(inv *Invoice) []string {
+		return require(len(inv.VAT) > 0, "An Invoice shall at least have one VAT breakdown group (BG-23).")
+	}},
+	{"BR-CO-25", func(inv *Invoice) []string {
+		if inv.Payable != nil && inv.Payable.Sign() > 0 && inv.DueDate.IsZero() {
+			return []string{"In case the Amount due for payment (BT-115) is positive, either the Payment due date (BT-9) or the Payment terms (BT-20) shall be present."}
+		}
+		return nil
+	}},
+	{"BR-CO-26", func(inv *Invoice) []string {
+		return require(inv.Seller.VAT != "", "The Seller identifier (BT-29), the Seller legal registration identifier (BT-30) or the Seller VAT identifier (BT-31) shall be present.")
+	}},
+	{"BR-S-01", hasBreakdown(CategoryStandard,
+		"An Invoice with an Invoice line where the Invoiced item VAT category code (BT-151) is \"Standard rated\" shall contain at least one VAT breakdown (BG-23) with the VAT category code (BT-118) equal to \"Standard rated\".")},
+	{"BR-S-02", sellerVAT(CategoryStandard,
+		"An Invoice with an Invoice line where the Invoiced item VAT category code (BT-151) is \"Standard rated\" shall contain the Seller VAT identifier (BT-31).")},
+	{"BR-S-05", forLines(func(inv *Invoice, i int, line Line) string {
+		if line.VATCategory == CategoryStandard && line.VATRate.Sign() <= 0 {
+			return fmt.Sprintf("The Invoiced item VAT rate (BT-152) %s%% of \"Standard rated\" invoice line %d shall be greater than zero.", line.VATRate, i+1)
+		}
+		return ""
+	})},
+	{"BR-S-08", taxableSum(CategoryStandard)},
+	{"BR-S-09", taxAmount(CategoryStandard)},
+	{"BR-Z-01", hasBreakdown(CategoryZero,
+		"An Invoice with an Invoice line where the Invoiced item VAT category code (BT-151) is \"Zero rated\" shall contain exactly one VAT breakdown (BG-23) with the VAT category code (BT-118) equal to \"Zero rated\".")},
+	{"BR-Z-02", sellerVAT(CategoryZero,
+		"An Invoice with an Invoice line where the Invoiced item VAT category code (BT-151) is \"Zero rated\" shall contain the Seller VAT identifier (BT-31).")},
+	{"BR-Z-05", forLines(func(inv *Invoice, i int, line Line) string {
+		if line.VATCategory == CategoryZero && line.VATRate.Sign() != 0 {
+			return fmt.Sprintf("The Invoiced item VAT rate (BT-152) %s%% of \"Zero rated\" invoice line %d shall be 0.", line.VATRate, i+1)
+		}
+		return ""
+	})},
+	{"BR-Z-08", taxableSum(CategoryZero)},
+	{"BR-Z-09", forVAT(CategoryZero, func(inv *Invoice, vat VATBreakdown) string {
+		if vat.Tax.Sign() != 0 {
+			return fmt.Sprintf("The VAT category tax amount (BT-117) %s of \"Zero rated\" shall equal 0.", inv.Amount(vat.Tax))
+		}
+		return ""
+	})},
+}
+
+// Validate checks the invoice against the EN 16931 business rules and
+// returns the violations in the order of the rules.
+func (inv *Invoice) Validate() []Violation {
+	var violations []Violation
+	for _, r := range rules {
+		for _, message := range r.check(inv) {
+			violations = append(violations, Violation{Rule: r.id, Message: message})
+		}
+	}
+	return violations
+}
+
+func require(ok bool, message string) []string {
+	if ok {
+		return nil
+	}
+	return []string{message}
+}
+
+// forLines checks every invoice line, the check returns an empty message for
+// the lines keeping the rule.
+func forLines(check func(inv *Invoice, i int, line Line) string) func(inv *Invoice) []string {
+	return func(inv *Invoice) []string {
+		var messages []string
+		for i, line := range inv.Lines {
+			if message := check(inv, i, line); message != "" {
+				messages = append(messages, message)
+			}
+		}
+		return messages
+	}
+}
+
+// forVAT checks every VAT breakdown of the category, or all of them when the
+// category is empty.
+func forVAT(category string, check func(inv *Invoice, vat VATBreakdown) string) func(inv *Invoice) []string {
+	return func(inv *Invoice) []string {
+		var messages []string
+		for _, vat := range inv.VAT {
+			if category != "" && vat.Category != category {
+				continue
+			}
+			if message := check(inv, vat); message != "" {
+				messages = append(messages, message)
+			}
+		}
+		return messages
+	}
+}
+
+// hasBreakdown requires a VAT breakdown of the category when a line is of it.
+func hasBreakdown(category, message string) func(inv *Invoice) []string {
+	return func(inv *Invoice) []string {
+		if !inv.hasLines(category) {
+			return nil
+		}
+		for _, vat := range inv.VAT {
+			if vat.Category == category {
+				return nil
+			}
+		}
+		return []string{message}
+	}
+}
+
+// sellerVAT requires the VAT identifier of the seller when a line is of the
+// category.
+func sellerVAT(category, message string) func(inv *Invoice) []string {
+	return func(inv *Invoice) []string {
+		return require(!inv.hasLines(category) || inv.Seller.VAT != "", message)
+	}
+}
+
+// taxableSum requires the taxable amount of every VAT breakdown of the
+// category to equal the sum of the net amounts of its lines.
+func taxableSum(category string) func(inv *Invoice) []string {
+	return forVAT(category, func(inv *Invoice, vat VATBreakdown) string {
+		sum := inv.sumLines(func(line Line) bool {
+			return line.VATCategory == category && line.VATRate.Cmp(vat.Rate) == 0
+		})
+		if inv.Amount(vat.Taxable) != inv.Amount(sum) {
+			return fmt.Sprintf("The VAT category taxable amount (BT-116) %s at %s%% shall equal the sum of the Invoice line net amounts at that rate, %s.",
+				inv.Amount(vat.Taxable), vat.Rate, inv.Amount(sum))
+		}
+		return ""
+	})
+}
+
+// taxAmount requires the tax amount of every VAT breakdown of the category,
+// or of all of them when the category is empty, to equal its taxable amount
+// at its rate.
+func taxAmount(category string) func(inv *Invoice) []string {
+	return forVAT(category, func(inv *Invoice, vat VATBreakdown) string {
+		want := new(inf.Dec).Mul(inv.round(vat.Taxable), vat.Rate)
+		want.QuoRound(want, inf.NewDec(100, 0), inv.Scale, inf.RoundHalfUp)
+		if inv.Amount(vat.Tax) != inv.Amount(want) {
+			return fmt.Sprintf("The VAT category tax amount (BT-117) %s at %s%% shall equal the VAT category taxable amount multiplied by the VAT category rate, %s.",
+				inv.Amount(vat.Tax), vat.Rate, inv.Amount(want))
+		}
+		return ""
+	})
+}
+
+func (inv *Invoice) hasLines(category string) bool {
+	for _, line := range inv.Lines {
+		if line.VATCategory == category {
+			return true
+		}
+	}
+	return false
+}
+
+// sumLines returns the sum of the net amounts of the matching lines, each
+// rounded as it is written in the invoice.
+func (inv *Invoice) sumLines(match func(Line) bool) *inf.Dec {
+	sum := new(inf.Dec)
+	for _, line := range inv.Lines {
+		if line.Net != nil && match(line) {
+			sum.Add(sum, inv.round(line.Net))
+		}
+	}
+	return sum
+}
+
+// requireEqual requires the amounts to be equal as written in the invoice.
+// The message formats the amount and the amount it should be.
+func (inv *Invoice) requireEqual(amount, want *inf.Dec, message string) []string {
+	if amount == nil || want == nil || inv.Amount(amount) == inv.Amount(want) {
+		return nil
+	}
+	return []string{fmt.Sprintf(message, inv.Amount(amount), inv.Amount(want))}
+}
+
+func (inv *Invoice) round(d *inf.Dec) *inf.Dec {
+	if d == nil {
+		return new(inf.Dec)
+	}
+	return new(inf.Dec).Round(d, inv.Scale, inf.RoundHalfUp)
+}
+
+// vatPrefixed tells whether the VAT identifier starts with a country code.
+func vatPrefixed(vat string) bool {
+	return len(vat) > 2 && vat[0] >= 'A' && vat[0] <= 'Z' && vat[1] >= 'A' && vat[1] <= 'Z'
+}
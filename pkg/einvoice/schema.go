@@ -0,0 +1,82 @@
+package einvoice
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+)
+
+// Schemas of the structured invoices bundled under schema/, with the schemas
+// they import.
+const (
+	SchemaFA2       = "fa2/schemat.xsd"
+	SchemaFatturaPA = "fatturapa/Schema_del_file_xml_FatturaPA_v1.2.2.xsd"
+)
+
+//go:embed schema
+var schemas embed.FS
+
+// SchemaValidator checks the structured invoices against the bundled schemas
+// of their format with xmllint, without network access.
+type SchemaValidator struct {
+	// XMLLint is the path of the xmllint binary.
+	XMLLint string
+	// Dir is the directory the bundled schemas are written to.
+	Dir string
+}
+
+// NewSchemaValidator writes the bundled schemas to a temporary directory, as
+// xmllint resolves the imports of a schema from files, and returns a
+// SchemaValidator with the xmllint found in the PATH.
+func NewSchemaValidator() (*SchemaValidator, error) {
+	xmllint, err := exec.LookPath("xmllint")
+	if err != nil {
+		return nil, fmt.Errorf("schema validation requires xmllint: %w", err)
+	}
+	dir, err := os.MkdirTemp("", "facturnetes-schemas")
+	if err != nil {
+		return nil, err
+	}
+	root, err := fs.Sub(schemas, "schema")
+	if err != nil {
+		return nil, err
+	}
+	err = fs.WalkDir(root, ".", func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			return os.MkdirAll(filepath.Join(dir, path), 0o755)
+		}
+		data, err := fs.ReadFile(root, path)
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(filepath.Join(dir, path), data, 0o644)
+	})
+	if err != nil {
+		os.RemoveAll(dir)
+		return nil, fmt.Errorf("unable to write the bundled schemas: %w", err)
+	}
+	return &SchemaValidator{XMLLint: xmllint, Dir: dir}, nil
+}
+
+// Close removes the schemas written by NewSchemaValidator.
+func (v *SchemaValidator) Close() error {
+	return os.RemoveAll(v.Dir)
+}
+
+// Validate returns an error when the document is not valid against the
+// schema.
+func (v *SchemaValidator) Validate(schema string, doc []byte) error {
+	cmd := exec.Command(v.XMLLint, "--noout", "--nonet", "--schema", filepath.Join(v.Dir, schema), "-")
+	cmd.Stdin = bytes.NewReader(doc)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("document is not valid against %s: %w\n%s", schema, err, bytes.TrimSpace(out))
+	}
+	return nil
+}
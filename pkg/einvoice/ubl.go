@@ -0,0 +1,254 @@
+package einvoice
+
+import (
+	"encoding/xml"
+	"time"
+
+	"gopkg.in/inf.v0"
+)
+
+// UBL namespaces.
+const (
+	ublInvoiceNS = "urn:oasis:names:specification:ubl:schema:xsd:Invoice-2"
+	ublCACNS     = "urn:oasis:names:specification:ubl:schema:xsd:CommonAggregateComponents-2"
+	ublCBCNS     = "urn:oasis:names:specification:ubl:schema:xsd:CommonBasicComponents-2"
+)
+
+// UBL date format.
+const ublDate = "2006-01-02"
+
+type ublInvoice struct {
+	XMLName         xml.Name `xml:"Invoice"`
+	XMLNS           string   `xml:"xmlns,attr"`
+	CAC             string   `xml:"xmlns:cac,attr"`
+	CBC             string   `xml:"xmlns:cbc,attr"`
+	UBLVersionID    string   `xml:"cbc:UBLVersionID"`
+	CustomizationID string   `xml:"cbc:CustomizationID"`
+	ProfileID       string   `xml:"cbc:ProfileID,omitempty"`
+	ID              string   `xml:"cbc:ID"`
+	IssueDate       string   `xml:"cbc:IssueDate"`
+	DueDate         string   `xml:"cbc:DueDate,omitempty"`
+	TypeCode        string   `xml:"cbc:InvoiceTypeCode"`
+	Note            string   `xml:"cbc:Note,omitempty"`
+	Currency        string   `xml:"cbc:DocumentCurrencyCode"`
+	BuyerReference  string   `xml:"cbc:BuyerReference,omitempty"`
+
+	BillingReferences []ublBillingReference `xml:"cac:BillingReference"`
+	Supplier          ublParty              `xml:"cac:AccountingSupplierParty>cac:Party"`
+	Customer          ublParty              `xml:"cac:AccountingCustomerParty>cac:Party"`
+	Delivery          *ublDelivery          `xml:"cac:Delivery"`
+	PaymentMeans      *ublPaymentMeans      `xml:"cac:PaymentMeans"`
+	TaxTotal          ublTaxTotal           `xml:"cac:TaxTotal"`
+	MonetaryTotal     ublMonetaryTotal      `xml:"cac:LegalMonetaryTotal"`
+	Lines             []ublLine             `xml:"cac:InvoiceLine"`
+}
+
+type ublBillingReference struct {
+	ID string `xml:"cac:InvoiceDocumentReference>cbc:ID"`
+}
+
+type ublParty struct {
+	Endpoint    *ublEndpoint   `xml:"cbc:EndpointID"`
+	Address     ublAddress     `xml:"cac:PostalAddress"`
+	TaxScheme   *ublPartyTax   `xml:"cac:PartyTaxScheme"`
+	LegalEntity ublLegalEntity `xml:"cac:PartyLegalEntity"`
+	Contact     *ublContact    `xml:"cac:Contact"`
+}
+
+type ublEndpoint struct {
+	Scheme string `xml:"schemeID,attr"`
+	Value  string `xml:",chardata"`
+}
+
+type ublAddress struct {
+	Street     string `xml:"cbc:StreetName,omitempty"`
+	Additional string `xml:"cbc:AdditionalStreetName,omitempty"`
+	City       string `xml:"cbc:CityName,omitempty"`
+	PostalZone string `xml:"cbc:PostalZone,omitempty"`
+	Country    string `xml:"cac:Country>cbc:IdentificationCode,omitempty"`
+}
+
+type ublContact struct {
+	Name      string `xml:"cbc:Name,omitempty"`
+	Telephone string `xml:"cbc:Telephone,omitempty"`
+	Email     string `xml:"cbc:ElectronicMail,omitempty"`
+}
+
+type ublPartyTax struct {
+	CompanyID string `xml:"cbc:CompanyID"`
+	TaxScheme string `xml:"cac:TaxScheme>cbc:ID"`
+}
+
+type ublLegalEntity struct {
+	Name string `xml:"cbc:RegistrationName"`
+}
+
+type ublDelivery struct {
+	Date string `xml:"cbc:ActualDeliveryDate"`
+}
+
+type ublPaymentMeans struct {
+	Code    string              `xml:"cbc:PaymentMeansCode"`
+	PayeeID string              `xml:"cbc:PaymentID,omitempty"`
+	Account ublFinancialAccount `xml:"cac:PayeeFinancialAccount"`
+}
+
+type ublFinancialAccount struct {
+	ID     string `xml:"cbc:ID"`
+	Branch string `xml:"cac:FinancialInstitutionBranch>cbc:ID,omitempty"`
+}
+
+type ublAmount struct {
+	Currency string `xml:"currencyID,attr"`
+	Value    string `xml:",chardata"`
+}
+
+type ublQuantity struct {
+	Unit  string `xml:"unitCode,attr"`
+	Value string `xml:",chardata"`
+}
+
+type ublTaxTotal struct {
+	Amount    ublAmount        `xml:"cbc:TaxAmount"`
+	Subtotals []ublTaxSubtotal `xml:"cac:TaxSubtotal"`
+}
+
+type ublTaxSubtotal struct {
+	Taxable  ublAmount      `xml:"cbc:TaxableAmount"`
+	Amount   ublAmount      `xml:"cbc:TaxAmount"`
+	Category ublTaxCategory `xml:"cac:TaxCategory"`
+}
+
+type ublTaxCategory struct {
+	ID        string `xml:"cbc:ID"`
+	Percent   string `xml:"cbc:Percent"`
+	TaxScheme string `xml:"cac:TaxScheme>cbc:ID"`
+}
+
+type ublMonetaryTotal struct {
+	LineExtension ublAmount  `xml:"cbc:LineExtensionAmount"`
+	TaxExclusive  ublAmount  `xml:"cbc:TaxExclusiveAmount"`
+	TaxInclusive  ublAmount  `xml:"cbc:TaxInclusiveAmount"`
+	Prepaid       *ublAmount `xml:"cbc:PrepaidAmount"`
+	Payable       ublAmount  `xml:"cbc:PayableAmount"`
+}
+
+type ublLine struct {
+	ID            string         `xml:"cbc:ID"`
+	Quantity      ublQuantity    `xml:"cbc:InvoicedQuantity"`
+	LineExtension ublAmount      `xml:"cbc:LineExtensionAmount"`
+	Name          string         `xml:"cac:Item>cbc:Name"`
+	TaxCategory   ublTaxCategory `xml:"cac:Item>cac:ClassifiedTaxCategory"`
+	Price         ublAmount      `xml:"cac:Price>cbc:PriceAmount"`
+}
+
+// UBL serializes the invoice as an OASIS UBL 2.1 Invoice document following
+// the profile.
+func (inv *Invoice) UBL(profile Profile) ([]byte, error) {
+	if err := profile.check(inv); err != nil {
+		return nil, err
+	}
+	doc := ublInvoice{
+		XMLNS:           ublInvoiceNS,
+		CAC:             ublCACNS,
+		CBC:             ublCBCNS,
+		UBLVersionID:    "2.1",
+		CustomizationID: profile.Customization,
+		ProfileID:       profile.BusinessProcess,
+		ID:              inv.Number,
+		IssueDate:       ublDateOf(inv.IssueDate),
+		DueDate:         ublDateOf(inv.DueDate),
+		TypeCode:        inv.TypeCode,
+		Note:            inv.Note,
+		Currency:        inv.Currency,
+		BuyerReference:  inv.BuyerReference,
+		Supplier:        ublPartyOf(inv.Seller),
+		Customer:        ublPartyOf(inv.Buyer),
+		TaxTotal:        ublTaxTotal{Amount: inv.ublAmount(inv.Tax)},
+		MonetaryTotal: ublMonetaryTotal{
+			LineExtension: inv.ublAmount(inv.LineTotal),
+			TaxExclusive:  inv.ublAmount(inv.TaxExclusive),
+			TaxInclusive:  inv.ublAmount(inv.TaxInclusive),
+			Payable:       inv.ublAmount(inv.Payable),
+		},
+	}
+
+	for _, number := range inv.Preceding {
+		doc.BillingReferences = append(doc.BillingReferences, ublBillingReference{ID: number})
+	}
+	if !inv.DeliveryDate.IsZero() {
+		doc.Delivery = &ublDelivery{Date: ublDateOf(inv.DeliveryDate)}
+	}
+	if inv.Payment != nil {
+		doc.PaymentMeans = &ublPaymentMeans{
+			Code:    inv.Payment.MeansCode,
+			PayeeID: inv.Number,
+			Account: ublFinancialAccount{ID: inv.Payment.Account, Branch: inv.Payment.Bank},
+		}
+	}
+	for _, vat := range inv.VAT {
+		doc.TaxTotal.Subtotals = append(doc.TaxTotal.Subtotals, ublTaxSubtotal{
+			Taxable:  inv.ublAmount(vat.Taxable),
+			Amount:   inv.ublAmount(vat.Tax),
+			Category: ublTaxCategoryOf(vat.Category, vat.Rate),
+		})
+	}
+	if inv.Prepaid.Sign() != 0 {
+		prepaid := inv.ublAmount(inv.Prepaid)
+		doc.MonetaryTotal.Prepaid = &prepaid
+	}
+	for _, line := range inv.Lines {
+		doc.Lines = append(doc.Lines, ublLine{
+			ID:            line.ID,
+			Quantity:      ublQuantity{Unit: "C62", Value: line.Quantity.String()},
+			LineExtension: inv.ublAmount(line.Net),
+			Name:          line.Name,
+			TaxCategory:   ublTaxCategoryOf(line.VATCategory, line.VATRate),
+			Price:         ublAmount{Currency: inv.Currency, Value: line.Price.String()},
+		})
+	}
+
+	out, err := xml.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), append(out, '\n')...), nil
+}
+
+func (inv *Invoice) ublAmount(d *inf.Dec) ublAmount {
+	return ublAmount{Currency: inv.Currency, Value: inv.Amount(d)}
+}
+
+func ublPartyOf(p Party) ublParty {
+	party := ublParty{LegalEntity: ublLegalEntity{Name: p.Name}}
+	if p.EndpointID != "" {
+		party.Endpoint = &ublEndpoint{Scheme: p.EndpointScheme, Value: p.EndpointID}
+	}
+	party.Address.City = p.Address.City
+	party.Address.PostalZone = p.Address.PostalCode
+	party.Address.Country = p.Address.CountryCode
+	if len(p.Address.Lines) > 0 {
+		party.Address.Street = p.Address.Lines[0]
+	}
+	if len(p.Address.Lines) > 1 {
+		party.Address.Additional = p.Address.Lines[1]
+	}
+	if p.VAT != "" {
+		party.TaxScheme = &ublPartyTax{CompanyID: p.VAT, TaxScheme: "VAT"}
+	}
+	if c := p.Contact; c != nil {
+		party.Contact = &ublContact{Name: c.Name, Telephone: c.Phone, Email: c.Email}
+	}
+	return party
+}
+
+func ublTaxCategoryOf(category string, rate *inf.Dec) ublTaxCategory {
+	return ublTaxCategory{ID: category, Percent: rate.String(), TaxScheme: "VAT"}
+}
+
+func ublDateOf(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(ublDate)
+}
@@ -0,0 +1,106 @@
+// Package interest computes simple late-payment interest from tables of
+// interest rates changing over time.
+package interest
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	facturnetesv2 "github.com/cnvergence/facturnetes/api/v2"
+	"github.com/cnvergence/facturnetes/pkg/money"
+	"gopkg.in/inf.v0"
+)
+
+const day = 24 * time.Hour
+
+// Period is the interest on an amount for consecutive days with one rate.
+type Period struct {
+	Amount   *inf.Dec
+	From     time.Time
+	To       time.Time
+	Days     int64
+	Rate     *inf.Dec
+	Interest *inf.Dec
+}
+
+type rate struct {
+	from  time.Time
+	value *inf.Dec
+}
+
+// Table is a parsed InterestRateTable.
+type Table struct {
+	basis int64
+	rates []rate
+}
+
+// NewTable parses the rates of the table.
+func NewTable(spec *facturnetesv2.InterestRateTableSpec) (*Table, error) {
+	t := &Table{basis: 365}
+	if spec.DayCount == facturnetesv2.Actual360 {
+		t.basis = 360
+	}
+	for _, r := range spec.Rates {
+		from, err := facturnetesv2.ParseDate(r.From)
+		if err != nil {
+			return nil, fmt.Errorf("invalid date of rate %s: %w", r.Rate, err)
+		}
+		value, err := money.Parse(r.Rate)
+		if err != nil {
+			return nil, err
+		}
+		if value.Sign() < 0 {
+			return nil, fmt.Errorf("rate %s from %s is negative", r.Rate, r.From)
+		}
+		t.rates = append(t.rates, rate{from: from, value: value})
+	}
+	sort.Slice(t.rates, func(i, j int) bool { return t.rates[i].from.Before(t.rates[j].from) })
+	return t, nil
+}
+
+// Fixed returns a table with a single rate counted as Actual/365.
+func Fixed(value *inf.Dec) *Table {
+	return &Table{basis: 365, rates: []rate{{value: value}}}
+}
+
+// Simple returns the simple interest on the amount for the days after the
+// due date up to and including the payment date, split into the periods of
+// the rates in force. The interest of the periods is not rounded.
+func (t *Table) Simple(amount *inf.Dec, due, paid time.Time) ([]Period, error) {
+	var periods []Period
+	for start := due.AddDate(0, 0, 1); !start.After(paid); {
+		i := sort.Search(len(t.rates), func(i int) bool { return t.rates[i].from.After(start) }) - 1
+		if i < 0 {
+			return nil, fmt.Errorf("no interest rate in force on %s", start.Format(facturnetesv2.DateLayout))
+		}
+		end := paid
+		if i+1 < len(t.rates) && !t.rates[i+1].from.After(paid) {
+			end = t.rates[i+1].from.AddDate(0, 0, -1)
+		}
+
+		days := int64(end.Sub(start)/day) + 1
+		interest := new(inf.Dec).Mul(amount, t.rates[i].value)
+		interest.Mul(interest, inf.NewDec(days, 0))
+		interest.QuoRound(interest, inf.NewDec(t.basis*100, 0), amount.Scale()+10, inf.RoundHalfUp)
+		periods = append(periods, Period{
+			Amount:   amount,
+			From:     start,
+			To:       end,
+			Days:     days,
+			Rate:     t.rates[i].value,
+			Interest: interest,
+		})
+		start = end.AddDate(0, 0, 1)
+	}
+	return periods, nil
+}
+
+// Sum returns the interest of the periods rounded to the scale.
+func Sum(periods []Period, scale inf.Scale) *inf.Dec {
+	sum := new(inf.Dec)
+	for _, p := range periods {
+		sum.Add(sum, p.Interest)
+	}
+	return sum.Round(sum, scale, inf.RoundHalfUp)
+}
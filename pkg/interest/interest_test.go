@@ -0,0 +1,94 @@
+package interest
+
+import (
+	"testing"
+	"time"
+
+	facturnetesv2 "github.com/cnvergence/facturnetes/api/v2"
+	"github.com/cnvergence/facturnetes/pkg/money"
+)
+
+func date(value string) time.Time {
+	t, err := facturnetesv2.ParseDate(value)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func TestSimple(t *testing.T) {
+	rates := []facturnetesv2.InterestRate{
+		{From: "01-03-2022", Rate: "10"},
+		{From: "01-01-2022", Rate: "8"},
+	}
+
+	tests := []struct {
+		name     string
+		dayCount facturnetesv2.DayCount
+		due      string
+		paid     string
+		days     []int64
+		interest string
+		wantErr  bool
+	}{{
+		name:     "paid on time",
+		dayCount: facturnetesv2.Actual365,
+		due:      "14-02-2022",
+		paid:     "14-02-2022",
+		interest: "0.00",
+	}, {
+		name:     "one rate",
+		dayCount: facturnetesv2.Actual365,
+		due:      "14-01-2022",
+		paid:     "13-02-2022",
+		days:     []int64{30},
+		interest: "6.58",
+	}, {
+		name:     "rate change actual/365",
+		dayCount: facturnetesv2.Actual365,
+		due:      "14-02-2022",
+		paid:     "15-03-2022",
+		days:     []int64{14, 15},
+		interest: "7.18",
+	}, {
+		name:     "rate change actual/360",
+		dayCount: facturnetesv2.Actual360,
+		due:      "14-02-2022",
+		paid:     "15-03-2022",
+		days:     []int64{14, 15},
+		interest: "7.28",
+	}, {
+		name:     "no rate in force",
+		dayCount: facturnetesv2.Actual365,
+		due:      "14-12-2021",
+		paid:     "15-01-2022",
+		wantErr:  true,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			table, err := NewTable(&facturnetesv2.InterestRateTableSpec{DayCount: tt.dayCount, Rates: rates})
+			if err != nil {
+				t.Fatal(err)
+			}
+			amount, _ := money.Parse("1000.00")
+			periods, err := table.Simple(amount, date(tt.due), date(tt.paid))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Simple() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if len(periods) != len(tt.days) {
+				t.Fatalf("got %d periods, want %d", len(periods), len(tt.days))
+			}
+			for i, p := range periods {
+				if p.Days != tt.days[i] {
+					t.Errorf("period %d has %d days, want %d", i, p.Days, tt.days[i])
+				}
+			}
+			if got := money.Format(Sum(periods, 2), 2); got != tt.interest {
+				t.Errorf("interest = %s, want %s", got, tt.interest)
+			}
+		})
+	}
+}
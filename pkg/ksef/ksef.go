@@ -0,0 +1,400 @@
+// Package ksef is a client of the interactive API of KSeF, the Polish
+// e-invoicing clearance system.
+//
+// The client follows the flow of the KSeF online API with JSON bodies: it
+// opens a session in the context of the NIP of the seller by answering an
+// authorisation challenge with its token, sends the FA(2) invoices with the
+// session token and polls their status until KSeF assigns the KSeF number
+// and issues the UPO, the official acknowledgement of receipt. Sessions are
+// reused per NIP and opened again when they expire. The token never travels
+// in plain text: it is sent with the timestamp of the challenge, encrypted
+// with the public key of the Ministry of Finance published for the
+// environment.
+//
+// Mock is an in-process KSeF environment speaking the same API.
+package ksef
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Processing codes of a sent invoice.
+const (
+	// CodeReceived is returned while KSeF processes the invoice.
+	CodeReceived = 100
+	// CodeAccepted is returned once the invoice received its KSeF number.
+	CodeAccepted = 200
+	// Codes from CodeRejected on tell the invoice was rejected.
+	CodeRejected = 400
+)
+
+// sessionHeader carries the session token.
+const sessionHeader = "SessionToken"
+
+// sessionIdle is how long an unused session is kept, below the timeout of
+// KSeF sessions.
+const sessionIdle = 10 * time.Minute
+
+// ErrUnauthorized is returned when KSeF refuses the token or the session.
+var ErrUnauthorized = errors.New("ksef: unauthorized")
+
+// Client talks to a KSeF environment on behalf of the holder of a token.
+type Client struct {
+	url   string
+	token string
+	key   *rsa.PublicKey
+	http  *http.Client
+
+	mu       sync.Mutex
+	sessions map[string]*sessionSlot
+}
+
+// sessionSlot holds the session of a NIP. Its lock serialises opening the
+// session of the NIP without holding up the other NIPs.
+type sessionSlot struct {
+	mu      sync.Mutex
+	current *session
+}
+
+type session struct {
+	token     string
+	reference string
+	lastUsed  time.Time
+}
+
+// NewClient returns a client of the KSeF environment at url, authorised by
+// the token generated for the sellers in KSeF. The token is encrypted with
+// the public key of the environment.
+func NewClient(url, token string, key *rsa.PublicKey) *Client {
+	return &Client{
+		url:      strings.TrimSuffix(url, "/"),
+		token:    token,
+		key:      key,
+		http:     &http.Client{Timeout: 30 * time.Second},
+		sessions: map[string]*sessionSlot{},
+	}
+}
+
+// Status is the processing status of a sent invoice.
+type Status struct {
+	Code        int
+	Description string
+	// KSeFNumber is the number KSeF assigned to the accepted invoice.
+	KSeFNumber string
+	// UPO is the acknowledgement of receipt of the accepted invoice.
+	UPO []byte
+}
+
+// Pending tells whether KSeF still processes the invoice.
+func (s *Status) Pending() bool {
+	return s.Code < CodeAccepted || (s.Code > CodeAccepted && s.Code < CodeRejected)
+}
+
+// Rejected tells whether KSeF rejected the invoice.
+func (s *Status) Rejected() bool {
+	return s.Code >= CodeRejected
+}
+
+// Send sends the FA(2) invoice of the seller with the NIP and returns the
+// element reference number to poll its status with.
+func (c *Client) Send(ctx context.Context, nip string, invoice []byte) (string, error) {
+	hash := sha256.Sum256(invoice)
+	req := sendRequest{}
+	req.InvoiceHash.HashSHA = hashSHA{Algorithm: "SHA-256", Encoding: "Base64", Value: base64.StdEncoding.EncodeToString(hash[:])}
+	req.InvoiceHash.FileSize = len(invoice)
+	req.InvoicePayload = invoicePayload{Type: "plain", InvoiceBody: base64.StdEncoding.EncodeToString(invoice)}
+
+	resp := sendResponse{}
+	err := c.withSession(ctx, nip, func(token string) error {
+		return c.do(ctx, http.MethodPut, "/online/Invoice/Send", token, req, &resp)
+	})
+	if err != nil {
+		return "", fmt.Errorf("unable to send the invoice: %w", err)
+	}
+	return resp.ElementReferenceNumber, nil
+}
+
+// Status returns the processing status of the invoice sent by the seller
+// with the NIP.
+func (c *Client) Status(ctx context.Context, nip, reference string) (*Status, error) {
+	resp := statusResponse{}
+	err := c.withSession(ctx, nip, func(token string) error {
+		return c.do(ctx, http.MethodGet, "/online/Invoice/Status/"+reference, token, nil, &resp)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to get the status of invoice %s: %w", reference, err)
+	}
+	status := &Status{Code: resp.ProcessingCode, Description: resp.ProcessingDescription}
+	if resp.InvoiceStatus != nil {
+		status.KSeFNumber = resp.InvoiceStatus.KSeFReferenceNumber
+	}
+	if resp.UPO != "" {
+		if status.UPO, err = base64.StdEncoding.DecodeString(resp.UPO); err != nil {
+			return nil, fmt.Errorf("invalid UPO of invoice %s: %w", reference, err)
+		}
+	}
+	return status, nil
+}
+
+// withSession calls fn with the token of a session in the context of the
+// NIP. A session KSeF no longer accepts is opened again once.
+func (c *Client) withSession(ctx context.Context, nip string, fn func(token string) error) error {
+	s, err := c.session(ctx, nip)
+	if err != nil {
+		return err
+	}
+	err = fn(s.token)
+	if errors.Is(err, ErrUnauthorized) {
+		c.drop(nip, s)
+		if s, err = c.session(ctx, nip); err != nil {
+			return err
+		}
+		err = fn(s.token)
+	}
+	return err
+}
+
+// session returns the session of the NIP, opening one when it has none or
+// it was idle for too long.
+func (c *Client) session(ctx context.Context, nip string) (*session, error) {
+	slot := c.slot(nip)
+	slot.mu.Lock()
+	defer slot.mu.Unlock()
+	now := time.Now()
+	if s := slot.current; s != nil && now.Sub(s.lastUsed) < sessionIdle {
+		s.lastUsed = now
+		return s, nil
+	}
+
+	challenge := challengeResponse{}
+	if err := c.do(ctx, http.MethodPost, "/online/Session/AuthorisationChallenge", "",
+		challengeRequest{ContextIdentifier: identifier{Type: "onip", Identifier: nip}}, &challenge); err != nil {
+		return nil, fmt.Errorf("unable to get an authorisation challenge: %w", err)
+	}
+	token, err := encryptToken(c.key, c.token, challenge.Timestamp)
+	if err != nil {
+		return nil, err
+	}
+	init := initResponse{}
+	if err := c.do(ctx, http.MethodPost, "/online/Session/InitToken", "", initRequest{
+		Challenge:  challenge.Challenge,
+		Identifier: identifier{Type: "onip", Identifier: nip},
+		Token:      token,
+	}, &init); err != nil {
+		return nil, fmt.Errorf("unable to open a session: %w", err)
+	}
+
+	s := &session{token: init.SessionToken.Token, reference: init.ReferenceNumber, lastUsed: now}
+	slot.current = s
+	return s, nil
+}
+
+// slot returns the session slot of the NIP.
+func (c *Client) slot(nip string) *sessionSlot {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	slot := c.sessions[nip]
+	if slot == nil {
+		slot = &sessionSlot{}
+		c.sessions[nip] = slot
+	}
+	return slot
+}
+
+// encryptToken returns the token joined with the timestamp of the challenge
+// in milliseconds, encrypted with the public key of the environment and
+// encoded in Base64, as InitToken expects it.
+func encryptToken(key *rsa.PublicKey, token string, timestamp time.Time) (string, error) {
+	if key == nil {
+		return "", errors.New("ksef: no public key to encrypt the token with")
+	}
+	plain := token + "|" + strconv.FormatInt(timestamp.UnixMilli(), 10)
+	encrypted, err := rsa.EncryptPKCS1v15(rand.Reader, key, []byte(plain))
+	if err != nil {
+		return "", fmt.Errorf("unable to encrypt the token: %w", err)
+	}
+	return base64.StdEncoding.EncodeToString(encrypted), nil
+}
+
+// ParsePublicKey returns the RSA public key of a KSeF environment from its
+// PEM encoding, either a public key or the certificate carrying it.
+func ParsePublicKey(data []byte) (*rsa.PublicKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("ksef: public key is not PEM encoded")
+	}
+	var key interface{}
+	var err error
+	switch block.Type {
+	case "CERTIFICATE":
+		var cert *x509.Certificate
+		if cert, err = x509.ParseCertificate(block.Bytes); err == nil {
+			key = cert.PublicKey
+		}
+	default:
+		key, err = x509.ParsePKIXPublicKey(block.Bytes)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("ksef: invalid public key: %w", err)
+	}
+	rsaKey, ok := key.(*rsa.PublicKey)
+	if !ok {
+		return nil, errors.New("ksef: public key is not an RSA key")
+	}
+	return rsaKey, nil
+}
+
+// drop forgets the session of the NIP unless another one replaced it.
+func (c *Client) drop(nip string, s *session) {
+	slot := c.slot(nip)
+	slot.mu.Lock()
+	defer slot.mu.Unlock()
+	if slot.current == s {
+		slot.current = nil
+	}
+}
+
+func (c *Client) do(ctx context.Context, method, path, token string, in, out interface{}) error {
+	var body io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.url+path, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if token != "" {
+		req.Header.Set(sessionHeader, token)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode == http.StatusUnauthorized {
+		return ErrUnauthorized
+	}
+	if resp.StatusCode >= http.StatusBadRequest {
+		return apiError(resp.StatusCode, data)
+	}
+	return json.Unmarshal(data, out)
+}
+
+// apiError returns the exceptions reported by KSeF.
+func apiError(status int, data []byte) error {
+	resp := exceptionResponse{}
+	if err := json.Unmarshal(data, &resp); err != nil || len(resp.Exception.ExceptionDetailList) == 0 {
+		return fmt.Errorf("ksef: HTTP %d", status)
+	}
+	var details []string
+	for _, d := range resp.Exception.ExceptionDetailList {
+		details = append(details, fmt.Sprintf("%d %s", d.ExceptionCode, d.ExceptionDescription))
+	}
+	return fmt.Errorf("ksef: HTTP %d: %s", status, strings.Join(details, "; "))
+}
+
+type identifier struct {
+	Type       string `json:"type"`
+	Identifier string `json:"identifier"`
+}
+
+type challengeRequest struct {
+	ContextIdentifier identifier `json:"contextIdentifier"`
+}
+
+type challengeResponse struct {
+	Timestamp time.Time `json:"timestamp"`
+	Challenge string    `json:"challenge"`
+}
+
+type initRequest struct {
+	Challenge  string     `json:"challenge"`
+	Identifier identifier `json:"identifier"`
+	// Token is the encrypted token, see encryptToken.
+	Token string `json:"token"`
+}
+
+type initResponse struct {
+	ReferenceNumber string `json:"referenceNumber"`
+	SessionToken    struct {
+		Token string `json:"token"`
+	} `json:"sessionToken"`
+}
+
+type hashSHA struct {
+	Algorithm string `json:"algorithm"`
+	Encoding  string `json:"encoding"`
+	Value     string `json:"value"`
+}
+
+type invoicePayload struct {
+	Type        string `json:"type"`
+	InvoiceBody string `json:"invoiceBody"`
+}
+
+type sendRequest struct {
+	InvoiceHash struct {
+		HashSHA  hashSHA `json:"hashSHA"`
+		FileSize int     `json:"fileSize"`
+	} `json:"invoiceHash"`
+	InvoicePayload invoicePayload `json:"invoicePayload"`
+}
+
+type sendResponse struct {
+	ElementReferenceNumber string `json:"elementReferenceNumber"`
+	ProcessingCode         int    `json:"processingCode"`
+	ProcessingDescription  string `json:"processingDescription"`
+}
+
+type statusResponse struct {
+	ElementReferenceNumber string         `json:"elementReferenceNumber"`
+	ProcessingCode         int            `json:"processingCode"`
+	ProcessingDescription  string         `json:"processingDescription"`
+	InvoiceStatus          *invoiceStatus `json:"invoiceStatus,omitempty"`
+	UPO                    string         `json:"upo,omitempty"`
+}
+
+type invoiceStatus struct {
+	KSeFReferenceNumber string    `json:"ksefReferenceNumber"`
+	AcquisitionTime     time.Time `json:"acquisitionTimestamp"`
+}
+
+type exceptionResponse struct {
+	Exception struct {
+		ExceptionDetailList []exceptionDetail `json:"exceptionDetailList"`
+	} `json:"exception"`
+}
+
+type exceptionDetail struct {
+	ExceptionCode        int    `json:"exceptionCode"`
+	ExceptionDescription string `json:"exceptionDescription"`
+}
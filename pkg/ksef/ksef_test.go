@@ -0,0 +1,199 @@
+package ksef
+
+import (
+	"bytes"
+	"context"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const token = "0123456789ABCDEF"
+
+// invoice returns the FA(2) invoice of the einvoice golden files.
+func invoice(t *testing.T) []byte {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("..", "einvoice", "testdata", "invoice-sample.ksef.xml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestSendAndPoll(t *testing.T) {
+	mock := NewMock(token)
+	mock.Polls = 2
+	server := httptest.NewServer(mock)
+	defer server.Close()
+	client := NewClient(server.URL, token, mock.PublicKey())
+	ctx := context.Background()
+
+	ref, err := client.Send(ctx, "2222222222", invoice(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < mock.Polls; i++ {
+		status, err := client.Status(ctx, "2222222222", ref)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !status.Pending() {
+			t.Fatalf("poll %d: status %d, want pending", i+1, status.Code)
+		}
+	}
+	status, err := client.Status(ctx, "2222222222", ref)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.Code != CodeAccepted || status.Pending() || status.Rejected() {
+		t.Fatalf("status %d %s, want accepted", status.Code, status.Description)
+	}
+	if !strings.HasPrefix(status.KSeFNumber, "2222222222-") {
+		t.Errorf("KSeF number %q does not start with the NIP", status.KSeFNumber)
+	}
+	if !bytes.Contains(status.UPO, []byte("<NumerKSeFDokumentu>"+status.KSeFNumber+"</NumerKSeFDokumentu>")) {
+		t.Errorf("UPO does not contain the KSeF number:\n%s", status.UPO)
+	}
+	if n := mock.Sessions(); n != 1 {
+		t.Errorf("opened %d sessions, want 1", n)
+	}
+}
+
+func TestExpiredSession(t *testing.T) {
+	mock := NewMock(token)
+	server := httptest.NewServer(mock)
+	defer server.Close()
+	client := NewClient(server.URL, token, mock.PublicKey())
+	ctx := context.Background()
+
+	ref, err := client.Send(ctx, "2222222222", invoice(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	mock.ExpireSessions()
+	if _, err := client.Status(ctx, "2222222222", ref); err != nil {
+		t.Fatal(err)
+	}
+	if n := mock.Sessions(); n != 2 {
+		t.Errorf("opened %d sessions, want 2", n)
+	}
+}
+
+func TestSessionsPerNIP(t *testing.T) {
+	mock := NewMock(token)
+	mock.Polls = 0
+	opening, release := make(chan struct{}), make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		// Hold up opening the session of the other NIP.
+		if strings.HasSuffix(r.URL.Path, "/AuthorisationChallenge") && bytes.Contains(body, []byte("1111111111")) {
+			close(opening)
+			<-release
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		mock.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+	client := NewClient(server.URL, token, mock.PublicKey())
+	ctx := context.Background()
+
+	held := make(chan error, 1)
+	go func() {
+		_, err := client.Send(ctx, "1111111111", invoice(t))
+		held <- err
+	}()
+	<-opening
+	sent := make(chan error, 1)
+	go func() {
+		_, err := client.Send(ctx, "2222222222", invoice(t))
+		sent <- err
+	}()
+	select {
+	case err := <-sent:
+		if err != nil {
+			t.Error(err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Error("Send() waited for the session of another NIP")
+	}
+	close(release)
+	if err := <-held; err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRejected(t *testing.T) {
+	mock := NewMock(token)
+	mock.Polls = 0
+	server := httptest.NewServer(mock)
+	defer server.Close()
+	client := NewClient(server.URL, token, mock.PublicKey())
+	ctx := context.Background()
+
+	tests := []struct {
+		name string
+		nip  string
+	}{
+		{name: "accepted", nip: "2222222222"},
+		{name: "duplicate", nip: "2222222222"},
+		{name: "NIP of another seller", nip: "1111111111"},
+	}
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ref, err := client.Send(ctx, tt.nip, invoice(t))
+			if err != nil {
+				t.Fatal(err)
+			}
+			status, err := client.Status(ctx, tt.nip, ref)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got, want := status.Rejected(), i > 0; got != want {
+				t.Errorf("rejected = %v, want %v: %d %s", got, want, status.Code, status.Description)
+			}
+		})
+	}
+}
+
+func TestUnauthorized(t *testing.T) {
+	mock := NewMock(token)
+	server := httptest.NewServer(mock)
+	defer server.Close()
+
+	_, err := NewClient(server.URL, "wrong", mock.PublicKey()).Send(context.Background(), "2222222222", invoice(t))
+	if !errors.Is(err, ErrUnauthorized) {
+		t.Errorf("Send() error = %v, want %v", err, ErrUnauthorized)
+	}
+
+	other := NewMock(token)
+	_, err = NewClient(server.URL, token, other.PublicKey()).Send(context.Background(), "2222222222", invoice(t))
+	if !errors.Is(err, ErrUnauthorized) {
+		t.Errorf("Send() with the key of another environment error = %v, want %v", err, ErrUnauthorized)
+	}
+}
+
+func TestParsePublicKey(t *testing.T) {
+	mock := NewMock(token)
+	der, err := x509.MarshalPKIXPublicKey(mock.PublicKey())
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := ParsePublicKey(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !key.Equal(mock.PublicKey()) {
+		t.Error("parsed key differs from the public key of the mock")
+	}
+	if _, err := ParsePublicKey([]byte("not a key")); err == nil {
+		t.Error("ParsePublicKey() accepted data without a PEM block")
+	}
+}
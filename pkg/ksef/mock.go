@@ -0,0 +1,330 @@
+package ksef
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// faNamespace is the namespace of the FA(2) invoices the mock accepts.
+const faNamespace = "http://crd.gov.pl/wzor/2023/06/29/12648/"
+
+// upoNamespace is the namespace of the UPO issued by the mock.
+const upoNamespace = "http://ksef.mf.gov.pl/schema/gtw/svc/online/types/2021/10/01/0001"
+
+// Mock is an in-process KSeF environment to submit invoices without a
+// network, serve it with httptest.NewServer in tests or run cmd/ksef-mock.
+// It accepts the sessions opened with its token encrypted with PublicKey,
+// keeps every invoice in processing for Polls status requests and then
+// accepts it, unless it is not an FA(2) invoice of the NIP of the session or
+// repeats the number of an invoice already accepted.
+type Mock struct {
+	// Token is the only token accepted.
+	Token string
+	// Polls is the number of status requests an invoice stays in processing.
+	Polls int
+
+	key        *rsa.PrivateKey
+	mu         sync.Mutex
+	seq        int
+	opened     int
+	challenges map[string]mockChallenge
+	sessions   map[string]*mockSession
+	invoices   map[string]*mockInvoice
+	numbers    map[string]bool
+}
+
+type mockChallenge struct {
+	nip       string
+	timestamp time.Time
+}
+
+type mockSession struct {
+	nip       string
+	reference string
+}
+
+type mockInvoice struct {
+	session  *mockSession
+	number   string
+	hash     string
+	received time.Time
+	polls    int
+	status   statusResponse
+}
+
+// NewMock returns a KSeF environment accepting the token, processing every
+// invoice for one status request. The environment has its own key pair.
+func NewMock(token string) *Mock {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		panic(err)
+	}
+	return &Mock{
+		Token:      token,
+		Polls:      1,
+		key:        key,
+		challenges: map[string]mockChallenge{},
+		sessions:   map[string]*mockSession{},
+		invoices:   map[string]*mockInvoice{},
+		numbers:    map[string]bool{},
+	}
+}
+
+// PublicKey returns the public key the token is encrypted with.
+func (m *Mock) PublicKey() *rsa.PublicKey {
+	return &m.key.PublicKey
+}
+
+// ExpireSessions terminates every open session, as KSeF does after a
+// timeout.
+func (m *Mock) ExpireSessions() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.sessions = map[string]*mockSession{}
+}
+
+// Sessions returns the number of sessions opened so far.
+func (m *Mock) Sessions() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.opened
+}
+
+func (m *Mock) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	switch {
+	case r.Method == http.MethodPost && r.URL.Path == "/online/Session/AuthorisationChallenge":
+		m.challenge(w, r)
+	case r.Method == http.MethodPost && r.URL.Path == "/online/Session/InitToken":
+		m.initToken(w, r)
+	case r.Method == http.MethodPut && r.URL.Path == "/online/Invoice/Send":
+		if s := m.authorize(w, r); s != nil {
+			m.send(w, r, s)
+		}
+	case r.Method == http.MethodGet && strings.HasPrefix(r.URL.Path, "/online/Invoice/Status/"):
+		if s := m.authorize(w, r); s != nil {
+			m.status(w, s, strings.TrimPrefix(r.URL.Path, "/online/Invoice/Status/"))
+		}
+	default:
+		exception(w, http.StatusNotFound, 21101, "Nieprawidłowe żądanie.")
+	}
+}
+
+func (m *Mock) challenge(w http.ResponseWriter, r *http.Request) {
+	req := challengeRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.ContextIdentifier.Type != "onip" {
+		exception(w, http.StatusBadRequest, 21405, "Błąd walidacji danych wejściowych.")
+		return
+	}
+	now := time.Now().UTC()
+	challenge := fmt.Sprintf("%s-CR-%s", now.Format("20060102-150405"), randomHex(5))
+	m.challenges[challenge] = mockChallenge{nip: req.ContextIdentifier.Identifier, timestamp: now}
+	writeJSON(w, http.StatusCreated, challengeResponse{Timestamp: now, Challenge: challenge})
+}
+
+func (m *Mock) initToken(w http.ResponseWriter, r *http.Request) {
+	req := initRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		exception(w, http.StatusBadRequest, 21405, "Błąd walidacji danych wejściowych.")
+		return
+	}
+	challenge, ok := m.challenges[req.Challenge]
+	// A challenge is answered once.
+	delete(m.challenges, req.Challenge)
+	if !ok || challenge.nip != req.Identifier.Identifier || !m.validToken(req.Token, challenge.timestamp) {
+		exception(w, http.StatusUnauthorized, 21301, "Brak autoryzacji.")
+		return
+	}
+	nip := challenge.nip
+
+	m.seq++
+	m.opened++
+	s := &mockSession{nip: nip, reference: m.reference("SO")}
+	token := randomHex(32)
+	m.sessions[token] = s
+	resp := initResponse{ReferenceNumber: s.reference}
+	resp.SessionToken.Token = token
+	writeJSON(w, http.StatusCreated, resp)
+}
+
+// validToken tells whether the encrypted token is the token of the mock
+// joined with the timestamp of the challenge.
+func (m *Mock) validToken(encrypted string, timestamp time.Time) bool {
+	data, err := base64.StdEncoding.DecodeString(encrypted)
+	if err != nil {
+		return false
+	}
+	plain, err := rsa.DecryptPKCS1v15(rand.Reader, m.key, data)
+	return err == nil && string(plain) == m.Token+"|"+strconv.FormatInt(timestamp.UnixMilli(), 10)
+}
+
+func (m *Mock) authorize(w http.ResponseWriter, r *http.Request) *mockSession {
+	s := m.sessions[r.Header.Get(sessionHeader)]
+	if s == nil {
+		exception(w, http.StatusUnauthorized, 21301, "Brak autoryzacji.")
+	}
+	return s
+}
+
+func (m *Mock) send(w http.ResponseWriter, r *http.Request, s *mockSession) {
+	req := sendRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.InvoicePayload.Type != "plain" {
+		exception(w, http.StatusBadRequest, 21405, "Błąd walidacji danych wejściowych.")
+		return
+	}
+	body, err := base64.StdEncoding.DecodeString(req.InvoicePayload.InvoiceBody)
+	hash := sha256.Sum256(body)
+	if err != nil || len(body) != req.InvoiceHash.FileSize ||
+		base64.StdEncoding.EncodeToString(hash[:]) != req.InvoiceHash.HashSHA.Value {
+		exception(w, http.StatusBadRequest, 21164, "Faktura o podanym skrócie i rozmiarze nie zgadza się z treścią.")
+		return
+	}
+
+	m.seq++
+	ref := m.reference("EE")
+	inv := &mockInvoice{session: s, hash: req.InvoiceHash.HashSHA.Value, received: time.Now().UTC()}
+	inv.status = statusResponse{ElementReferenceNumber: ref, ProcessingCode: CodeReceived, ProcessingDescription: "Dokument przyjęty do dalszego przetwarzania"}
+	inv.number, err = m.check(s, body)
+	if err != nil {
+		inv.status.ProcessingCode = 450
+		inv.status.ProcessingDescription = "Błąd weryfikacji semantyki dokumentu faktury: " + err.Error()
+	}
+	m.invoices[ref] = inv
+	writeJSON(w, http.StatusAccepted, sendResponse{
+		ElementReferenceNumber: ref,
+		ProcessingCode:         CodeReceived,
+		ProcessingDescription:  "Dokument przyjęty do dalszego przetwarzania",
+	})
+}
+
+// check returns the number of the FA(2) invoice, issued by the NIP of the
+// session.
+func (m *Mock) check(s *mockSession, body []byte) (string, error) {
+	fa := struct {
+		XMLName xml.Name
+		NIP     string `xml:"Podmiot1>DaneIdentyfikacyjne>NIP"`
+		Number  string `xml:"Fa>P_2"`
+	}{}
+	if err := xml.Unmarshal(body, &fa); err != nil {
+		return "", err
+	}
+	switch {
+	case fa.XMLName.Space != faNamespace || fa.XMLName.Local != "Faktura":
+		return "", fmt.Errorf("not an FA(2) invoice")
+	case fa.NIP != s.nip:
+		return "", fmt.Errorf("seller NIP %s is not the NIP %s of the session", fa.NIP, s.nip)
+	case fa.Number == "":
+		return "", fmt.Errorf("invoice has no number")
+	}
+	return fa.Number, nil
+}
+
+func (m *Mock) status(w http.ResponseWriter, s *mockSession, ref string) {
+	inv := m.invoices[ref]
+	if inv == nil || inv.session.nip != s.nip {
+		exception(w, http.StatusNotFound, 21164, "Faktura o podanym identyfikatorze nie istnieje.")
+		return
+	}
+	if inv.status.ProcessingCode == CodeReceived && inv.polls >= m.Polls {
+		key := s.nip + "/" + inv.number
+		if m.numbers[key] {
+			inv.status.ProcessingCode = 440
+			inv.status.ProcessingDescription = "Duplikat faktury."
+		} else {
+			m.numbers[key] = true
+			m.accept(inv)
+		}
+	}
+	inv.polls++
+	writeJSON(w, http.StatusOK, inv.status)
+}
+
+// accept assigns the KSeF number to the invoice and issues its UPO.
+func (m *Mock) accept(inv *mockInvoice) {
+	m.seq++
+	number := fmt.Sprintf("%s-%s-%012X", inv.session.nip, inv.received.Format("20060102"), m.seq)
+	number += fmt.Sprintf("-%02X", crc8([]byte(number)))
+	inv.status.ProcessingCode = CodeAccepted
+	inv.status.ProcessingDescription = "Dokument został poprawnie przetworzony"
+	inv.status.InvoiceStatus = &invoiceStatus{KSeFReferenceNumber: number, AcquisitionTime: inv.received}
+
+	var upo bytes.Buffer
+	upo.WriteString(xml.Header)
+	fmt.Fprintf(&upo, "<Potwierdzenie xmlns=%q>\n", upoNamespace)
+	for _, e := range [][2]string{
+		{"NazwaPodmiotuPrzyjmujacego", "Ministerstwo Finansów"},
+		{"NumerReferencyjnySesji", inv.session.reference},
+		{"IdentyfikatorKontekstu", inv.session.nip},
+	} {
+		upo.WriteString("  <" + e[0] + ">")
+		xml.EscapeText(&upo, []byte(e[1]))
+		upo.WriteString("</" + e[0] + ">\n")
+	}
+	upo.WriteString("  <Dokument>\n")
+	for _, e := range [][2]string{
+		{"NumerKSeFDokumentu", number},
+		{"NumerFaktury", inv.number},
+		{"NipSprzedawcy", inv.session.nip},
+		{"DataPrzeslania", inv.received.Format(time.RFC3339)},
+		{"SkrotDokumentu", inv.hash},
+	} {
+		upo.WriteString("    <" + e[0] + ">")
+		xml.EscapeText(&upo, []byte(e[1]))
+		upo.WriteString("</" + e[0] + ">\n")
+	}
+	upo.WriteString("  </Dokument>\n</Potwierdzenie>\n")
+	inv.status.UPO = base64.StdEncoding.EncodeToString(upo.Bytes())
+}
+
+// reference returns a KSeF reference number of the given kind.
+func (m *Mock) reference(kind string) string {
+	return fmt.Sprintf("%s-%s-%s-%010X-%02X", time.Now().UTC().Format("20060102"), kind, randomHex(5), m.seq, m.seq%256)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func exception(w http.ResponseWriter, status, code int, description string) {
+	resp := exceptionResponse{}
+	resp.Exception.ExceptionDetailList = []exceptionDetail{{ExceptionCode: code, ExceptionDescription: description}}
+	writeJSON(w, status, resp)
+}
+
+func randomHex(n int) string {
+	b := make([]byte, n)
+	rand.Read(b)
+	return strings.ToUpper(hex.EncodeToString(b))
+}
+
+// crc8 is the checksum closing a KSeF number.
+func crc8(data []byte) byte {
+	var crc byte
+	for _, b := range data {
+		crc ^= b
+		for i := 0; i < 8; i++ {
+			if crc&0x80 != 0 {
+				crc = crc<<1 ^ 0x07
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+	return crc
+}
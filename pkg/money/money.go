@@ -0,0 +1,201 @@
+// Package money computes invoice amounts with exact decimal arithmetic.
+package money
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	facturnetesv2 "github.com/cnvergence/facturnetes/api/v2"
+	"gopkg.in/inf.v0"
+)
+
+// currencyScales lists currencies whose minor unit is not the cent.
+var currencyScales = map[string]inf.Scale{
+	"BIF": 0, "CLP": 0, "DJF": 0, "GNF": 0, "ISK": 0, "JPY": 0, "KMF": 0, "KRW": 0,
+	"PYG": 0, "RWF": 0, "UGX": 0, "VND": 0, "VUV": 0, "XAF": 0, "XOF": 0, "XPF": 0,
+	"BHD": 3, "IQD": 3, "JOD": 3, "KWD": 3, "LYD": 3, "OMR": 3, "TND": 3,
+}
+
+// LineTotal holds the amounts of a single invoice line.
+type LineTotal struct {
+	Net   *inf.Dec
+	VAT   *inf.Dec
+	Gross *inf.Dec
+}
+
+// RateTotal holds the amounts of all lines sharing a VAT rate.
+type RateTotal struct {
+	Rate *inf.Dec
+	Net  *inf.Dec
+	VAT  *inf.Dec
+}
+
+// Totals of an invoice.
+type Totals struct {
+	Scale inf.Scale
+	Lines []LineTotal
+	// VAT breakdown ordered by rate.
+	VAT   []RateTotal
+	Net   *inf.Dec
+	Tax   *inf.Dec
+	Gross *inf.Dec
+}
+
+// Parse parses a decimal amount.
+func Parse(value facturnetesv2.Decimal) (*inf.Dec, error) {
+	d, ok := new(inf.Dec).SetString(strings.TrimSpace(string(value)))
+	if !ok {
+		return nil, fmt.Errorf("invalid decimal %q", value)
+	}
+	return d, nil
+}
+
+// CurrencyScale returns the number of decimal places of the currency minor unit.
+func CurrencyScale(currency string) inf.Scale {
+	if s, ok := currencyScales[strings.ToUpper(currency)]; ok {
+		return s
+	}
+	return 2
+}
+
+// Format formats an amount with the given number of decimal places.
+func Format(d *inf.Dec, scale inf.Scale) string {
+	return new(inf.Dec).Round(d, scale, inf.RoundHalfUp).String()
+}
+
+func rounder(mode facturnetesv2.RoundingMode) inf.Rounder {
+	if mode == facturnetesv2.RoundHalfEven {
+		return inf.RoundHalfEven
+	}
+	return inf.RoundHalfUp
+}
+
+// percentOf returns rate percent of amount, exactly.
+func percentOf(amount, rate *inf.Dec) *inf.Dec {
+	d := new(inf.Dec).Mul(amount, rate)
+	return d.SetScale(d.Scale() + 2)
+}
+
+// Compute computes the line, VAT and grand totals of the invoice data.
+func Compute(data *facturnetesv2.InvoiceData) (*Totals, error) {
+	scale := CurrencyScale(data.Currency)
+	round := rounder(data.Rounding.Mode)
+	perDocument := data.Rounding.Scope == facturnetesv2.RoundPerDocument
+
+	totals := &Totals{
+		Scale: scale,
+		Net:   new(inf.Dec),
+		Tax:   new(inf.Dec),
+		Gross: new(inf.Dec),
+	}
+	rates := map[string]*RateTotal{}
+
+	for i, item := range data.Items {
+		if item == nil {
+			continue
+		}
+		quantity, err := Parse(item.Quantity)
+		if err != nil {
+			return nil, fmt.Errorf("item %d quantity: %w", i+1, err)
+		}
+		price, err := Parse(item.UnitPrice)
+		if err != nil {
+			return nil, fmt.Errorf("item %d unit price: %w", i+1, err)
+		}
+		rate, err := Parse(item.VATRate)
+		if err != nil {
+			return nil, fmt.Errorf("item %d VAT rate: %w", i+1, err)
+		}
+
+		net := new(inf.Dec).Mul(quantity, price)
+		line := LineTotal{Net: new(inf.Dec).Round(net, scale, round)}
+		line.VAT = new(inf.Dec).Round(percentOf(line.Net, rate), scale, round)
+		line.Gross = new(inf.Dec).Add(line.Net, line.VAT)
+		totals.Lines = append(totals.Lines, line)
+
+		key := rate.String()
+		rt, ok := rates[key]
+		if !ok {
+			rt = &RateTotal{Rate: rate, Net: new(inf.Dec), VAT: new(inf.Dec)}
+			rates[key] = rt
+		}
+		if perDocument {
+			rt.Net.Add(rt.Net, net)
+		} else {
+			rt.Net.Add(rt.Net, line.Net)
+			rt.VAT.Add(rt.VAT, line.VAT)
+		}
+	}
+
+	for _, rt := range rates {
+		if perDocument {
+			rt.Net.Round(rt.Net, scale, round)
+			rt.VAT.Round(percentOf(rt.Net, rt.Rate), scale, round)
+		}
+		totals.Net.Add(totals.Net, rt.Net)
+		totals.Tax.Add(totals.Tax, rt.VAT)
+		totals.VAT = append(totals.VAT, *rt)
+	}
+	sort.Slice(totals.VAT, func(i, j int) bool {
+		return totals.VAT[i].Rate.Cmp(totals.VAT[j].Rate) < 0
+	})
+	totals.Gross.Add(totals.Net, totals.Tax)
+
+	return totals, nil
+}
+
+// Status returns the totals as published in the Invoice status.
+func (t *Totals) Status(currency string) *facturnetesv2.InvoiceTotals {
+	status := &facturnetesv2.InvoiceTotals{
+		Currency: currency,
+		Subtotal: facturnetesv2.Decimal(Format(t.Net, t.Scale)),
+		VATTotal: facturnetesv2.Decimal(Format(t.Tax, t.Scale)),
+		Total:    facturnetesv2.Decimal(Format(t.Gross, t.Scale)),
+	}
+	for _, rt := range t.VAT {
+		status.VAT = append(status.VAT, facturnetesv2.VATTotal{
+			Rate: facturnetesv2.Decimal(rt.Rate.String()),
+			Net:  facturnetesv2.Decimal(Format(rt.Net, t.Scale)),
+			VAT:  facturnetesv2.Decimal(Format(rt.VAT, t.Scale)),
+		})
+	}
+	return status
+}
+
+// Subtract returns the difference between two totals, per VAT rate and in
+// total. The line amounts are not kept.
+func Subtract(after, before *Totals) *Totals {
+	diff := &Totals{
+		Scale: after.Scale,
+		Net:   new(inf.Dec).Sub(after.Net, before.Net),
+		Tax:   new(inf.Dec).Sub(after.Tax, before.Tax),
+		Gross: new(inf.Dec).Sub(after.Gross, before.Gross),
+	}
+
+	rates := map[string]*RateTotal{}
+	add := func(rt RateTotal, sign int64) {
+		key := rt.Rate.String()
+		d, ok := rates[key]
+		if !ok {
+			d = &RateTotal{Rate: rt.Rate, Net: new(inf.Dec), VAT: new(inf.Dec)}
+			rates[key] = d
+		}
+		d.Net.Add(d.Net, new(inf.Dec).Mul(rt.Net, inf.NewDec(sign, 0)))
+		d.VAT.Add(d.VAT, new(inf.Dec).Mul(rt.VAT, inf.NewDec(sign, 0)))
+	}
+	for _, rt := range after.VAT {
+		add(rt, 1)
+	}
+	for _, rt := range before.VAT {
+		add(rt, -1)
+	}
+	for _, rt := range rates {
+		diff.VAT = append(diff.VAT, *rt)
+	}
+	sort.Slice(diff.VAT, func(i, j int) bool {
+		return diff.VAT[i].Rate.Cmp(diff.VAT[j].Rate) < 0
+	})
+
+	return diff
+}
@@ -0,0 +1,88 @@
+package money
+
+import (
+	"testing"
+
+	facturnetesv2 "github.com/cnvergence/facturnetes/api/v2"
+)
+
+func TestCompute(t *testing.T) {
+	items := []*facturnetesv2.Item{
+		{Description: "a", Quantity: "3", UnitPrice: "0.1", VATRate: "23"},
+		{Description: "b", Quantity: "1", UnitPrice: "0.125", VATRate: "23"},
+		{Description: "c", Quantity: "1", UnitPrice: "0.135", VATRate: "23"},
+		{Description: "d", Quantity: "11", UnitPrice: "2", VATRate: "0"},
+		{Description: "e", Quantity: "1", UnitPrice: "0.10", VATRate: "5"},
+		{Description: "f", Quantity: "1", UnitPrice: "0.10", VATRate: "5"},
+		{Description: "g", Quantity: "1", UnitPrice: "0.10", VATRate: "5"},
+	}
+
+	tests := []struct {
+		name     string
+		rounding facturnetesv2.Rounding
+		net      string
+		tax      string
+		gross    string
+	}{{
+		name:     "per line half up",
+		rounding: facturnetesv2.Rounding{Mode: facturnetesv2.RoundHalfUp, Scope: facturnetesv2.RoundPerLine},
+		net:      "22.87",
+		tax:      "0.16",
+		gross:    "23.03",
+	}, {
+		name:     "per line half even",
+		rounding: facturnetesv2.Rounding{Mode: facturnetesv2.RoundHalfEven, Scope: facturnetesv2.RoundPerLine},
+		net:      "22.86",
+		tax:      "0.13",
+		gross:    "22.99",
+	}, {
+		name:     "per document half up",
+		rounding: facturnetesv2.Rounding{Mode: facturnetesv2.RoundHalfUp, Scope: facturnetesv2.RoundPerDocument},
+		net:      "22.86",
+		tax:      "0.15",
+		gross:    "23.01",
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			totals, err := Compute(&facturnetesv2.InvoiceData{Currency: "EUR", Items: items, Rounding: tt.rounding})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := Format(totals.Net, totals.Scale); got != tt.net {
+				t.Errorf("net = %s, want %s", got, tt.net)
+			}
+			if got := Format(totals.Tax, totals.Scale); got != tt.tax {
+				t.Errorf("tax = %s, want %s", got, tt.tax)
+			}
+			if got := Format(totals.Gross, totals.Scale); got != tt.gross {
+				t.Errorf("gross = %s, want %s", got, tt.gross)
+			}
+		})
+	}
+}
+
+func TestSubtract(t *testing.T) {
+	before, err := Compute(&facturnetesv2.InvoiceData{Items: []*facturnetesv2.Item{
+		{Description: "a", Quantity: "2", UnitPrice: "10", VATRate: "23"},
+		{Description: "b", Quantity: "1", UnitPrice: "5", VATRate: "8"},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	after, err := Compute(&facturnetesv2.InvoiceData{Items: []*facturnetesv2.Item{
+		{Description: "a", Quantity: "1", UnitPrice: "10", VATRate: "23"},
+		{Description: "b", Quantity: "0", UnitPrice: "5", VATRate: "8"},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	diff := Subtract(after, before).Status("PLN")
+	if diff.Subtotal != "-15.00" || diff.VATTotal != "-2.70" || diff.Total != "-17.70" {
+		t.Errorf("Subtract() = %s net, %s VAT, %s gross, want -15.00, -2.70, -17.70", diff.Subtotal, diff.VATTotal, diff.Total)
+	}
+	if len(diff.VAT) != 2 || diff.VAT[0].VAT != "-0.40" || diff.VAT[1].VAT != "-2.30" {
+		t.Errorf("Subtract() VAT = %v", diff.VAT)
+	}
+}
@@ -3,7 +3,7 @@ package resource
 import (
 	"fmt"
 
-	facturnetesv1 "github.com/cnvergence/facturnetes/api/v1"
+	facturnetesv2 "github.com/cnvergence/facturnetes/api/v2"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -15,7 +15,7 @@ const (
 	defaultImageName      = "viewer:latest"
 )
 
-func Deployment(invoice *facturnetesv1.Invoice) *appsv1.Deployment {
+func Deployment(invoice *facturnetesv2.Invoice) *appsv1.Deployment {
 	deploymentName := invoice.Spec.Deployment.Name
 	if invoice.Spec.Deployment.Name == "" {
 		deploymentName = defaultDeploymentName
@@ -48,7 +48,7 @@ func Deployment(invoice *facturnetesv1.Invoice) *appsv1.Deployment {
 							Secret: &corev1.SecretVolumeSource{
 								SecretName: invoice.Name,
 								Items: []corev1.KeyToPath{{
-									Key:  "pdf",
+									Key:  ViewerKey(invoice),
 									Path: "test.pdf",
 								},
 								},
@@ -80,7 +80,7 @@ func Deployment(invoice *facturnetesv1.Invoice) *appsv1.Deployment {
 	}
 }
 
-func Service(invoice *facturnetesv1.Invoice) *corev1.Service {
+func Service(invoice *facturnetesv2.Invoice) *corev1.Service {
 	labels := Labels(invoice)
 	return &corev1.Service{
 		ObjectMeta: metav1.ObjectMeta{
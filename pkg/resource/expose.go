@@ -4,12 +4,12 @@ import (
 	"net/url"
 	"strings"
 
-	facturnetesv1 "github.com/cnvergence/facturnetes/api/v1"
+	facturnetesv2 "github.com/cnvergence/facturnetes/api/v2"
 	networkingv1 "k8s.io/api/networking/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
-func Ingress(invoice *facturnetesv1.Invoice) *networkingv1.Ingress {
+func Ingress(invoice *facturnetesv2.Invoice) *networkingv1.Ingress {
 	u, err := url.Parse(invoice.Spec.Exposure.PublicURL)
 	if err != nil {
 		return &networkingv1.Ingress{}
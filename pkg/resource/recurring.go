@@ -0,0 +1,89 @@
+package resource
+
+import (
+	"bytes"
+	"fmt"
+	"text/template"
+	"time"
+
+	facturnetesv2 "github.com/cnvergence/facturnetes/api/v2"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// RecurringInvoiceLabel names the RecurringInvoice which created an invoice.
+	RecurringInvoiceLabel = "facturnetes.cnvergence.io/recurring-invoice"
+	// ScheduledTimeAnnotation keeps the scheduled time of a recurring invoice.
+	ScheduledTimeAnnotation = "facturnetes.cnvergence.io/scheduled-time"
+)
+
+// RecurringInvoiceName returns the name of the invoice scheduled at the given
+// time, so that an invoice is created only once per run.
+func RecurringInvoiceName(ri *facturnetesv2.RecurringInvoice, scheduled time.Time) string {
+	return fmt.Sprintf("%s-%d", ri.Name, scheduled.Unix()/60)
+}
+
+// RecurringInvoice returns the invoice created by the RecurringInvoice for the
+// scheduled time, given in the time zone of the schedule.
+func RecurringInvoice(ri *facturnetesv2.RecurringInvoice, scheduled time.Time) (*facturnetesv2.Invoice, error) {
+	labels := map[string]string{}
+	for k, v := range ri.Spec.Template.Metadata.Labels {
+		labels[k] = v
+	}
+	labels[RecurringInvoiceLabel] = ri.Name
+	annotations := map[string]string{}
+	for k, v := range ri.Spec.Template.Metadata.Annotations {
+		annotations[k] = v
+	}
+	annotations[ScheduledTimeAnnotation] = scheduled.Format(time.RFC3339)
+
+	invoice := &facturnetesv2.Invoice{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        RecurringInvoiceName(ri, scheduled),
+			Namespace:   ri.Namespace,
+			Labels:      labels,
+			Annotations: annotations,
+		},
+		Spec: *ri.Spec.Template.Spec.DeepCopy(),
+	}
+
+	data := &invoice.Spec.InvoiceData
+	vars := struct{ IssueDate time.Time }{scheduled}
+	expand := func(field, text string) (string, error) {
+		tmpl, err := template.New(field).Option("missingkey=error").Parse(text)
+		if err != nil {
+			return "", fmt.Errorf("template of %s: %w", field, err)
+		}
+		var out bytes.Buffer
+		if err := tmpl.Execute(&out, vars); err != nil {
+			return "", fmt.Errorf("template of %s: %w", field, err)
+		}
+		return out.String(), nil
+	}
+
+	var err error
+	data.Number = ""
+	data.IssueDate = scheduled.Format(facturnetesv2.DateLayout)
+	if data.SaleDate, err = expand("saleDate", data.SaleDate); err != nil {
+		return nil, err
+	}
+	if data.DueDate, err = expand("dueDate", data.DueDate); err != nil {
+		return nil, err
+	}
+	if data.DueDate == "" && ri.Spec.PaymentTermDays != nil {
+		data.DueDate = scheduled.AddDate(0, 0, int(*ri.Spec.PaymentTermDays)).Format(facturnetesv2.DateLayout)
+	}
+	if data.Notes, err = expand("notes", data.Notes); err != nil {
+		return nil, err
+	}
+	for i, item := range data.Items {
+		if item == nil {
+			continue
+		}
+		if item.Description, err = expand(fmt.Sprintf("items[%d].description", i), item.Description); err != nil {
+			return nil, err
+		}
+	}
+
+	return invoice, nil
+}
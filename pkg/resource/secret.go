@@ -1,19 +1,106 @@
 package resource
 
 import (
-	facturnetesv1 "github.com/cnvergence/facturnetes/api/v1"
+	facturnetesv2 "github.com/cnvergence/facturnetes/api/v2"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
-func Secret(invoice *facturnetesv1.Invoice, data []byte) *corev1.Secret {
+// Keys of the documents in the invoice Secret.
+const (
+	// PDFKey is the key of the PDF document.
+	PDFKey = "pdf"
+	// ViewerPDFKey is the key of the stamped copy served by the viewer.
+	ViewerPDFKey = "viewer-pdf"
+	// UBLKey is the key of the UBL 2.1 Invoice XML.
+	UBLKey = "ubl.xml"
+	// CIIKey is the key of the CII invoice XML.
+	CIIKey = "cii.xml"
+	// KSeFKey is the key of the KSeF FA(2) invoice XML.
+	KSeFKey = "ksef.xml"
+	// FatturaPAKey is the key of the FatturaPA invoice XML.
+	FatturaPAKey = "fatturapa.xml"
+)
+
+// Secret returns the Secret keeping the documents of the invoice by key.
+// Documents that are nil are left out.
+func Secret(invoice *facturnetesv2.Invoice, documents map[string][]byte) *corev1.Secret {
 	labels := Labels(invoice)
-	return &corev1.Secret{
+	secret := &corev1.Secret{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      invoice.Name,
 			Namespace: invoice.Namespace,
 			Labels:    labels,
 		},
-		Data: map[string][]byte{"pdf": data},
+		Data: map[string][]byte{},
+	}
+	for key, data := range documents {
+		if data != nil {
+			secret.Data[key] = data
+		}
+	}
+	return secret
+}
+
+// ViewerKey returns the key of the document served by the viewer.
+func ViewerKey(invoice *facturnetesv2.Invoice) string {
+	if invoice.Spec.Deployment.PaidStamp && invoice.Status.State == facturnetesv2.Paid {
+		return ViewerPDFKey
+	}
+	return PDFKey
+}
+
+// CreditNoteSecret returns the Secret keeping the PDF of the credit note.
+func CreditNoteSecret(note *facturnetesv2.CreditNote, data []byte) *corev1.Secret {
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      CreditNoteSecretName(note),
+			Namespace: note.Namespace,
+			Labels:    map[string]string{"app": note.Name},
+		},
+		Data: map[string][]byte{PDFKey: data},
+	}
+}
+
+// CreditNoteSecretName returns the name of the Secret of the credit note,
+// apart from the Secrets of the invoices.
+func CreditNoteSecretName(note *facturnetesv2.CreditNote) string {
+	return note.Name + "-creditnote"
+}
+
+// InterestNoteSecret returns the Secret keeping the PDF of the interest note.
+func InterestNoteSecret(note *facturnetesv2.InterestNote, data []byte) *corev1.Secret {
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      InterestNoteSecretName(note),
+			Namespace: note.Namespace,
+			Labels:    map[string]string{"app": note.Name},
+		},
+		Data: map[string][]byte{PDFKey: data},
+	}
+}
+
+// InterestNoteSecretName returns the name of the Secret of the interest
+// note, apart from the Secrets of the invoices.
+func InterestNoteSecretName(note *facturnetesv2.InterestNote) string {
+	return note.Name + "-interestnote"
+}
+
+// ReminderSecret returns the Secret keeping the PDF of the reminder of a
+// dunning level for the invoice.
+func ReminderSecret(invoice *facturnetesv2.Invoice, level string, data []byte) *corev1.Secret {
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      ReminderSecretName(invoice, level),
+			Namespace: invoice.Namespace,
+			Labels:    Labels(invoice),
+		},
+		Data: map[string][]byte{PDFKey: data},
 	}
 }
+
+// ReminderSecretName returns the name of the Secret of the reminder of a
+// dunning level for the invoice.
+func ReminderSecretName(invoice *facturnetesv2.Invoice, level string) string {
+	return invoice.Name + "-" + level
+}
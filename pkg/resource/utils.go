@@ -1,8 +1,8 @@
 package resource
 
-import facturnetesv1 "github.com/cnvergence/facturnetes/api/v1"
+import facturnetesv2 "github.com/cnvergence/facturnetes/api/v2"
 
-func Labels(invoice *facturnetesv1.Invoice) map[string]string {
+func Labels(invoice *facturnetesv2.Invoice) map[string]string {
 	return map[string]string{
 		"app": invoice.Name,
 	}
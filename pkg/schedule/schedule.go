@@ -0,0 +1,37 @@
+// Package schedule computes the runs of cron schedules in a time zone.
+package schedule
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/robfig/cron/v3"
+)
+
+// Parse parses a standard cron schedule running in the time zone, UTC when empty.
+func Parse(spec, timeZone string) (cron.Schedule, *time.Location, error) {
+	if timeZone == "" {
+		timeZone = "UTC"
+	}
+	loc, err := time.LoadLocation(timeZone)
+	if err != nil {
+		return nil, nil, fmt.Errorf("unknown time zone %q: %w", timeZone, err)
+	}
+	sched, err := cron.ParseStandard(spec)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid schedule %q: %w", spec, err)
+	}
+	if s, ok := sched.(*cron.SpecSchedule); ok {
+		s.Location = loc
+	}
+	return sched, loc, nil
+}
+
+// Runs returns the latest scheduled time after last and not after now, zero
+// when no run was scheduled, and the next scheduled time after now.
+func Runs(sched cron.Schedule, last, now time.Time) (latest, next time.Time) {
+	for t := sched.Next(last); !t.IsZero() && !t.After(now); t = sched.Next(t) {
+		latest = t
+	}
+	return latest, sched.Next(now)
+}
@@ -0,0 +1,42 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRuns(t *testing.T) {
+	sched, loc, err := Parse("0 6 1 * *", "Europe/Warsaw")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	last := time.Date(2022, 1, 1, 6, 0, 0, 0, loc)
+	now := time.Date(2022, 3, 15, 12, 0, 0, 0, time.UTC)
+	latest, next := Runs(sched, last, now)
+
+	if want := time.Date(2022, 3, 1, 6, 0, 0, 0, loc); !latest.Equal(want) {
+		t.Errorf("latest = %s, want %s", latest, want)
+	}
+	if want := time.Date(2022, 4, 1, 6, 0, 0, 0, loc); !next.Equal(want) {
+		t.Errorf("next = %s, want %s", next, want)
+	}
+	// 6:00 in Warsaw is 5:00 UTC in winter and 4:00 UTC in summer.
+	if next.UTC().Hour() != 4 || latest.UTC().Hour() != 5 {
+		t.Errorf("runs are not in the time zone: latest %s, next %s", latest.UTC(), next.UTC())
+	}
+
+	latest, _ = Runs(sched, time.Date(2022, 3, 1, 6, 0, 0, 0, loc), now)
+	if !latest.IsZero() {
+		t.Errorf("latest = %s, want no run", latest)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	if _, _, err := Parse("0 6 1 * *", "Mars/Olympus"); err == nil {
+		t.Error("expected an error for an unknown time zone")
+	}
+	if _, _, err := Parse("every monday", "UTC"); err == nil {
+		t.Error("expected an error for an invalid schedule")
+	}
+}
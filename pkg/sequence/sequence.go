@@ -0,0 +1,198 @@
+// Package sequence allocates invoice numbers from InvoiceSequence objects.
+package sequence
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	facturnetesv2 "github.com/cnvergence/facturnetes/api/v2"
+)
+
+var token = regexp.MustCompile(`\{(YYYY|YY|MM|DD|seq(:([0-9]+))?)\}`)
+
+// Period returns the reset period the date belongs to.
+func Period(reset facturnetesv2.ResetPeriod, date time.Time) string {
+	switch reset {
+	case facturnetesv2.ResetYearly:
+		return date.Format("2006")
+	case facturnetesv2.ResetMonthly:
+		return date.Format("2006-01")
+	}
+	return ""
+}
+
+// Check returns an error when the format cannot produce unique numbers with
+// the reset period, e.g. a monthly reset without the month in the number.
+func Check(format string, reset facturnetesv2.ResetPeriod) error {
+	found := map[string]bool{}
+	for _, m := range token.FindAllStringSubmatch(format, -1) {
+		name := m[1]
+		if strings.HasPrefix(name, "seq") {
+			name = "seq"
+		}
+		found[name] = true
+	}
+
+	year := found["YYYY"] || found["YY"]
+	switch {
+	case !found["seq"]:
+		return fmt.Errorf("format %q has no {seq} counter", format)
+	case reset == facturnetesv2.ResetYearly && !year:
+		return fmt.Errorf("format %q of a yearly sequence has no year", format)
+	case reset == facturnetesv2.ResetMonthly && !(year && found["MM"]):
+		return fmt.Errorf("format %q of a monthly sequence has no year and month", format)
+	}
+	return nil
+}
+
+// Format returns the invoice number of the counter value issued on the date.
+func Format(format string, date time.Time, seq int64) string {
+	return token.ReplaceAllStringFunc(format, func(t string) string {
+		m := token.FindStringSubmatch(t)
+		switch m[1] {
+		case "YYYY":
+			return date.Format("2006")
+		case "YY":
+			return date.Format("06")
+		case "MM":
+			return date.Format("01")
+		case "DD":
+			return date.Format("02")
+		}
+		width, _ := strconv.Atoi(m[3])
+		return fmt.Sprintf("%0*d", width, seq)
+	})
+}
+
+// Allocate advances the counter of the period of the date and returns the new
+// invoice number. Only the status of the sequence is changed; the caller must
+// persist it with optimistic concurrency before using the number.
+func Allocate(s *facturnetesv2.InvoiceSequence, date time.Time) (string, error) {
+	if err := Check(s.Spec.Format, s.Spec.Reset); err != nil {
+		return "", err
+	}
+
+	period := Period(s.Spec.Reset, date)
+	var counter *facturnetesv2.SequencePeriod
+	for i := range s.Status.Periods {
+		if s.Status.Periods[i].Period == period {
+			counter = &s.Status.Periods[i]
+			break
+		}
+	}
+	if counter == nil {
+		s.Status.Periods = append(s.Status.Periods, facturnetesv2.SequencePeriod{Period: period})
+		counter = &s.Status.Periods[len(s.Status.Periods)-1]
+	}
+
+	counter.Last++
+	number := Format(s.Spec.Format, date, counter.Last)
+	s.Status.LastNumber = number
+
+	return number, nil
+}
+
+// maxMissing bounds the missing values listed for a period.
+const maxMissing = 100
+
+// Parse returns the period and the counter value of a number written in the
+// format. ok is false when the number does not match the format.
+func Parse(format string, reset facturnetesv2.ResetPeriod, number string) (period string, seq int64, ok bool) {
+	var pattern strings.Builder
+	var fields []string
+	last := 0
+	pattern.WriteString("^")
+	for _, loc := range token.FindAllStringSubmatchIndex(format, -1) {
+		pattern.WriteString(regexp.QuoteMeta(format[last:loc[0]]))
+		name := format[loc[2]:loc[3]]
+		switch name {
+		case "YYYY":
+			pattern.WriteString(`([0-9]{4})`)
+		case "YY", "MM", "DD":
+			pattern.WriteString(`([0-9]{2})`)
+		default:
+			name = "seq"
+			pattern.WriteString(`([0-9]+)`)
+		}
+		fields = append(fields, name)
+		last = loc[1]
+	}
+	pattern.WriteString(regexp.QuoteMeta(format[last:]))
+	pattern.WriteString("$")
+
+	m := regexp.MustCompile(pattern.String()).FindStringSubmatch(number)
+	if m == nil {
+		return "", 0, false
+	}
+	values := map[string]string{}
+	for i, name := range fields {
+		values[name] = m[i+1]
+	}
+	seq, err := strconv.ParseInt(values["seq"], 10, 64)
+	if err != nil {
+		return "", 0, false
+	}
+
+	year := values["YYYY"]
+	if year == "" && values["YY"] != "" {
+		year = "20" + values["YY"]
+	}
+	switch reset {
+	case facturnetesv2.ResetYearly:
+		period = year
+	case facturnetesv2.ResetMonthly:
+		period = year + "-" + values["MM"]
+	}
+	return period, seq, true
+}
+
+// Gaps returns the counter values missing from the numbers of the sequence,
+// up to the last value allocated or used in each period.
+func Gaps(s *facturnetesv2.InvoiceSequence, numbers []string) []facturnetesv2.SequenceGap {
+	highest := map[string]int64{}
+	used := map[string]map[int64]bool{}
+	for _, p := range s.Status.Periods {
+		highest[p.Period] = p.Last
+	}
+	for _, number := range numbers {
+		period, seq, ok := Parse(s.Spec.Format, s.Spec.Reset, number)
+		if !ok {
+			continue
+		}
+		if used[period] == nil {
+			used[period] = map[int64]bool{}
+		}
+		used[period][seq] = true
+		if seq > highest[period] {
+			highest[period] = seq
+		}
+	}
+
+	periods := make([]string, 0, len(highest))
+	for period := range highest {
+		periods = append(periods, period)
+	}
+	sort.Strings(periods)
+
+	var gaps []facturnetesv2.SequenceGap
+	for _, period := range periods {
+		gap := facturnetesv2.SequenceGap{Period: period, Missing: []int64{}}
+		for seq := int64(1); seq <= highest[period]; seq++ {
+			if used[period][seq] {
+				continue
+			}
+			gap.Count++
+			if len(gap.Missing) < maxMissing {
+				gap.Missing = append(gap.Missing, seq)
+			}
+		}
+		if gap.Count > 0 {
+			gaps = append(gaps, gap)
+		}
+	}
+	return gaps
+}
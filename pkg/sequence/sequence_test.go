@@ -0,0 +1,101 @@
+package sequence
+
+import (
+	"testing"
+	"time"
+
+	facturnetesv2 "github.com/cnvergence/facturnetes/api/v2"
+)
+
+func date(s string) time.Time {
+	t, err := facturnetesv2.ParseDate(s)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func TestAllocate(t *testing.T) {
+	seq := &facturnetesv2.InvoiceSequence{
+		Spec: facturnetesv2.InvoiceSequenceSpec{Format: "FV/{YYYY}/{MM}/{seq:4}", Reset: facturnetesv2.ResetMonthly},
+	}
+
+	for _, tt := range []struct {
+		date   string
+		number string
+	}{
+		{"05-01-2022", "FV/2022/01/0001"},
+		{"31-01-2022", "FV/2022/01/0002"},
+		{"01-02-2022", "FV/2022/02/0001"},
+		// Backdated invoices continue the counter of their own period.
+		{"20-01-2022", "FV/2022/01/0003"},
+		{"01-02-2022", "FV/2022/02/0002"},
+	} {
+		number, err := Allocate(seq, date(tt.date))
+		if err != nil {
+			t.Fatalf("Allocate(%s): %v", tt.date, err)
+		}
+		if number != tt.number {
+			t.Errorf("Allocate(%s) = %s, want %s", tt.date, number, tt.number)
+		}
+	}
+	if seq.Status.LastNumber != "FV/2022/02/0002" {
+		t.Errorf("LastNumber = %s", seq.Status.LastNumber)
+	}
+}
+
+func TestFormat(t *testing.T) {
+	for _, tt := range []struct {
+		format string
+		seq    int64
+		want   string
+	}{
+		{"{seq}", 7, "7"},
+		{"INV-{YY}{MM}{DD}-{seq:3}", 42, "INV-220315-042"},
+		{"{YYYY}/{seq:2}", 1234, "2022/1234"},
+	} {
+		if got := Format(tt.format, date("15-03-2022"), tt.seq); got != tt.want {
+			t.Errorf("Format(%q, %d) = %s, want %s", tt.format, tt.seq, got, tt.want)
+		}
+	}
+}
+
+func TestCheck(t *testing.T) {
+	for _, tt := range []struct {
+		format string
+		reset  facturnetesv2.ResetPeriod
+		valid  bool
+	}{
+		{"FV/{YYYY}/{MM}/{seq:4}", facturnetesv2.ResetMonthly, true},
+		{"FV/{YYYY}/{seq}", facturnetesv2.ResetYearly, true},
+		{"FV/{seq}", facturnetesv2.ResetNever, true},
+		{"FV/{YYYY}/{seq}", facturnetesv2.ResetMonthly, false},
+		{"FV/{MM}/{seq}", facturnetesv2.ResetYearly, false},
+		{"FV/{YYYY}", facturnetesv2.ResetNever, false},
+	} {
+		if err := Check(tt.format, tt.reset); (err == nil) != tt.valid {
+			t.Errorf("Check(%q, %s) = %v, want valid %t", tt.format, tt.reset, err, tt.valid)
+		}
+	}
+}
+
+func TestGaps(t *testing.T) {
+	seq := &facturnetesv2.InvoiceSequence{
+		Spec: facturnetesv2.InvoiceSequenceSpec{Format: "FV/{YYYY}/{seq:3}", Reset: facturnetesv2.ResetYearly},
+		Status: facturnetesv2.InvoiceSequenceStatus{
+			Periods: []facturnetesv2.SequencePeriod{{Period: "2022", Last: 6}},
+		},
+	}
+	numbers := []string{"FV/2022/001", "FV/2022/002", "FV/2022/004", "FV/2021/003", "manual-7", "FV/2022/006"}
+
+	gaps := Gaps(seq, numbers)
+	if len(gaps) != 2 {
+		t.Fatalf("Gaps() = %v, want 2 periods", gaps)
+	}
+	if gaps[0].Period != "2021" || gaps[0].Count != 2 || len(gaps[0].Missing) != 2 {
+		t.Errorf("2021 gap = %+v, want 1 and 2 missing", gaps[0])
+	}
+	if gaps[1].Period != "2022" || gaps[1].Count != 2 || gaps[1].Missing[0] != 3 || gaps[1].Missing[1] != 5 {
+		t.Errorf("2022 gap = %+v, want 3 and 5 missing", gaps[1])
+	}
+}